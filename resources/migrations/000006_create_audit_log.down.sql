@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS audit_log_video_id_idx;
+
+DROP TABLE IF EXISTS audit_log;
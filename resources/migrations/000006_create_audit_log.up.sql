@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id         bigserial PRIMARY KEY,
+    video_id   uuid        NOT NULL,
+    action     text        NOT NULL,
+    actor      text        NOT NULL,
+    details    text,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_video_id_idx ON audit_log (video_id);
@@ -0,0 +1 @@
+ALTER TABLE videos DROP COLUMN IF EXISTS converted_size_bytes;
@@ -0,0 +1,2 @@
+ALTER TABLE videos
+  ADD COLUMN converted_size_bytes bigint;
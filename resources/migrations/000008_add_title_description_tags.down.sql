@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS video_tags;
+DROP TABLE IF EXISTS tags;
+
+ALTER TABLE videos
+  DROP COLUMN IF EXISTS title,
+  DROP COLUMN IF EXISTS description;
@@ -0,0 +1,18 @@
+ALTER TABLE videos
+  ADD COLUMN title text NOT NULL DEFAULT '',
+  ADD COLUMN description text NOT NULL DEFAULT '';
+
+UPDATE videos SET title = regexp_replace(filename, '\.[^.]*$', '');
+
+CREATE TABLE IF NOT EXISTS tags (
+    id   bigserial PRIMARY KEY,
+    name text NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS video_tags (
+    video_id uuid   NOT NULL REFERENCES videos (id) ON DELETE CASCADE,
+    tag_id   bigint NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (video_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS video_tags_tag_id_idx ON video_tags (tag_id);
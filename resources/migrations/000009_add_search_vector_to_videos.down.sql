@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS videos_search_vector_idx;
+
+ALTER TABLE videos
+  DROP COLUMN IF EXISTS search_vector,
+  DROP COLUMN IF EXISTS search_language,
+  DROP COLUMN IF EXISTS tags_text;
@@ -0,0 +1,21 @@
+ALTER TABLE videos
+  ADD COLUMN tags_text text NOT NULL DEFAULT '',
+  ADD COLUMN search_language regconfig NOT NULL DEFAULT 'simple';
+
+UPDATE videos v
+SET tags_text = COALESCE((
+    SELECT string_agg(t.name, ' ' ORDER BY t.name)
+    FROM video_tags vt
+    JOIN tags t ON t.id = vt.tag_id
+    WHERE vt.video_id = v.id
+), '');
+
+ALTER TABLE videos
+  ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector(search_language, coalesce(title, '')), 'A') ||
+    setweight(to_tsvector(search_language, coalesce(tags_text, '')), 'B') ||
+    setweight(to_tsvector(search_language, coalesce(description, '')), 'C') ||
+    setweight(to_tsvector('simple'::regconfig, coalesce(filename, '')), 'D')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS videos_search_vector_idx ON videos USING GIN (search_vector);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS collections (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    title          text        NOT NULL,
+    description    text        NOT NULL DEFAULT '',
+    cover_video_id uuid        NULL REFERENCES videos (id) ON DELETE SET NULL,
+    created_at     timestamptz NOT NULL DEFAULT now(),
+    updated_at     timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS collection_videos (
+    collection_id uuid        NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    video_id      uuid        NOT NULL REFERENCES videos (id) ON DELETE CASCADE,
+    position      int         NOT NULL,
+    added_at      timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (collection_id, video_id)
+);
+
+CREATE INDEX IF NOT EXISTS collection_videos_position_idx ON collection_videos (collection_id, position);
+CREATE INDEX IF NOT EXISTS collection_videos_video_id_idx ON collection_videos (video_id);
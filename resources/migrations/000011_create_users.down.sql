@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS videos_owner_id_idx;
+
+ALTER TABLE videos DROP COLUMN IF EXISTS owner_id;
+
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS users;
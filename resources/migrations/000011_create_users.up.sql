@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS users (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    email         text        NOT NULL UNIQUE,
+    password_hash text        NOT NULL,
+    role          text        NOT NULL DEFAULT 'user'
+        CHECK (role IN ('user', 'admin')),
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    updated_at    timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash text        NOT NULL UNIQUE,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+ALTER TABLE videos
+  ADD COLUMN owner_id uuid NULL REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS videos_owner_id_idx ON videos (owner_id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name         text        NOT NULL,
+    prefix       text        NOT NULL UNIQUE,
+    key_hash     text        NOT NULL,
+    scopes       text[]      NOT NULL DEFAULT '{}',
+    owner_id     uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at   timestamptz NULL,
+    last_used_at timestamptz NULL,
+    revoked_at   timestamptz NULL,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_owner_id_idx ON api_keys (owner_id);
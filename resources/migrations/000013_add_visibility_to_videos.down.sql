@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS videos_public_created_at_idx;
+DROP INDEX IF EXISTS videos_slug_idx;
+
+ALTER TABLE videos DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,7 @@
+ALTER TABLE videos
+    ADD COLUMN IF NOT EXISTS visibility text NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('public', 'unlisted', 'private'));
+
+CREATE INDEX IF NOT EXISTS videos_slug_idx ON videos (slug);
+CREATE INDEX IF NOT EXISTS videos_public_created_at_idx ON videos (created_at DESC)
+    WHERE visibility = 'public' AND archived_at IS NULL;
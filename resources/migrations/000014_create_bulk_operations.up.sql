@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS bulk_operations (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    operation   text        NOT NULL
+        CHECK (operation IN ('archive', 'restore', 'reprocess', 'tag', 'set_visibility')),
+    params      jsonb       NOT NULL DEFAULT '{}',
+    principal   jsonb       NOT NULL DEFAULT '{}',
+    created_by  uuid        NULL REFERENCES users (id) ON DELETE SET NULL,
+    status      text        NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'completed')),
+    total       int         NOT NULL DEFAULT 0,
+    succeeded   int         NOT NULL DEFAULT 0,
+    failed      int         NOT NULL DEFAULT 0,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    started_at  timestamptz NULL,
+    finished_at timestamptz NULL
+);
+
+CREATE INDEX IF NOT EXISTS bulk_operations_status_idx ON bulk_operations (status)
+    WHERE status <> 'completed';
+
+-- video_id deliberately has no foreign key so results survive a purge
+CREATE TABLE IF NOT EXISTS bulk_operation_items (
+    operation_id uuid        NOT NULL REFERENCES bulk_operations (id) ON DELETE CASCADE,
+    video_id     uuid        NOT NULL,
+    position     int         NOT NULL,
+    status       text        NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'succeeded', 'failed')),
+    error        text        NULL,
+    code         text        NULL,
+    updated_at   timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (operation_id, video_id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE videos
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
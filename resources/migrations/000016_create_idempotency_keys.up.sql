@@ -0,0 +1,18 @@
+-- owner is the user id of the caller: keys are only unique per user
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    owner         text        NOT NULL,
+    key           text        NOT NULL,
+    method        text        NOT NULL,
+    path          text        NOT NULL,
+    fingerprint   text        NOT NULL,
+    status_code   int         NULL,
+    response_type text        NULL,
+    headers       jsonb       NOT NULL DEFAULT '{}',
+    body          bytea       NULL,
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    completed_at  timestamptz NULL,
+    expires_at    timestamptz NOT NULL,
+    PRIMARY KEY (owner, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS video_revisions;
+
+ALTER TABLE videos DROP COLUMN IF EXISTS revision;
@@ -0,0 +1,32 @@
+ALTER TABLE videos
+    ADD COLUMN IF NOT EXISTS revision integer NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS video_revisions (
+    id                   uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    video_id             uuid        NOT NULL REFERENCES videos (id) ON DELETE CASCADE,
+    number               integer     NOT NULL,
+    filename             text        NOT NULL,
+    size_bytes           bigint      NOT NULL DEFAULT 0,
+    duration_s           integer     NULL,
+    status               text        NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'ready', 'failed')),
+    failure_reason       text        NULL,
+    converted_size_bytes bigint      NULL,
+    created_by           uuid        NULL REFERENCES users (id) ON DELETE SET NULL,
+    created_at           timestamptz NOT NULL DEFAULT now(),
+    ready_at             timestamptz NULL,
+    UNIQUE (video_id, number)
+);
+
+-- every existing video becomes its own first revision
+INSERT INTO video_revisions (video_id, number, filename, size_bytes, duration_s, status,
+                             converted_size_bytes, created_by, created_at, ready_at)
+SELECT id, 1, filename, size_bytes, duration_s,
+       CASE
+           WHEN hls_ready_at IS NOT NULL THEN 'ready'
+           WHEN status = 'interrupted' THEN 'failed'
+           ELSE 'pending'
+       END,
+       converted_size_bytes, owner_id, created_at, hls_ready_at
+FROM videos
+ON CONFLICT (video_id, number) DO NOTHING;
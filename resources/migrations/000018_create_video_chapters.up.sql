@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS video_chapters (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    video_id   uuid        NOT NULL REFERENCES videos (id) ON DELETE CASCADE,
+    kind       text        NOT NULL DEFAULT 'chapter'
+        CHECK (kind IN ('chapter', 'marker')),
+    title      text        NOT NULL,
+    start_ms   integer     NOT NULL CHECK (start_ms >= 0),
+    end_ms     integer     NULL CHECK (end_ms > start_ms),
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS video_chapters_video_id_start_idx ON video_chapters (video_id, start_ms);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS video_subtitles (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    video_id   uuid        NOT NULL REFERENCES videos (id) ON DELETE CASCADE,
+    language   text        NOT NULL,
+    label      text        NOT NULL,
+    format     text        NOT NULL CHECK (format IN ('srt', 'vtt')),
+    is_default boolean     NOT NULL DEFAULT false,
+    cue_count  integer     NOT NULL DEFAULT 0,
+    content    text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (video_id, language)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS video_subtitles_default_idx ON video_subtitles (video_id) WHERE is_default;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS video_segment_retention;
+DROP TABLE IF EXISTS video_daily_viewers;
+DROP TABLE IF EXISTS video_daily_stats;
+DROP TABLE IF EXISTS playback_session_segments;
+DROP TABLE IF EXISTS playback_sessions;
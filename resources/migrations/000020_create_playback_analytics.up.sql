@@ -0,0 +1,48 @@
+-- raw playback sessions, one per playback token; pruned after the raw retention
+CREATE TABLE IF NOT EXISTS playback_sessions (
+    video_id     uuid        NOT NULL REFERENCES videos (id) ON DELETE CASCADE,
+    session_key  text        NOT NULL,
+    viewer_key   text        NOT NULL,
+    day          date        NOT NULL,
+    segments     integer     NOT NULL DEFAULT 0,
+    watch_ms     bigint      NOT NULL DEFAULT 0,
+    started_at   timestamptz NOT NULL,
+    last_seen_at timestamptz NOT NULL,
+    PRIMARY KEY (video_id, session_key)
+);
+
+CREATE INDEX IF NOT EXISTS playback_sessions_last_seen_idx ON playback_sessions (last_seen_at);
+
+CREATE TABLE IF NOT EXISTS playback_session_segments (
+    video_id    uuid    NOT NULL,
+    session_key text    NOT NULL,
+    segment     integer NOT NULL,
+    PRIMARY KEY (video_id, session_key, segment),
+    FOREIGN KEY (video_id, session_key) REFERENCES playback_sessions (video_id, session_key) ON DELETE CASCADE
+);
+
+-- rollups
+CREATE TABLE IF NOT EXISTS video_daily_stats (
+    video_id       uuid    NOT NULL REFERENCES videos (id) ON DELETE CASCADE,
+    day            date    NOT NULL,
+    views          integer NOT NULL DEFAULT 0,
+    unique_viewers integer NOT NULL DEFAULT 0,
+    watch_ms       bigint  NOT NULL DEFAULT 0,
+    PRIMARY KEY (video_id, day)
+);
+
+CREATE TABLE IF NOT EXISTS video_daily_viewers (
+    video_id   uuid NOT NULL REFERENCES videos (id) ON DELETE CASCADE,
+    day        date NOT NULL,
+    viewer_key text NOT NULL,
+    PRIMARY KEY (video_id, day, viewer_key)
+);
+
+CREATE TABLE IF NOT EXISTS video_segment_retention (
+    video_id uuid    NOT NULL REFERENCES videos (id) ON DELETE CASCADE,
+    day      date    NOT NULL,
+    segment  integer NOT NULL,
+    start_ms bigint  NOT NULL DEFAULT 0,
+    sessions integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (video_id, day, segment)
+);
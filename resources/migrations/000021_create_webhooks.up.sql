@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    url         text        NOT NULL,
+    description text        NOT NULL DEFAULT '',
+    events      text[]      NOT NULL DEFAULT '{}',
+    secret      text        NOT NULL,
+    active      boolean     NOT NULL DEFAULT true,
+    created_by  uuid        NULL REFERENCES users (id) ON DELETE SET NULL,
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    updated_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id       uuid        NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id         uuid        NOT NULL,
+    event            text        NOT NULL,
+    payload          jsonb       NOT NULL,
+    status           text        NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts         integer     NOT NULL DEFAULT 0,
+    next_attempt_at  timestamptz NOT NULL DEFAULT now(),
+    last_status_code integer     NULL,
+    last_error       text        NULL,
+    response_body    text        NULL,
+    created_at       timestamptz NOT NULL DEFAULT now(),
+    delivered_at     timestamptz NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at DESC);
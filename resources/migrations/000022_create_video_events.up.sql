@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS video_events (
+    id         bigserial PRIMARY KEY,
+    video_id   uuid        NOT NULL,
+    owner_id   uuid        NULL,
+    type       text        NOT NULL,
+    status     text        NOT NULL,
+    progress   real        NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS video_events_created_at_idx ON video_events (created_at);
+CREATE INDEX IF NOT EXISTS video_events_video_idx ON video_events (video_id, id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS collections_owner_id_idx;
+
+ALTER TABLE collections DROP COLUMN IF EXISTS owner_id;
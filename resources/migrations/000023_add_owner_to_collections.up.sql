@@ -0,0 +1,4 @@
+ALTER TABLE collections
+  ADD COLUMN owner_id uuid NULL REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS collections_owner_id_idx ON collections (owner_id, created_at DESC);
@@ -0,0 +1 @@
+ALTER TABLE bulk_operations DROP COLUMN IF EXISTS lease_expires_at;
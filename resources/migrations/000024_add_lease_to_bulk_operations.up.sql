@@ -0,0 +1,4 @@
+-- an operation is claimed by one process at a time; a running operation
+-- whose lease ran out was left by a process that stopped and is taken over
+ALTER TABLE bulk_operations
+  ADD COLUMN lease_expires_at timestamptz NULL;
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newTestSigner(t *testing.T, secret, issuer string) *Signer {
+	t.Helper()
+	cfg := &config.Config{Auth: config.AuthConfig{JWTSecret: secret, Issuer: issuer, AccessTTL: 15 * time.Minute}}
+	signer, err := NewSigner(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	signer := newTestSigner(t, "secret", "videos")
+	now := time.Unix(1700000000, 0)
+
+	token, err := signer.Sign("user-1", domain.Role("admin"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := signer.Parse(token, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := Claims{Subject: "user-1", Role: "admin", Issuer: "videos", IssuedAt: now.Unix(), ExpiresAt: now.Add(15 * time.Minute).Unix()}
+	if *claims != want {
+		t.Errorf("claims = %+v, want %+v", *claims, want)
+	}
+}
+
+func TestSignerRejects(t *testing.T) {
+	signer := newTestSigner(t, "secret", "videos")
+	now := time.Unix(1700000000, 0)
+	token, err := signer.Sign("user-1", domain.Role("user"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	otherSecret, _ := newTestSigner(t, "other", "videos").Sign("user-1", domain.Role("user"), now)
+	otherIssuer, _ := newTestSigner(t, "secret", "elsewhere").Sign("user-1", domain.Role("user"), now)
+	noSubject, _ := signer.Sign("", domain.Role("user"), now)
+
+	tests := []struct {
+		name  string
+		token string
+		now   time.Time
+	}{
+		{"expired", token, now.Add(15 * time.Minute)},
+		{"malformed", "abc", now},
+		{"too many parts", token + ".x", now},
+		{"other secret", otherSecret, now},
+		{"other issuer", otherIssuer, now},
+		{"no subject", noSubject, now},
+		{"alg none", encode(`{"alg":"none","typ":"JWT"}`) + "." + parts[1] + ".", now},
+		{"tampered payload", parts[0] + "." + encode(`{"sub":"admin","role":"admin","iss":"videos","exp":9999999999}`) + "." + parts[2], now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := signer.Parse(tt.token, tt.now); !errors.Is(err, domain.ErrInvalidToken) {
+				t.Errorf("error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestSignerGeneratesSecret(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	token, err := newTestSigner(t, "", "").Sign("user-1", domain.Role("user"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every instance without a configured secret has its own
+	if _, err := newTestSigner(t, "", "").Parse(token, now); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("error = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newTestPlaybackSigner(t *testing.T, playback config.PlaybackConfig, jwtSecret string) *PlaybackSigner {
+	t.Helper()
+	cfg := &config.Config{Playback: playback, Auth: config.AuthConfig{JWTSecret: jwtSecret}}
+	signer, err := NewPlaybackSigner(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestPlaybackSigner(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ttl := time.Hour
+	bound := newTestPlaybackSigner(t, config.PlaybackConfig{Secret: "playback", TTL: ttl, BindIP: true}, "")
+	unbound := newTestPlaybackSigner(t, config.PlaybackConfig{Secret: "playback", TTL: ttl}, "")
+	fallback := newTestPlaybackSigner(t, config.PlaybackConfig{TTL: ttl}, "playback")
+	jwt := newTestSigner(t, "playback", "")
+
+	jwtToken, err := jwt.Sign("user-1", domain.Role("user"), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		signer *PlaybackSigner
+		token  string
+		ip     string
+		now    time.Time
+		ok     bool
+	}{
+		{"valid", unbound, unbound.Sign("v1", "2024/01/02/slug", "10.0.0.1", now), "10.0.0.2", now, true},
+		{"bound to the same address", bound, bound.Sign("v1", "2024/01/02/slug", "10.0.0.1", now), "10.0.0.1", now, true},
+		{"bound to another address", bound, bound.Sign("v1", "2024/01/02/slug", "10.0.0.1", now), "10.0.0.2", now, false},
+		{"expired", unbound, unbound.Sign("v1", "2024/01/02/slug", "", now), "", now.Add(ttl), false},
+		{"missing dir", unbound, unbound.Sign("v1", "", "", now), "", now, false},
+		{"no separator", unbound, "abc", "", now, false},
+		{"tampered", unbound, unbound.Sign("v1", "2024/01/02/slug", "", now) + "x", "", now, false},
+		{"falls back to the JWT secret", unbound, fallback.Sign("v1", "2024/01/02/slug", "", now), "", now, true},
+		// the same secret signs both, so a session token must not pass as a playback token
+		{"session token", fallback, jwtToken, "", now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.signer.Parse(tt.token, tt.ip, tt.now)
+			if !tt.ok {
+				if !errors.Is(err, domain.ErrInvalidToken) {
+					t.Errorf("error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if claims.VideoID != "v1" || claims.Dir != "2024/01/02/slug" || claims.ExpiresAt != now.Add(ttl).Unix() {
+				t.Errorf("claims = %+v", *claims)
+			}
+		})
+	}
+}
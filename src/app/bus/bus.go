@@ -0,0 +1,221 @@
+package bus
+
+import (
+	"awesomeProject/src/app/cache"
+	"awesomeProject/src/app/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Channel is the PostgreSQL notification channel every instance listens on.
+const Channel = "app_events"
+
+const (
+	healthCheckInterval = 30 * time.Second
+	minReconnectDelay   = time.Second
+	maxReconnectDelay   = 30 * time.Second
+)
+
+type Topic string
+
+const (
+	// TopicVideoChanged is sent whenever a video row changes; cached copies
+	// of the video are stale from then on.
+	TopicVideoChanged Topic = "video.changed"
+	// TopicVideoEvent is sent when an event is appended to the video event
+	// log.
+	TopicVideoEvent Topic = "video.event"
+)
+
+// Message is a domain event as exchanged between instances. It only names
+// what changed; receivers read the current state from the database.
+type Message struct {
+	Topic   Topic  `json:"topic"`
+	VideoID string `json:"videoId,omitempty"`
+}
+
+type Handler func(Message)
+
+// Notify sends msg to every instance. When db is a transaction PostgreSQL
+// holds the notification back until it commits and drops it on rollback.
+func Notify(db *gorm.DB, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return db.Exec("SELECT pg_notify(?, ?)", Channel, string(payload)).Error
+}
+
+// Bus delivers the notifications of all instances, including its own, to
+// local handlers. It listens on a dedicated connection and reconnects with
+// backoff when that connection is lost.
+type Bus struct {
+	dsn string
+	log *zap.Logger
+
+	mu        sync.RWMutex
+	handlers  map[Topic][]Handler
+	onConnect []func()
+
+	runCtx context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func New(cfg *config.Config, logger *zap.Logger) *Bus {
+	return &Bus{
+		dsn:      cfg.DB.DSN(),
+		log:      logger,
+		handlers: make(map[Topic][]Handler),
+	}
+}
+
+// Subscribe registers h for topic. Handlers run on the listener goroutine
+// and must not block.
+func (b *Bus) Subscribe(topic Topic, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], h)
+}
+
+// OnConnect registers f to run whenever the listener (re)connects.
+// Notifications sent while it was disconnected are lost, so f should
+// discard whatever they would have invalidated.
+func (b *Bus) OnConnect(f func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onConnect = append(b.onConnect, f)
+}
+
+func (b *Bus) Start() {
+	b.done = make(chan struct{})
+
+	go func() {
+		defer close(b.done)
+
+		delay := minReconnectDelay
+		for {
+			connected, err := b.listen()
+			if b.runCtx.Err() != nil {
+				return
+			}
+			if connected {
+				delay = minReconnectDelay
+			}
+			b.log.Warn("event bus disconnected, reconnecting", zap.Duration("in", delay), zap.Error(err))
+
+			select {
+			case <-b.runCtx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, maxReconnectDelay)
+		}
+	}()
+}
+
+// listen serves one connection until it fails. It reports whether LISTEN
+// succeeded, which resets the reconnect backoff.
+func (b *Bus) listen() (bool, error) {
+	conn, err := pgx.Connect(b.runCtx, b.dsn)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = conn.Close(ctx)
+	}()
+
+	if _, err := conn.Exec(b.runCtx, "LISTEN "+pgx.Identifier{Channel}.Sanitize()); err != nil {
+		return false, err
+	}
+	b.log.Info("event bus listening", zap.String("channel", Channel))
+	b.connected()
+
+	for {
+		ctx, cancel := context.WithTimeout(b.runCtx, healthCheckInterval)
+		notification, err := conn.WaitForNotification(ctx)
+		cancel()
+
+		switch {
+		case err == nil:
+			b.dispatch(notification.Payload)
+		case errors.Is(err, context.DeadlineExceeded) && b.runCtx.Err() == nil && !conn.IsClosed():
+			// a quiet channel; make sure the connection is still alive
+			if err := conn.Ping(b.runCtx); err != nil {
+				return true, err
+			}
+		default:
+			return true, err
+		}
+	}
+}
+
+func (b *Bus) connected() {
+	b.mu.RLock()
+	hooks := b.onConnect
+	b.mu.RUnlock()
+
+	for _, f := range hooks {
+		f()
+	}
+}
+
+func (b *Bus) dispatch(payload string) {
+	var msg Message
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		b.log.Warn("malformed event bus message", zap.String("payload", payload), zap.Error(err))
+		return
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[msg.Topic]
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(msg)
+	}
+}
+
+// invalidateCache keeps the video cache of this instance in line with
+// changes made by any instance.
+func invalidateCache(b *Bus, videos *cache.VideoCache) {
+	b.Subscribe(TopicVideoChanged, func(msg Message) {
+		videos.Delete(msg.VideoID)
+	})
+	b.OnConnect(videos.Clear)
+}
+
+var Module = fx.Module("event_bus",
+	fx.Provide(New),
+	fx.Invoke(invalidateCache),
+	fx.Invoke(func(lc fx.Lifecycle, b *Bus) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				b.runCtx, b.cancel = context.WithCancel(context.Background())
+				b.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				if b.cancel == nil {
+					return nil
+				}
+				b.cancel()
+				select {
+				case <-b.done:
+				case <-ctx.Done():
+				}
+				return nil
+			},
+		})
+	}),
+)
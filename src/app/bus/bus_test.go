@@ -0,0 +1,79 @@
+package bus
+
+import (
+	"awesomeProject/src/app/testdb"
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestBusReconnects(t *testing.T) {
+	db, dsn := testdb.OpenDSN(t)
+
+	// the listener is told apart from other connections by its name
+	name := "bus_test_" + uuid.NewString()[:8]
+	listener, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := listener.Query()
+	query.Set("application_name", name)
+	listener.RawQuery = query.Encode()
+
+	b := &Bus{dsn: listener.String(), log: zap.NewNop(), handlers: make(map[Topic][]Handler)}
+	connects := make(chan struct{}, 4)
+	b.OnConnect(func() { connects <- struct{}{} })
+	// other tests notify on the same database
+	videoID := uuid.NewString()
+	received := make(chan Message, 4)
+	b.Subscribe(TopicVideoChanged, func(msg Message) {
+		if msg.VideoID == videoID {
+			received <- msg
+		}
+	})
+
+	b.runCtx, b.cancel = context.WithCancel(context.Background())
+	b.Start()
+	t.Cleanup(func() {
+		b.cancel()
+		<-b.done
+	})
+
+	wait := func(what string, ch <-chan struct{}) {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+	deliver := func() {
+		t.Helper()
+		if err := Notify(db, Message{Topic: TopicVideoChanged, VideoID: videoID}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-received:
+		case <-time.After(10 * time.Second):
+			t.Fatal("notification was not delivered")
+		}
+	}
+
+	wait("the listener to connect", connects)
+	deliver()
+
+	res := db.Exec("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE application_name = ?", name)
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if res.RowsAffected != 1 {
+		t.Fatalf("terminated %d listeners, want 1", res.RowsAffected)
+	}
+
+	wait("the listener to reconnect", connects)
+	deliver()
+}
@@ -121,6 +121,14 @@ func (cache *VideoCache) Delete(id string) {
 
 }
 
+func (cache *VideoCache) Clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	clear(cache.cache)
+	cache.logger.Info("cache cleared")
+}
+
 var CacheModule = fx.Module("video-cache",
 	fx.Provide(NewVideoCache),
 	fx.Invoke(func(lc fx.Lifecycle, c *VideoCache) {
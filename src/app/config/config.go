@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -41,6 +43,67 @@ type DataConfig struct {
 	RawDir     string
 }
 
+type RetentionConfig struct {
+	ArchiveRetention time.Duration
+	PurgeInterval    time.Duration
+}
+
+type AuthConfig struct {
+	JWTSecret     string
+	Issuer        string
+	AccessTTL     time.Duration
+	RefreshTTL    time.Duration
+	AdminEmail    string
+	AdminPassword string
+}
+
+type PlaybackConfig struct {
+	Secret string
+	TTL    time.Duration
+	BindIP bool
+}
+
+type ReconcileConfig struct {
+	Interval      time.Duration
+	GracePeriod   time.Duration
+	DeleteOrphans bool
+	MarkBroken    bool
+}
+
+type SearchConfig struct {
+	Language string
+}
+
+type OpenAPIConfig struct {
+	Validate bool
+}
+
+type IdempotencyConfig struct {
+	TTL           time.Duration
+	SweepInterval time.Duration
+}
+
+type AnalyticsConfig struct {
+	Enabled       bool
+	FlushInterval time.Duration
+	RawRetention  time.Duration
+}
+
+type WebhookConfig struct {
+	Timeout      time.Duration
+	MaxAttempts  int
+	PollInterval time.Duration
+	// AllowedCIDRs are internal networks that receivers may be on, such as
+	// an intranet CMS; public addresses are always allowed.
+	AllowedCIDRs []netip.Prefix
+}
+
+type EventsConfig struct {
+	PollInterval time.Duration
+	Heartbeat    time.Duration
+	Retention    time.Duration
+}
+
 type ConversionConfig struct {
 	TmpDir   string
 	ConvDir  string
@@ -48,11 +111,22 @@ type ConversionConfig struct {
 }
 
 type Config struct {
-	DB    DBConfig
-	Http  HttpConfig
-	Data  DataConfig
-	Conv  ConversionConfig
-	Cache CacheConfig
+	DB        DBConfig
+	Http      HttpConfig
+	Data      DataConfig
+	Conv      ConversionConfig
+	Cache     CacheConfig
+	Retention RetentionConfig
+	Reconcile ReconcileConfig
+	Search    SearchConfig
+	Auth      AuthConfig
+	Playback  PlaybackConfig
+	OpenAPI   OpenAPIConfig
+
+	Idempotency IdempotencyConfig
+	Analytics   AnalyticsConfig
+	Webhook     WebhookConfig
+	Events      EventsConfig
 }
 
 func Load() *Config {
@@ -82,6 +156,55 @@ func Load() *Config {
 			DefaultExpiration: time.Duration(getEnvAsInt("DEFAULT_CACHE_EXPIRATION_SECS", 60)) * time.Second,
 			DefaultFrequency:  time.Duration(getEnvAsInt("DEFAULT_CACHE_CLEAN_FREQUENCY_SECS", 60)) * time.Second,
 		},
+		Retention: RetentionConfig{
+			ArchiveRetention: time.Duration(getEnvAsInt("ARCHIVE_RETENTION_DAYS", 0)) * 24 * time.Hour,
+			PurgeInterval:    time.Duration(getEnvAsInt("PURGE_INTERVAL_SECS", 3600)) * time.Second,
+		},
+		Auth: AuthConfig{
+			JWTSecret:     getEnv("JWT_SECRET", ""),
+			Issuer:        getEnv("JWT_ISSUER", "video_backend"),
+			AccessTTL:     time.Duration(getEnvAsInt("JWT_ACCESS_TTL_SECS", 900)) * time.Second,
+			RefreshTTL:    time.Duration(getEnvAsInt("JWT_REFRESH_TTL_SECS", 30*24*3600)) * time.Second,
+			AdminEmail:    getEnv("ADMIN_EMAIL", ""),
+			AdminPassword: getEnv("ADMIN_PASSWORD", ""),
+		},
+		Playback: PlaybackConfig{
+			Secret: getEnv("PLAYBACK_SECRET", ""),
+			TTL:    time.Duration(getEnvAsInt("PLAYBACK_TOKEN_TTL_SECS", 6*3600)) * time.Second,
+			BindIP: getEnvAsBool("PLAYBACK_BIND_IP", false),
+		},
+		Reconcile: ReconcileConfig{
+			Interval:      time.Duration(getEnvAsInt("RECONCILE_INTERVAL_SECS", 0)) * time.Second,
+			GracePeriod:   time.Duration(getEnvAsInt("RECONCILE_GRACE_PERIOD_SECS", 86400)) * time.Second,
+			DeleteOrphans: getEnvAsBool("RECONCILE_DELETE_ORPHANS", false),
+			MarkBroken:    getEnvAsBool("RECONCILE_MARK_BROKEN", false),
+		},
+		Search: SearchConfig{
+			Language: getEnv("SEARCH_LANGUAGE", "simple"),
+		},
+		OpenAPI: OpenAPIConfig{
+			Validate: getEnvAsBool("OPENAPI_VALIDATE", false),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:           time.Duration(getEnvAsInt("IDEMPOTENCY_TTL_SECS", 24*3600)) * time.Second,
+			SweepInterval: time.Duration(getEnvAsInt("IDEMPOTENCY_SWEEP_INTERVAL_SECS", 3600)) * time.Second,
+		},
+		Analytics: AnalyticsConfig{
+			Enabled:       getEnvAsBool("ANALYTICS_ENABLED", true),
+			FlushInterval: time.Duration(getEnvAsInt("ANALYTICS_FLUSH_INTERVAL_SECS", 10)) * time.Second,
+			RawRetention:  time.Duration(getEnvAsInt("ANALYTICS_RAW_RETENTION_DAYS", 90)) * 24 * time.Hour,
+		},
+		Webhook: WebhookConfig{
+			Timeout:      time.Duration(getEnvAsInt("WEBHOOK_TIMEOUT_SECS", 10)) * time.Second,
+			MaxAttempts:  getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			PollInterval: time.Duration(getEnvAsInt("WEBHOOK_POLL_INTERVAL_SECS", 5)) * time.Second,
+			AllowedCIDRs: getEnvAsPrefixes("WEBHOOK_ALLOWED_CIDRS"),
+		},
+		Events: EventsConfig{
+			PollInterval: time.Duration(getEnvAsInt("EVENTS_POLL_INTERVAL_MS", 1000)) * time.Millisecond,
+			Heartbeat:    time.Duration(getEnvAsInt("EVENTS_HEARTBEAT_SECS", 15)) * time.Second,
+			Retention:    time.Duration(getEnvAsInt("EVENTS_RETENTION_HOURS", 24)) * time.Hour,
+		},
 	}
 }
 
@@ -101,6 +224,30 @@ func getEnvAsInt(key string, def int) int {
 	return def
 }
 
+func getEnvAsBool(key string, def bool) bool {
+	if valStr, ok := os.LookupEnv(key); ok {
+		if val, err := strconv.ParseBool(valStr); err == nil {
+			return val
+		}
+	}
+	return def
+}
+
+// getEnvAsPrefixes reads a comma separated list of CIDRs; a bare address
+// stands for itself. Invalid entries are skipped.
+func getEnvAsPrefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, raw := range strings.Split(os.Getenv(key), ",") {
+		raw = strings.TrimSpace(raw)
+		if prefix, err := netip.ParsePrefix(raw); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(raw); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return prefixes
+}
+
 var Module = fx.Module("config",
 	fx.Provide(
 		Load,
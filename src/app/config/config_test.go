@@ -0,0 +1,26 @@
+package config
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestGetEnvAsPrefixes(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []netip.Prefix
+	}{
+		{"", nil},
+		{"10.0.0.0/8", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+		{" 192.168.1.7/24 , fd00::/8", []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24"), netip.MustParsePrefix("fd00::/8")}},
+		{"127.0.0.1,::1", []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32"), netip.MustParsePrefix("::1/128")}},
+		{"intranet,10.0.0.0/33,172.16.0.0/12", []netip.Prefix{netip.MustParsePrefix("172.16.0.0/12")}},
+	}
+	for _, tt := range tests {
+		t.Setenv("WEBHOOK_ALLOWED_CIDRS", tt.value)
+		if got := getEnvAsPrefixes("WEBHOOK_ALLOWED_CIDRS"); !slices.Equal(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key is not found")
+
+const APIKeyPrefix = "vk_"
+
+type Scope string
+
+const (
+	ScopeVideoRead    Scope = "video:read"
+	ScopeVideoWrite   Scope = "video:write"
+	ScopeVideoArchive Scope = "video:archive"
+	ScopeAdmin        Scope = "admin"
+)
+
+var knownScopes = map[Scope]struct{}{
+	ScopeVideoRead:    {},
+	ScopeVideoWrite:   {},
+	ScopeVideoArchive: {},
+	ScopeAdmin:        {},
+}
+
+type APIKey struct {
+	ID         string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     pq.StringArray `gorm:"type:text[]"`
+	OwnerID    string         `gorm:"type:uuid"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time `gorm:"not null;default:now()"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+type APIKeyDTO struct {
+	ID         string
+	Name       string
+	Prefix     string
+	Scopes     []string
+	OwnerID    string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	// Key is only populated in the response that creates the key.
+	Key string `json:",omitempty"`
+}
+
+func (k APIKey) ToDto() APIKeyDTO {
+	return APIKeyDTO{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		OwnerID:    k.OwnerID,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+func (k APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+func ParseScopes(raw []string) ([]Scope, error) {
+	if len(raw) == 0 {
+		return nil, &ValidationError{Field: "scopes", Message: "at least one scope is required"}
+	}
+	seen := make(map[Scope]struct{}, len(raw))
+	scopes := make([]Scope, 0, len(raw))
+	for _, r := range raw {
+		scope := Scope(strings.ToLower(strings.TrimSpace(r)))
+		if _, ok := knownScopes[scope]; !ok {
+			return nil, &ValidationError{Field: "scopes", Message: fmt.Sprintf("unknown scope %q", r)}
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
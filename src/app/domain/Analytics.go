@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultAnalyticsDays = 30
+	MaxAnalyticsDays     = 366
+
+	// NoSegment marks playlist fetches, which open a session without
+	// counting as watched media.
+	NoSegment = -1
+)
+
+const analyticsDateFormat = "2006-01-02"
+
+// PlaybackEvent is one media fetch attributed to a playback session. A
+// session is one playback token; the viewer is a salted hash of the client.
+type PlaybackEvent struct {
+	VideoID    string
+	SessionKey string
+	ViewerKey  string
+	Segment    int
+	StartMs    int64
+	DurationMs int64
+	At         time.Time
+}
+
+// AnalyticsRange is an inclusive range of UTC days.
+type AnalyticsRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r AnalyticsRange) Days() int {
+	return int(r.To.Sub(r.From)/(24*time.Hour)) + 1
+}
+
+// ParseAnalyticsRange reads the from and to query values (YYYY-MM-DD). The
+// default is the last DefaultAnalyticsDays days up to today.
+func ParseAnalyticsRange(from, to string, now time.Time) (AnalyticsRange, error) {
+	var r AnalyticsRange
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	r.To = today
+	if to != "" {
+		t, err := time.Parse(analyticsDateFormat, to)
+		if err != nil {
+			return r, &ValidationError{Field: "to", Message: "must be a date in YYYY-MM-DD format"}
+		}
+		r.To = t
+	}
+
+	r.From = r.To.AddDate(0, 0, -(DefaultAnalyticsDays - 1))
+	if from != "" {
+		t, err := time.Parse(analyticsDateFormat, from)
+		if err != nil {
+			return r, &ValidationError{Field: "from", Message: "must be a date in YYYY-MM-DD format"}
+		}
+		r.From = t
+	}
+
+	if r.From.After(r.To) {
+		return r, &ValidationError{Field: "from", Message: "must not be after to"}
+	}
+	if r.Days() > MaxAnalyticsDays {
+		return r, &ValidationError{Field: "from", Message: fmt.Sprintf("range must not exceed %d days", MaxAnalyticsDays)}
+	}
+	return r, nil
+}
+
+func FormatAnalyticsDate(t time.Time) string {
+	return t.UTC().Format(analyticsDateFormat)
+}
+
+type DailyAnalytics struct {
+	Date          string  `json:"date"`
+	Views         int64   `json:"views"`
+	UniqueViewers int64   `json:"uniqueViewers"`
+	WatchTimeS    float64 `json:"watchTimeS"`
+}
+
+// RetentionPoint tells how many viewing sessions fetched a segment; Ratio
+// is relative to all views in the range.
+type RetentionPoint struct {
+	Segment  int     `json:"segment"`
+	StartS   float64 `json:"startS"`
+	Sessions int64   `json:"sessions"`
+	Ratio    float64 `json:"ratio"`
+}
+
+type VideoAnalytics struct {
+	VideoID           string           `json:"videoId"`
+	From              string           `json:"from"`
+	To                string           `json:"to"`
+	Views             int64            `json:"views"`
+	UniqueViewers     int64            `json:"uniqueViewers"`
+	WatchTimeS        float64          `json:"watchTimeS"`
+	AverageWatchTimeS float64          `json:"averageWatchTimeS"`
+	Daily             []DailyAnalytics `json:"daily"`
+	Retention         []RetentionPoint `json:"retention"`
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditArchived         AuditAction = "archived"
+	AuditRestored         AuditAction = "restored"
+	AuditReprocessed      AuditAction = "reprocessed"
+	AuditReplaced         AuditAction = "source_replaced"
+	AuditRolledBack       AuditAction = "rolled_back"
+	AuditChapters         AuditAction = "chapters_updated"
+	AuditSubtitlesSet     AuditAction = "subtitles_updated"
+	AuditSubtitlesRemoved AuditAction = "subtitles_removed"
+	AuditPurged           AuditAction = "purged"
+)
+
+const (
+	ActorRetention = "retention"
+	ActorAnonymous = "anonymous"
+)
+
+type AuditEntry struct {
+	ID        int64 `gorm:"primaryKey"`
+	VideoID   string
+	Action    string
+	Actor     string
+	Details   *string
+	CreatedAt time.Time `gorm:"not null;default:now()"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBulkOperationNotFound = errors.New("bulk operation is not found")
+	ErrVideoNotArchived      = errors.New("video is not archived")
+)
+
+const MaxBulkItems = 1000
+
+type BulkOperationType string
+
+const (
+	BulkArchive       BulkOperationType = "archive"
+	BulkRestore       BulkOperationType = "restore"
+	BulkReprocess     BulkOperationType = "reprocess"
+	BulkTag           BulkOperationType = "tag"
+	BulkSetVisibility BulkOperationType = "set_visibility"
+)
+
+type BulkStatus string
+
+const (
+	BulkQueued    BulkStatus = "queued"
+	BulkRunning   BulkStatus = "running"
+	BulkCompleted BulkStatus = "completed"
+)
+
+type BulkItemStatus string
+
+const (
+	BulkItemPending   BulkItemStatus = "pending"
+	BulkItemSucceeded BulkItemStatus = "succeeded"
+	BulkItemFailed    BulkItemStatus = "failed"
+)
+
+type TagMode string
+
+const (
+	TagModeAdd     TagMode = "add"
+	TagModeRemove  TagMode = "remove"
+	TagModeReplace TagMode = "replace"
+)
+
+type BulkParams struct {
+	Tags       []string `json:"tags,omitempty"`
+	TagMode    TagMode  `json:"tagMode,omitempty"`
+	Visibility string   `json:"visibility,omitempty"`
+}
+
+// BulkOperation stores the principal that requested it so items are
+// executed with the caller's permissions after a restart.
+type BulkOperation struct {
+	ID         string            `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Operation  BulkOperationType `gorm:"type:text"`
+	Params     BulkParams        `gorm:"type:jsonb;serializer:json"`
+	Principal  Principal         `gorm:"type:jsonb;serializer:json"`
+	CreatedBy  *string           `gorm:"type:uuid"`
+	Status     BulkStatus        `gorm:"type:text;not null;default:queued"`
+	Total      int
+	Succeeded  int
+	Failed     int
+	CreatedAt  time.Time `gorm:"not null;default:now()"`
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	// LeaseExpiresAt is when a running operation may be taken over by
+	// another process
+	LeaseExpiresAt *time.Time
+	Items          []BulkItem `gorm:"foreignKey:OperationID"`
+}
+
+type BulkItem struct {
+	OperationID string         `gorm:"type:uuid;primaryKey"`
+	VideoID     string         `gorm:"type:uuid;primaryKey"`
+	Position    int            `gorm:"not null"`
+	Status      BulkItemStatus `gorm:"type:text;not null;default:pending"`
+	Error       *string
+	Code        *string
+	UpdatedAt   time.Time `gorm:"not null;default:now()"`
+}
+
+func (BulkItem) TableName() string {
+	return "bulk_operation_items"
+}
+
+type BulkOperationDTO struct {
+	ID         string
+	Operation  BulkOperationType
+	Params     BulkParams
+	Status     BulkStatus
+	Total      int
+	Processed  int
+	Succeeded  int
+	Failed     int
+	Progress   float64
+	CreatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	Items      []BulkItemDTO `json:",omitempty"`
+}
+
+type BulkItemDTO struct {
+	VideoID string
+	Status  BulkItemStatus
+	Error   *string `json:",omitempty"`
+	Code    *string `json:",omitempty"`
+}
+
+func (op BulkOperation) ToDto() BulkOperationDTO {
+	dto := BulkOperationDTO{
+		ID:         op.ID,
+		Operation:  op.Operation,
+		Params:     op.Params,
+		Status:     op.Status,
+		Total:      op.Total,
+		Processed:  op.Succeeded + op.Failed,
+		Succeeded:  op.Succeeded,
+		Failed:     op.Failed,
+		Progress:   1,
+		CreatedAt:  op.CreatedAt,
+		StartedAt:  op.StartedAt,
+		FinishedAt: op.FinishedAt,
+	}
+	if op.Total > 0 {
+		dto.Progress = float64(dto.Processed) / float64(op.Total)
+	}
+	for _, item := range op.Items {
+		dto.Items = append(dto.Items, BulkItemDTO{
+			VideoID: item.VideoID,
+			Status:  item.Status,
+			Error:   item.Error,
+			Code:    item.Code,
+		})
+	}
+	return dto
+}
+
+// RequiredScope is the API key scope needed to run the operation.
+func (t BulkOperationType) RequiredScope() Scope {
+	switch t {
+	case BulkArchive, BulkRestore:
+		return ScopeVideoArchive
+	default:
+		return ScopeVideoWrite
+	}
+}
+
+func ParseBulkOperation(raw string, params BulkParams) (BulkOperationType, BulkParams, error) {
+	op := BulkOperationType(strings.ToLower(strings.TrimSpace(raw)))
+	switch op {
+	case BulkArchive, BulkRestore, BulkReprocess:
+		return op, BulkParams{}, nil
+	case BulkTag:
+		mode := TagMode(strings.ToLower(string(params.TagMode)))
+		switch mode {
+		case "":
+			mode = TagModeAdd
+		case TagModeAdd, TagModeRemove, TagModeReplace:
+		default:
+			return "", params, &ValidationError{Field: "params.tagMode", Message: "must be one of add, remove, replace"}
+		}
+		tags, err := NormalizeTags(params.Tags)
+		if err != nil {
+			return "", params, err
+		}
+		if len(tags) == 0 && mode != TagModeReplace {
+			return "", params, &ValidationError{Field: "params.tags", Message: "must not be empty"}
+		}
+		return op, BulkParams{Tags: tags, TagMode: mode}, nil
+	case BulkSetVisibility:
+		visibility, err := ParseVisibility(params.Visibility)
+		if err != nil {
+			return "", params, &ValidationError{Field: "params.visibility", Message: err.(*ValidationError).Message}
+		}
+		return op, BulkParams{Visibility: string(visibility)}, nil
+	default:
+		return "", params, &ValidationError{
+			Field:   "operation",
+			Message: fmt.Sprintf("must be one of %s, %s, %s, %s, %s", BulkArchive, BulkRestore, BulkReprocess, BulkTag, BulkSetVisibility),
+		}
+	}
+}
+
+// ApplyTags merges names into current according to mode.
+func ApplyTags(current []string, names []string, mode TagMode) []string {
+	switch mode {
+	case TagModeReplace:
+		return names
+	case TagModeRemove:
+		result := make([]string, 0, len(current))
+		for _, c := range current {
+			if !slices.Contains(names, c) {
+				result = append(result, c)
+			}
+		}
+		return result
+	default:
+		result := append([]string{}, current...)
+		for _, n := range names {
+			if !slices.Contains(result, n) {
+				result = append(result, n)
+			}
+		}
+		return result
+	}
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestApplyTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		current []string
+		names   []string
+		mode    TagMode
+		want    []string
+	}{
+		{"add appends new tags", []string{"a", "b"}, []string{"b", "c"}, TagModeAdd, []string{"a", "b", "c"}},
+		{"add to none", nil, []string{"a"}, TagModeAdd, []string{"a"}},
+		{"remove drops listed tags", []string{"a", "b", "c"}, []string{"b", "x"}, TagModeRemove, []string{"a", "c"}},
+		{"remove everything", []string{"a"}, []string{"a"}, TagModeRemove, []string{}},
+		{"replace", []string{"a", "b"}, []string{"c"}, TagModeReplace, []string{"c"}},
+		{"replace with nothing", []string{"a"}, []string{}, TagModeReplace, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := slices.Clone(tt.current)
+			got := ApplyTags(current, tt.names, tt.mode)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(current, tt.current) {
+				t.Errorf("current was modified to %v", current)
+			}
+		})
+	}
+}
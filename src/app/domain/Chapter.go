@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxChapters        = 500
+	MaxChapterTitleLen = 200
+)
+
+type ChapterKind string
+
+const (
+	KindChapter ChapterKind = "chapter"
+	KindMarker  ChapterKind = "marker"
+)
+
+func ParseChapterKind(s string) (ChapterKind, bool) {
+	switch k := ChapterKind(strings.ToLower(strings.TrimSpace(s))); k {
+	case "":
+		return KindChapter, true
+	case KindChapter, KindMarker:
+		return k, true
+	default:
+		return "", false
+	}
+}
+
+// Chapter is a titled position in a video. Chapters split the timeline into
+// navigable sections; markers are free-standing points or ranges.
+type Chapter struct {
+	ID        string      `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	VideoID   string      `gorm:"type:uuid"`
+	Kind      ChapterKind `gorm:"type:text;not null;default:chapter"`
+	Title     string
+	StartMs   int
+	EndMs     *int
+	CreatedAt time.Time `gorm:"not null;default:now()"`
+}
+
+func (Chapter) TableName() string {
+	return "video_chapters"
+}
+
+type ChapterDTO struct {
+	ID      string
+	Kind    ChapterKind
+	Title   string
+	StartMs int
+	EndMs   *int
+}
+
+func (c Chapter) ToDto() ChapterDTO {
+	return ChapterDTO{
+		ID:      c.ID,
+		Kind:    c.Kind,
+		Title:   c.Title,
+		StartMs: c.StartMs,
+		EndMs:   c.EndMs,
+	}
+}
+
+func ChapterDtos(chapters []Chapter) []ChapterDTO {
+	dtos := make([]ChapterDTO, 0, len(chapters))
+	for _, c := range chapters {
+		dtos = append(dtos, c.ToDto())
+	}
+	return dtos
+}
+
+// ValidateChapters normalizes chapters in place and checks them against the
+// duration of the video. Chapters of kind chapter must not overlap; markers
+// may be placed anywhere within the video.
+func ValidateChapters(chapters []Chapter, duration sql.NullInt32) error {
+	if len(chapters) > MaxChapters {
+		return &ValidationError{Field: "chapters", Message: fmt.Sprintf("at most %d entries are allowed", MaxChapters)}
+	}
+	if len(chapters) > 0 && !duration.Valid {
+		return &ValidationError{Field: "chapters", Message: "video duration is not known yet"}
+	}
+	durationMs := int(duration.Int32) * 1000
+
+	var errs ValidationErrors
+	for i := range chapters {
+		c := &chapters[i]
+		field := fmt.Sprintf("chapters[%d]", i)
+
+		c.Title = strings.TrimSpace(c.Title)
+		switch {
+		case c.Title == "":
+			errs = append(errs, ValidationError{Field: field + ".title", Message: "must not be empty"})
+		case utf8.RuneCountInString(c.Title) > MaxChapterTitleLen:
+			errs = append(errs, ValidationError{Field: field + ".title", Message: fmt.Sprintf("must be at most %d characters", MaxChapterTitleLen)})
+		}
+
+		if kind, ok := ParseChapterKind(string(c.Kind)); ok {
+			c.Kind = kind
+		} else {
+			errs = append(errs, ValidationError{Field: field + ".kind", Message: "must be one of chapter, marker"})
+		}
+
+		if c.StartMs < 0 || c.StartMs >= durationMs {
+			errs = append(errs, ValidationError{Field: field + ".startMs", Message: fmt.Sprintf("must be within the video duration of %dms", durationMs)})
+		}
+		if c.EndMs != nil && (*c.EndMs <= c.StartMs || *c.EndMs > durationMs) {
+			errs = append(errs, ValidationError{Field: field + ".endMs", Message: fmt.Sprintf("must be after startMs and at most %dms", durationMs)})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	SortChapters(chapters)
+
+	prev := -1
+	for i, c := range chapters {
+		if c.Kind != KindChapter {
+			continue
+		}
+		if prev >= 0 {
+			p := chapters[prev]
+			if c.StartMs == p.StartMs || (p.EndMs != nil && *p.EndMs > c.StartMs) {
+				errs = append(errs, ValidationError{Field: "chapters", Message: fmt.Sprintf("chapter %q overlaps chapter %q", c.Title, p.Title)})
+			}
+		}
+		prev = i
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func SortChapters(chapters []Chapter) {
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].StartMs < chapters[j].StartMs
+	})
+}
+
+// ChapterSpan is a chapter with its end resolved for playback.
+type ChapterSpan struct {
+	Chapter
+	End int
+}
+
+// ChapterSpans resolves where each chapter ends: at its own end, otherwise
+// at the start of the next chapter or the end of the video. Entries past the
+// duration, which a shorter replacement source may leave behind, are dropped.
+func ChapterSpans(chapters []Chapter, duration sql.NullInt32) []ChapterSpan {
+	if !duration.Valid {
+		return nil
+	}
+	durationMs := int(duration.Int32) * 1000
+
+	spans := make([]ChapterSpan, 0, len(chapters))
+	for i, c := range chapters {
+		if c.StartMs >= durationMs {
+			continue
+		}
+		end := durationMs
+		switch {
+		case c.EndMs != nil:
+			end = min(*c.EndMs, durationMs)
+		case c.Kind == KindMarker:
+			end = c.StartMs
+		default:
+			for _, next := range chapters[i+1:] {
+				if next.Kind == KindChapter && next.StartMs > c.StartMs {
+					end = min(next.StartMs, durationMs)
+					break
+				}
+			}
+		}
+		spans = append(spans, ChapterSpan{Chapter: c, End: end})
+	}
+	return spans
+}
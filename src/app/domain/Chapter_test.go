@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestChapterSpans(t *testing.T) {
+	ms := func(v int) *int { return &v }
+	duration := sql.NullInt32{Int32: 60, Valid: true}
+
+	tests := []struct {
+		name     string
+		chapters []Chapter
+		duration sql.NullInt32
+		want     []int
+	}{
+		{
+			name:     "unknown duration",
+			chapters: []Chapter{{Kind: KindChapter, StartMs: 0}},
+			want:     nil,
+		},
+		{
+			name: "open chapters end at the next one and the video end",
+			chapters: []Chapter{
+				{Kind: KindChapter, StartMs: 0},
+				{Kind: KindChapter, StartMs: 20000},
+				{Kind: KindChapter, StartMs: 45000},
+			},
+			duration: duration,
+			want:     []int{20000, 45000, 60000},
+		},
+		{
+			name: "explicit ends are kept and clamped",
+			chapters: []Chapter{
+				{Kind: KindChapter, StartMs: 0, EndMs: ms(10000)},
+				{Kind: KindChapter, StartMs: 30000, EndMs: ms(90000)},
+			},
+			duration: duration,
+			want:     []int{10000, 60000},
+		},
+		{
+			name: "markers are points and do not end chapters",
+			chapters: []Chapter{
+				{Kind: KindChapter, StartMs: 0},
+				{Kind: KindMarker, StartMs: 5000},
+				{Kind: KindChapter, StartMs: 30000},
+			},
+			duration: duration,
+			want:     []int{30000, 5000, 60000},
+		},
+		{
+			name: "entries past the end are dropped",
+			chapters: []Chapter{
+				{Kind: KindChapter, StartMs: 0},
+				{Kind: KindChapter, StartMs: 60000},
+				{Kind: KindMarker, StartMs: 75000},
+			},
+			duration: duration,
+			want:     []int{60000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := ChapterSpans(tt.chapters, tt.duration)
+			if len(spans) != len(tt.want) {
+				t.Fatalf("got %d spans, want %d", len(spans), len(tt.want))
+			}
+			for i, span := range spans {
+				if span.End != tt.want[i] {
+					t.Errorf("span %d ends at %d, want %d", i, span.End, tt.want[i])
+				}
+			}
+		})
+	}
+}
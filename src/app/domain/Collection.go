@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCollectionNotFound       = errors.New("collection is not found")
+	ErrVideoAlreadyInCollection = errors.New("video is already in the collection")
+	ErrVideoNotInCollection     = errors.New("video is not in the collection")
+)
+
+type Collection struct {
+	ID           string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Title        string
+	Description  string
+	CoverVideoID *string   `gorm:"type:uuid"`
+	CoverVideo   *Video    `gorm:"foreignKey:CoverVideoID"`
+	OwnerID      *string   `gorm:"type:uuid"`
+	VideoCount   int64     `gorm:"->"`
+	CreatedAt    time.Time `gorm:"not null;default:now()"`
+	UpdatedAt    time.Time `gorm:"not null;default:now()"`
+}
+
+type CollectionVideo struct {
+	CollectionID string `gorm:"type:uuid;primaryKey"`
+	VideoID      string `gorm:"type:uuid;primaryKey"`
+	Position     int
+	AddedAt      time.Time `gorm:"not null;default:now()"`
+}
+
+type CollectionDTO struct {
+	ID           string
+	Title        string
+	Description  string
+	CoverVideoID *string
+	CoverUrl     string
+	OwnerID      *string
+	VideoCount   int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type CollectionUpdate struct {
+	Title        *string
+	Description  *string
+	CoverVideoID *string
+}
+
+func (c Collection) ToDto() CollectionDTO {
+	return CollectionDTO{
+		ID:           c.ID,
+		Title:        c.Title,
+		Description:  c.Description,
+		CoverVideoID: c.CoverVideoID,
+		OwnerID:      c.OwnerID,
+		VideoCount:   c.VideoCount,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = &ValidationError{Field: "cursor", Message: "is malformed or does not match the requested sort"}
+
+type Cursor struct {
+	Sort  SortField `json:"s"`
+	Desc  bool      `json:"d"`
+	Value any       `json:"v"`
+	ID    string    `json:"id"`
+}
+
+func NewCursor(spec VideoQuery, last Video) Cursor {
+	return Cursor{
+		Sort:  spec.Sort,
+		Desc:  spec.Desc,
+		Value: last.SortValue(spec.Sort),
+		ID:    last.ID,
+	}
+}
+
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// QueryValue converts the JSON-decoded sort value back into a type the
+// database can compare against the sort expression.
+func (c Cursor) QueryValue() (any, error) {
+	switch c.Sort {
+	case SortCreatedAt:
+		s, ok := c.Value.(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	case SortTitle, SortFilename, SortStatus:
+		s, ok := c.Value.(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		return s, nil
+	case SortDuration, SortSize:
+		n, ok := c.Value.(float64)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		return int64(n), nil
+	case SortRelevance:
+		n, ok := c.Value.(float64)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		return n, nil
+	}
+	return nil, ErrInvalidCursor
+}
+
+func DecodeCursor(s string, spec VideoQuery) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	// the id is compared against a uuid column, where anything else would
+	// fail in the database rather than here
+	if _, err := uuid.Parse(c.ID); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.Sort != spec.Sort || c.Desc != spec.Desc {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	video := Video{
+		ID:         "7f1c1d3a-8d0e-4a58-9d43-2d7f0d1c9a10",
+		Title:      "Holiday",
+		Filename:   "holiday.mp4",
+		Status:     string(StatusComplete),
+		SizeBytes:  1 << 20,
+		DurationS:  sql.NullInt32{Int32: 95, Valid: true},
+		CreatedAt:  created,
+		SearchRank: 0.25,
+	}
+
+	tests := []struct {
+		sort SortField
+		want any
+	}{
+		{SortCreatedAt, created},
+		{SortTitle, "Holiday"},
+		{SortFilename, "holiday.mp4"},
+		{SortStatus, string(StatusComplete)},
+		{SortDuration, int64(95)},
+		{SortSize, int64(1 << 20)},
+		{SortRelevance, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.sort), func(t *testing.T) {
+			spec := VideoQuery{Sort: tt.sort, Desc: true}
+			cursor, err := DecodeCursor(NewCursor(spec, video).Encode(), spec)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if cursor.ID != video.ID {
+				t.Errorf("id = %q, want %q", cursor.ID, video.ID)
+			}
+			value, err := cursor.QueryValue()
+			if err != nil {
+				t.Fatalf("query value: %v", err)
+			}
+			if got, ok := value.(time.Time); ok {
+				if !got.Equal(tt.want.(time.Time)) {
+					t.Errorf("value = %v, want %v", got, tt.want)
+				}
+				return
+			}
+			if value != tt.want {
+				t.Errorf("value = %#v, want %#v", value, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	spec := VideoQuery{Sort: SortTitle}
+	valid := Cursor{Sort: SortTitle, Value: "a", ID: "7f1c1d3a-8d0e-4a58-9d43-2d7f0d1c9a10"}
+
+	tests := []struct {
+		name   string
+		cursor string
+		spec   VideoQuery
+	}{
+		{"not base64", "%%%", spec},
+		{"not json", "bm90IGpzb24", spec},
+		{"missing id", Cursor{Sort: SortTitle, Value: "a"}.Encode(), spec},
+		{"id not a uuid", Cursor{Sort: SortTitle, Value: "a", ID: "x' OR 1=1"}.Encode(), spec},
+		{"other sort", valid.Encode(), VideoQuery{Sort: SortFilename}},
+		{"other direction", valid.Encode(), VideoQuery{Sort: SortTitle, Desc: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.cursor, tt.spec); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestCursorQueryValueRejectsWrongType(t *testing.T) {
+	tests := []Cursor{
+		{Sort: SortCreatedAt, Value: 1.0, ID: "x"},
+		{Sort: SortCreatedAt, Value: "yesterday", ID: "x"},
+		{Sort: SortTitle, Value: 1.0, ID: "x"},
+		{Sort: SortSize, Value: "1", ID: "x"},
+		{Sort: SortRelevance, Value: nil, ID: "x"},
+		{Sort: "views", Value: 1.0, ID: "x"},
+	}
+	for _, c := range tests {
+		if _, err := c.QueryValue(); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s %#v: error = %v, want ErrInvalidCursor", c.Sort, c.Value, err)
+		}
+	}
+}
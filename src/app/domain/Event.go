@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LastEventIDHeader = "Last-Event-ID"
+	// MaxEventVideoFilter bounds how many videos one event stream may follow.
+	MaxEventVideoFilter = 50
+)
+
+type VideoEventType string
+
+const (
+	VideoEventProcessing VideoEventType = "video.processing"
+	VideoEventProgress   VideoEventType = "video.progress"
+	VideoEventReady      VideoEventType = "video.ready"
+	VideoEventFailed     VideoEventType = "video.failed"
+	VideoEventArchived   VideoEventType = "video.archived"
+	VideoEventRestored   VideoEventType = "video.restored"
+)
+
+// VideoEvent is a status change of a video as streamed to clients. Events
+// are numbered by the database so that a stream can be resumed from any
+// instance. Status is the status of the video right after the change.
+type VideoEvent struct {
+	ID        int64   `gorm:"primaryKey"`
+	VideoID   string  `gorm:"type:uuid"`
+	OwnerID   *string `gorm:"type:uuid"`
+	Type      VideoEventType
+	Status    string
+	Progress  *float64
+	CreatedAt time.Time `gorm:"not null;default:now()"`
+}
+
+func (VideoEvent) TableName() string {
+	return "video_events"
+}
+
+type VideoEventDTO struct {
+	ID        int64
+	VideoID   string
+	Type      VideoEventType
+	Status    string
+	Progress  *float64 `json:",omitempty"`
+	CreatedAt time.Time
+}
+
+func (e VideoEvent) ToDto() VideoEventDTO {
+	return VideoEventDTO{
+		ID:        e.ID,
+		VideoID:   e.VideoID,
+		Type:      e.Type,
+		Status:    e.Status,
+		Progress:  e.Progress,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+// EventFilter selects the events a stream receives. An empty VideoIDs
+// matches every video; OwnerID, when set, restricts events to the videos of
+// that user.
+type EventFilter struct {
+	VideoIDs []string
+	OwnerID  string
+}
+
+func (f EventFilter) Match(e VideoEvent) bool {
+	if f.OwnerID != "" && (e.OwnerID == nil || *e.OwnerID != f.OwnerID) {
+		return false
+	}
+	if len(f.VideoIDs) == 0 {
+		return true
+	}
+	for _, id := range f.VideoIDs {
+		if id == e.VideoID {
+			return true
+		}
+	}
+	return false
+}
+
+// EventStreamQuery is what a client asks of an event stream: the videos to
+// follow and, when resuming, the last event it has received.
+type EventStreamQuery struct {
+	VideoIDs []string
+	After    *int64
+}
+
+// ParseEventStreamQuery reads video_id, which may be repeated or comma
+// separated, and the resume position. EventSource sends the position in the
+// Last-Event-ID header; lastEventId in the query serves other clients.
+func ParseEventStreamQuery(values url.Values, lastEventID string) (EventStreamQuery, error) {
+	var q EventStreamQuery
+
+	for _, raw := range splitList(values["video_id"]) {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return q, ErrIncorrectUuid
+		}
+		q.VideoIDs = append(q.VideoIDs, id.String())
+	}
+
+	if lastEventID = strings.TrimSpace(lastEventID); lastEventID == "" {
+		lastEventID = strings.TrimSpace(values.Get("lastEventId"))
+	}
+	if lastEventID != "" {
+		after, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || after < 0 {
+			return q, &ValidationError{Field: LastEventIDHeader, Message: "must be a non-negative integer"}
+		}
+		q.After = &after
+	}
+	return q, nil
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrInvalidIdempotencyKey = errors.New("idempotency key must be 1 to 255 printable characters")
+)
+
+const IdempotencyHeader = "Idempotency-Key"
+
+// IdempotencyRecord is the stored outcome of a request made with an
+// Idempotency-Key. StatusCode is nil while the first request is running.
+type IdempotencyRecord struct {
+	Owner        string `gorm:"primaryKey"`
+	Key          string `gorm:"primaryKey"`
+	Method       string
+	Path         string
+	Fingerprint  string
+	StatusCode   *int
+	ResponseType *string
+	Headers      map[string]string `gorm:"type:jsonb;serializer:json"`
+	Body         []byte
+	CreatedAt    time.Time `gorm:"not null;default:now()"`
+	CompletedAt  *time.Time
+	ExpiresAt    time.Time
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != nil
+}
+
+func ValidIdempotencyKey(key string) bool {
+	if len(key) == 0 || len(key) > 255 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,208 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SortField string
+
+const (
+	SortCreatedAt SortField = "created_at"
+	SortTitle     SortField = "title"
+	SortFilename  SortField = "filename"
+	SortDuration  SortField = "duration"
+	SortSize      SortField = "size"
+	SortStatus    SortField = "status"
+	SortRelevance SortField = "relevance"
+)
+
+// relevance is computed from the search query, so it has no fixed expression here
+var sortColumns = map[SortField]string{
+	SortCreatedAt: "created_at",
+	SortTitle:     "title",
+	SortFilename:  "filename",
+	SortDuration:  "COALESCE(duration_s, 0)",
+	SortSize:      "size_bytes",
+	SortStatus:    "status",
+	SortRelevance: "",
+}
+
+type VideoQuery struct {
+	Archive      ListFilter
+	Statuses     []VideoStatus
+	Visibility   []Visibility
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	MinDurationS *int
+	MaxDurationS *int
+	MinSizeBytes *int64
+	MaxSizeBytes *int64
+	Tags         []string
+	Search       string
+	OwnerID      *string
+	Sort         SortField
+	Desc         bool
+}
+
+func (q VideoQuery) SortColumn() string {
+	return sortColumns[q.Sort]
+}
+
+func ParseVideoQuery(values url.Values) (VideoQuery, error) {
+	q := VideoQuery{
+		Search: strings.TrimSpace(values.Get("q")),
+	}
+
+	archive, ok := ParseListFilter(values.Get("status"))
+	if !ok {
+		return q, &ValidationError{Field: "status", Message: "must be one of all, active, archived"}
+	}
+	q.Archive = archive
+
+	for _, raw := range splitList(values["processing_status"]) {
+		status := VideoStatus(strings.ToLower(raw))
+		switch status {
+		case StatusUploaded, StatusProcessing, StatusComplete, StatusInterrupted:
+			q.Statuses = append(q.Statuses, status)
+		default:
+			return q, &ValidationError{Field: "processing_status", Message: fmt.Sprintf("unknown status %q", raw)}
+		}
+	}
+
+	for _, raw := range splitList(values["visibility"]) {
+		visibility, err := ParseVisibility(raw)
+		if err != nil {
+			return q, err
+		}
+		q.Visibility = append(q.Visibility, visibility)
+	}
+
+	var err error
+	if q.CreatedFrom, err = parseTimeParam(values, "created_from", false); err != nil {
+		return q, err
+	}
+	if q.CreatedTo, err = parseTimeParam(values, "created_to", true); err != nil {
+		return q, err
+	}
+	if q.CreatedFrom != nil && q.CreatedTo != nil && q.CreatedTo.Before(*q.CreatedFrom) {
+		return q, &ValidationError{Field: "created_to", Message: "must not be before created_from"}
+	}
+
+	if q.MinDurationS, err = parseIntParam[int](values, "min_duration"); err != nil {
+		return q, err
+	}
+	if q.MaxDurationS, err = parseIntParam[int](values, "max_duration"); err != nil {
+		return q, err
+	}
+	if q.MinDurationS != nil && q.MaxDurationS != nil && *q.MaxDurationS < *q.MinDurationS {
+		return q, &ValidationError{Field: "max_duration", Message: "must not be less than min_duration"}
+	}
+
+	if q.MinSizeBytes, err = parseIntParam[int64](values, "min_size"); err != nil {
+		return q, err
+	}
+	if q.MaxSizeBytes, err = parseIntParam[int64](values, "max_size"); err != nil {
+		return q, err
+	}
+	if q.MinSizeBytes != nil && q.MaxSizeBytes != nil && *q.MaxSizeBytes < *q.MinSizeBytes {
+		return q, &ValidationError{Field: "max_size", Message: "must not be less than min_size"}
+	}
+
+	if tags := splitList(values["tags"]); len(tags) > 0 {
+		if q.Tags, err = NormalizeTags(tags); err != nil {
+			return q, err
+		}
+	}
+
+	if err := q.parseSort(values.Get("sort")); err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+func (q *VideoQuery) parseSort(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		if q.Search != "" {
+			q.Sort, q.Desc = SortRelevance, true
+		} else {
+			q.Sort, q.Desc = SortCreatedAt, true
+		}
+		return nil
+	}
+
+	field, dir, hasDir := strings.Cut(raw, ":")
+	desc := false
+	if strings.HasPrefix(field, "-") {
+		field, desc = field[1:], true
+	}
+	if hasDir {
+		switch strings.ToLower(dir) {
+		case "asc":
+			desc = false
+		case "desc":
+			desc = true
+		default:
+			return &ValidationError{Field: "sort", Message: fmt.Sprintf("unknown direction %q", dir)}
+		}
+	}
+
+	sort := SortField(strings.ToLower(field))
+	if _, ok := sortColumns[sort]; !ok {
+		return &ValidationError{Field: "sort", Message: fmt.Sprintf("unsupported sort field %q", field)}
+	}
+	if sort == SortRelevance && q.Search == "" {
+		return &ValidationError{Field: "sort", Message: "relevance requires q"}
+	}
+
+	q.Sort, q.Desc = sort, desc
+	return nil
+}
+
+func splitList(values []string) []string {
+	var result []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+func parseTimeParam(values url.Values, key string, endOfDay bool) (*time.Time, error) {
+	raw := strings.TrimSpace(values.Get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return nil, &ValidationError{Field: key, Message: "must be an RFC 3339 timestamp or YYYY-MM-DD date"}
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func parseIntParam[T int | int64](values url.Values, key string) (*T, error) {
+	raw := strings.TrimSpace(values.Get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n < 0 {
+		return nil, &ValidationError{Field: key, Message: "must be a non-negative integer"}
+	}
+	v := T(n)
+	return &v, nil
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseVideoQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		field    string
+		sort     SortField
+		desc     bool
+		statuses []VideoStatus
+		tags     []string
+	}{
+		{name: "defaults", query: "", sort: SortCreatedAt, desc: true},
+		{name: "search sorts by relevance", query: "q=cats", sort: SortRelevance, desc: true},
+		{name: "prefix descending", query: "sort=-title", sort: SortTitle, desc: true},
+		{name: "explicit direction", query: "sort=Size:asc", sort: SortSize},
+		{name: "direction overrides prefix", query: "sort=-duration:asc", sort: SortDuration},
+		{name: "status lists", query: "processing_status=complete,Uploaded&processing_status=interrupted",
+			sort: SortCreatedAt, desc: true, statuses: []VideoStatus{StatusComplete, StatusUploaded, StatusInterrupted}},
+		{name: "tags are normalized", query: "tags=Cats,%20dogs%20,cats", sort: SortCreatedAt, desc: true, tags: []string{"cats", "dogs"}},
+		{name: "unknown archive filter", query: "status=deleted", field: "status"},
+		{name: "unknown processing status", query: "processing_status=done", field: "processing_status"},
+		{name: "unknown visibility", query: "visibility=secret", field: "visibility"},
+		{name: "bad date", query: "created_from=yesterday", field: "created_from"},
+		{name: "reversed dates", query: "created_from=2024-02-01&created_to=2024-01-01", field: "created_to"},
+		{name: "negative duration", query: "min_duration=-1", field: "min_duration"},
+		{name: "reversed durations", query: "min_duration=10&max_duration=5", field: "max_duration"},
+		{name: "reversed sizes", query: "min_size=10&max_size=5", field: "max_size"},
+		{name: "unknown sort", query: "sort=views", field: "sort"},
+		{name: "unknown direction", query: "sort=title:up", field: "sort"},
+		{name: "relevance without search", query: "sort=relevance", field: "sort"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q, err := ParseVideoQuery(values)
+			if tt.field != "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Field != tt.field {
+					t.Fatalf("error = %v, want a validation error on %q", err, tt.field)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if q.Sort != tt.sort || q.Desc != tt.desc {
+				t.Errorf("sort = %s desc=%t, want %s desc=%t", q.Sort, q.Desc, tt.sort, tt.desc)
+			}
+			if !slices.Equal(q.Statuses, tt.statuses) {
+				t.Errorf("statuses = %v, want %v", q.Statuses, tt.statuses)
+			}
+			if !slices.Equal(q.Tags, tt.tags) {
+				t.Errorf("tags = %v, want %v", q.Tags, tt.tags)
+			}
+		})
+	}
+}
+
+func TestParseVideoQueryDateRange(t *testing.T) {
+	values := url.Values{"created_from": {"2024-01-01"}, "created_to": {"2024-01-31"}}
+	q, err := ParseVideoQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !q.CreatedFrom.Equal(want) {
+		t.Errorf("created_from = %v, want %v", q.CreatedFrom, want)
+	}
+	// a date as the upper bound includes the whole day
+	if want := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond); !q.CreatedTo.Equal(want) {
+		t.Errorf("created_to = %v, want %v", q.CreatedTo, want)
+	}
+}
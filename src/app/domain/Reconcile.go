@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+type StorageArea string
+
+const (
+	AreaRaw       StorageArea = "raw"
+	AreaTmp       StorageArea = "tmp"
+	AreaConverted StorageArea = "converted"
+	AreaArchive   StorageArea = "archive"
+)
+
+type OrphanEntry struct {
+	Area      StorageArea `json:"area"`
+	Path      string      `json:"path"`
+	Slug      string      `json:"slug"`
+	SizeBytes int64       `json:"sizeBytes"`
+	ModTime   time.Time   `json:"modTime"`
+	Deleted   bool        `json:"deleted"`
+}
+
+type MissingArtifact struct {
+	VideoID string      `json:"videoId"`
+	Slug    string      `json:"slug"`
+	Status  string      `json:"status"`
+	Area    StorageArea `json:"area"`
+	Path    string      `json:"path"`
+	Marked  bool        `json:"marked"`
+}
+
+type ReconcileReport struct {
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt time.Time         `json:"finishedAt"`
+	Scanned    int               `json:"scanned"`
+	Orphans    []OrphanEntry     `json:"orphans"`
+	Missing    []MissingArtifact `json:"missing"`
+}
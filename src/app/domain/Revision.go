@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision is not found")
+	ErrRevisionPending  = errors.New("a new source is still being processed")
+	ErrRevisionNotReady = errors.New("revision has no playable output")
+)
+
+type RevisionStatus string
+
+const (
+	RevisionPending    RevisionStatus = "pending"
+	RevisionProcessing RevisionStatus = "processing"
+	RevisionReady      RevisionStatus = "ready"
+	RevisionFailed     RevisionStatus = "failed"
+)
+
+// VideoRevision is one uploaded source of a video. The video points at its
+// current revision; the others are kept so editors can roll back.
+type VideoRevision struct {
+	ID                 string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	VideoID            string `gorm:"type:uuid"`
+	Number             int
+	Filename           string
+	SizeBytes          int64
+	DurationS          sql.NullInt32
+	Status             RevisionStatus `gorm:"type:text;not null;default:pending"`
+	FailureReason      *string
+	ConvertedSizeBytes *int64
+	CreatedBy          *string   `gorm:"type:uuid"`
+	CreatedAt          time.Time `gorm:"not null;default:now()"`
+	ReadyAt            *time.Time
+}
+
+func (VideoRevision) TableName() string {
+	return "video_revisions"
+}
+
+func (r VideoRevision) IsPending() bool {
+	return r.Status == RevisionPending || r.Status == RevisionProcessing
+}
+
+type VideoRevisionDTO struct {
+	Number        int
+	Filename      string
+	SizeBytes     int64
+	DurationS     sql.NullInt32
+	Status        RevisionStatus
+	FailureReason *string
+	Current       bool
+	CreatedAt     time.Time
+	ReadyAt       *time.Time
+}
+
+func (r VideoRevision) ToDto(current int) VideoRevisionDTO {
+	return VideoRevisionDTO{
+		Number:        r.Number,
+		Filename:      r.Filename,
+		SizeBytes:     r.SizeBytes,
+		DurationS:     r.DurationS,
+		Status:        r.Status,
+		FailureReason: r.FailureReason,
+		Current:       r.Number == current,
+		CreatedAt:     r.CreatedAt,
+		ReadyAt:       r.ReadyAt,
+	}
+}
+
+// RevisionDirName names the storage directories of a revision. The first
+// revision keeps the plain slug so files of existing videos stay in place.
+func RevisionDirName(slug string, revision int) string {
+	if revision <= 1 {
+		return slug
+	}
+	return fmt.Sprintf("%s.r%d", slug, revision)
+}
+
+// ParseRevisionDirName is the inverse of RevisionDirName.
+func ParseRevisionDirName(name string) (string, int) {
+	slug, suffix, ok := strings.Cut(name, ".r")
+	if !ok {
+		return name, 1
+	}
+	revision, err := strconv.Atoi(suffix)
+	if err != nil || revision <= 1 {
+		return name, 1
+	}
+	return slug, revision
+}
@@ -0,0 +1,35 @@
+package domain
+
+type StorageUsage struct {
+	TotalBytes int64            `json:"totalBytes"`
+	ByStatus   map[string]int64 `json:"byStatus"`
+}
+
+type VideoStorageEntry struct {
+	ID                 string `json:"id"`
+	Filename           string `json:"filename"`
+	Slug               string `json:"slug"`
+	Status             string `json:"status"`
+	SizeBytes          int64  `json:"sizeBytes"`
+	ConvertedSizeBytes *int64 `json:"convertedSizeBytes"`
+	TotalBytes         int64  `json:"totalBytes"`
+}
+
+type StorageStats struct {
+	Raw            StorageUsage        `json:"raw"`
+	Converted      StorageUsage        `json:"converted"`
+	Archived       StorageUsage        `json:"archived"`
+	Counts         map[string]int64    `json:"counts"`
+	TotalCount     int64               `json:"totalCount"`
+	TotalDurationS int64               `json:"totalDurationS"`
+	Largest        []VideoStorageEntry `json:"largest"`
+}
+
+const (
+	DefaultStatsTop = 10
+	MaxStatsTop     = 100
+)
+
+func NewStorageUsage() StorageUsage {
+	return StorageUsage{ByStatus: map[string]int64{}}
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrSubtitleNotFound = errors.New("subtitle track is not found")
+	ErrInvalidSubtitles = errors.New("file is not a valid srt or webvtt subtitle file")
+)
+
+const (
+	MaxSubtitleBytes    = 5 << 20
+	MaxSubtitleLabelLen = 100
+)
+
+type SubtitleFormat string
+
+const (
+	SubtitleSRT    SubtitleFormat = "srt"
+	SubtitleWebVTT SubtitleFormat = "vtt"
+)
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Subtitle is a caption track of a video in one language. Content is always
+// WebVTT; Format records what was uploaded.
+type Subtitle struct {
+	ID        string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	VideoID   string `gorm:"type:uuid"`
+	Language  string
+	Label     string
+	Format    SubtitleFormat `gorm:"type:text"`
+	IsDefault bool           `gorm:"not null;default:false"`
+	CueCount  int
+	Content   string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"not null;default:now()"`
+	UpdatedAt time.Time `gorm:"not null;default:now()"`
+}
+
+func (Subtitle) TableName() string {
+	return "video_subtitles"
+}
+
+type SubtitleDTO struct {
+	Language  string
+	Label     string
+	Format    SubtitleFormat
+	Default   bool
+	CueCount  int
+	Url       string
+	UpdatedAt time.Time
+}
+
+func (s Subtitle) ToDto() SubtitleDTO {
+	return SubtitleDTO{
+		Language:  s.Language,
+		Label:     s.Label,
+		Format:    s.Format,
+		Default:   s.IsDefault,
+		CueCount:  s.CueCount,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func SubtitleDtos(subtitles []Subtitle) []SubtitleDTO {
+	dtos := make([]SubtitleDTO, 0, len(subtitles))
+	for _, s := range subtitles {
+		dtos = append(dtos, s.ToDto())
+	}
+	return dtos
+}
+
+// NormalizeLanguage lower-cases a BCP 47 language tag such as "en" or
+// "pt-BR" and rejects anything else.
+func NormalizeLanguage(s string) (string, error) {
+	language := strings.ToLower(strings.TrimSpace(s))
+	if !languagePattern.MatchString(language) || len(language) > 35 {
+		return "", &ValidationError{Field: "language", Message: "must be a BCP 47 language tag such as en or pt-BR"}
+	}
+	return language, nil
+}
+
+func NormalizeSubtitleLabel(s, language string) (string, error) {
+	label := strings.Join(strings.Fields(s), " ")
+	if label == "" {
+		return language, nil
+	}
+	if utf8.RuneCountInString(label) > MaxSubtitleLabelLen {
+		return "", &ValidationError{Field: "label", Message: fmt.Sprintf("must be at most %d characters", MaxSubtitleLabelLen)}
+	}
+	return label, nil
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// UntitledTitle names uploads whose file name yields no title.
+	UntitledTitle        = "Untitled"
+	MaxTitleLength       = 200
+	MaxDescriptionLength = 5000
+	MaxTagLength         = 50
+	MaxTagsPerVideo      = 20
+)
+
+type Tag struct {
+	ID   int64 `gorm:"primaryKey"`
+	Name string
+}
+
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors reports several invalid fields at once.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, v := range e {
+		messages = append(messages, v.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func TagNames(tags []Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func NormalizeTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", &ValidationError{Field: "title", Message: "must not be empty"}
+	}
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return "", &ValidationError{Field: "title", Message: fmt.Sprintf("is too long (max %d chars)", MaxTitleLength)}
+	}
+	return title, nil
+}
+
+// TitleFromFilename derives the default title of an upload: the file name
+// without its extension, cut to MaxTitleLength.
+func TitleFromFilename(filename string) string {
+	title := strings.TrimSpace(strings.TrimSuffix(filename, filepath.Ext(filename)))
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		title = strings.TrimSpace(string([]rune(title)[:MaxTitleLength]))
+	}
+	if title == "" {
+		return UntitledTitle
+	}
+	return title
+}
+
+func NormalizeDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return "", &ValidationError{Field: "description", Message: fmt.Sprintf("is too long (max %d chars)", MaxDescriptionLength)}
+	}
+	return description, nil
+}
+
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	result := make([]string, 0, len(tags))
+
+	for _, raw := range tags {
+		tag := strings.ToLower(strings.Join(strings.Fields(raw), " "))
+		if tag == "" {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("tag %q is too long (max %d chars)", tag, MaxTagLength)}
+		}
+		for _, r := range tag {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != ' ' {
+				return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("tag %q contains invalid character %q", tag, r)}
+			}
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		result = append(result, tag)
+	}
+
+	if len(result) > MaxTagsPerVideo {
+		return nil, &ValidationError{Field: "tags", Message: fmt.Sprintf("too many tags (max %d)", MaxTagsPerVideo)}
+	}
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTitleFromFilename(t *testing.T) {
+	long := strings.Repeat("é", MaxTitleLength+10)
+
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"holiday.mp4", "holiday"},
+		{"  my trip.final.mov ", "my trip.final"},
+		{"no extension", "no extension"},
+		{".mp4", UntitledTitle},
+		{"   ", UntitledTitle},
+		{"", UntitledTitle},
+		{long + ".mp4", strings.Repeat("é", MaxTitleLength)},
+	}
+	for _, tt := range tests {
+		got := TitleFromFilename(tt.filename)
+		if got != tt.want {
+			t.Errorf("TitleFromFilename(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+		if _, err := NormalizeTitle(got); err != nil {
+			t.Errorf("TitleFromFilename(%q) = %q, which is not a valid title: %v", tt.filename, got, err)
+		}
+	}
+}
+
+func TestNormalizeTitleAndDescription(t *testing.T) {
+	if got, err := NormalizeTitle("  Holiday  "); err != nil || got != "Holiday" {
+		t.Errorf("NormalizeTitle = %q, %v", got, err)
+	}
+	for _, title := range []string{"", "  ", strings.Repeat("a", MaxTitleLength+1)} {
+		var validationErr *ValidationError
+		if _, err := NormalizeTitle(title); !errors.As(err, &validationErr) || validationErr.Field != "title" {
+			t.Errorf("NormalizeTitle(%d chars) error = %v", utf8.RuneCountInString(title), err)
+		}
+	}
+
+	if got, err := NormalizeDescription(" \n "); err != nil || got != "" {
+		t.Errorf("NormalizeDescription = %q, %v", got, err)
+	}
+	var validationErr *ValidationError
+	if _, err := NormalizeDescription(strings.Repeat("a", MaxDescriptionLength+1)); !errors.As(err, &validationErr) || validationErr.Field != "description" {
+		t.Errorf("NormalizeDescription error = %v", err)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	many := make([]string, MaxTagsPerVideo+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("tag%d", i)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+		ok   bool
+	}{
+		{"lowercased and deduplicated", []string{"Cats", "cats", " DOGS "}, []string{"cats", "dogs"}, true},
+		{"inner spaces collapsed", []string{"road   trip"}, []string{"road trip"}, true},
+		{"blank tags dropped", []string{"", "  ", "a"}, []string{"a"}, true},
+		{"letters, digits, dash and underscore", []string{"año-2024_x"}, []string{"año-2024_x"}, true},
+		{"punctuation", []string{"c++"}, nil, false},
+		{"too long", []string{strings.Repeat("a", MaxTagLength+1)}, nil, false},
+		{"too many", many, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTags(tt.tags)
+			if !tt.ok {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Field != "tags" {
+					t.Fatalf("error = %v, want a validation error on tags", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
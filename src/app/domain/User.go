@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnauthorized       = errors.New("authentication required")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidToken       = errors.New("token is invalid or expired")
+	ErrUserExists         = errors.New("user already exists")
+)
+
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+type User struct {
+	ID           string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Email        string
+	PasswordHash string
+	Role         string    `gorm:"not null;default:user"`
+	CreatedAt    time.Time `gorm:"not null;default:now()"`
+	UpdatedAt    time.Time `gorm:"not null;default:now()"`
+}
+
+type RefreshToken struct {
+	ID        string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string `gorm:"type:uuid"`
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time `gorm:"not null;default:now()"`
+}
+
+type UserDTO struct {
+	ID        string
+	Email     string
+	Role      string
+	CreatedAt time.Time
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+// Principal is the authenticated caller of a request. Scopes is nil for
+// interactive users, whose permissions follow from Role alone.
+type Principal struct {
+	UserID   string
+	Role     Role
+	APIKeyID string
+	Scopes   []Scope
+}
+
+func (p Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+func (p Principal) HasScope(scope Scope) bool {
+	if p.Scopes == nil {
+		return scope != ScopeAdmin || p.IsAdmin()
+	}
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Principal) Actor() string {
+	switch {
+	case p.APIKeyID != "":
+		return "apikey:" + p.APIKeyID
+	case p.UserID != "":
+		return "user:" + p.UserID
+	default:
+		return ActorAnonymous
+	}
+}
+
+func (p Principal) IsAnonymous() bool {
+	return p.UserID == "" && p.APIKeyID == ""
+}
+
+// CanView reports whether the caller may watch a video through its share
+// link. Unlike CanAccess it does not grant management rights.
+func (p Principal) CanView(video *Video) bool {
+	if Visibility(video.Visibility) != VisibilityPrivate {
+		return true
+	}
+	return !p.IsAnonymous() && p.HasScope(ScopeVideoRead) && p.CanAccess(video)
+}
+
+func (p Principal) CanAccess(video *Video) bool {
+	return p.owns(video.OwnerID)
+}
+
+func (p Principal) CanAccessCollection(collection *Collection) bool {
+	return p.owns(collection.OwnerID)
+}
+
+func (p Principal) owns(ownerID *string) bool {
+	if p.IsAdmin() {
+		return true
+	}
+	return ownerID != nil && *ownerID == p.UserID
+}
+
+func (u User) ToDto() UserDTO {
+	return UserDTO{
+		ID:        u.ID,
+		Email:     u.Email,
+		Role:      u.Role,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+func ParseRole(s string) (Role, bool) {
+	switch Role(strings.ToLower(strings.TrimSpace(s))) {
+	case "", RoleUser:
+		return RoleUser, true
+	case RoleAdmin:
+		return RoleAdmin, true
+	default:
+		return RoleUser, false
+	}
+}
+
+func NormalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", &ValidationError{Field: "email", Message: "must be a valid email address"}
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+func ValidatePassword(password string) error {
+	n := utf8.RuneCountInString(password)
+	if n < MinPasswordLength {
+		return &ValidationError{Field: "password", Message: "is too short (min 8 chars)"}
+	}
+	if len(password) > MaxPasswordLength {
+		return &ValidationError{Field: "password", Message: "is too long (max 72 bytes)"}
+	}
+	return nil
+}
@@ -12,6 +12,11 @@ var (
 	ErrIncorrectUuid     = errors.New("incorrect uuid format")
 	ErrVideoNotFound     = errors.New("video is not found")
 	ErrVideoIsProcessing = errors.New("video is being processed")
+	ErrForbidden         = errors.New("operation is not permitted")
+	ErrVideoArchived     = errors.New("video is archived")
+	ErrUnsupportedMedia  = errors.New("file is not a supported video")
+	ErrInvalidJSON       = errors.New("request body is not valid json")
+	ErrVersionMismatch   = errors.New("video was modified since it was read")
 )
 
 const (
@@ -20,40 +25,70 @@ const (
 )
 
 type Video struct {
-	ID        string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	Filename  string
-	Slug      string
-	SizeBytes int64
-	DurationS sql.NullInt32
-	CreatedAt time.Time `gorm:"not null;default:now()"`
+	ID          string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Filename    string
+	Title       string `gorm:"not null;default:''"`
+	Description string `gorm:"not null;default:''"`
+	Tags        []Tag  `gorm:"many2many:video_tags"`
+	TagsText    string `gorm:"not null;default:''"`
+	Slug        string
+	SizeBytes   int64
+	DurationS   sql.NullInt32
+	CreatedAt   time.Time `gorm:"not null;default:now()"`
 
 	Status              string `gorm:"type:text;not null;default:uploaded"`
 	RetryAttempt        int    `gorm:"not null;default:0"`
 	FailureReason       *string
 	ProcessingStartedAt *time.Time
 	HLSReadyAt          *time.Time
+	ConvertedSizeBytes  *int64
 
 	ArchivedAt *time.Time
+	OwnerID    *string    `gorm:"type:uuid"`
+	Visibility string     `gorm:"type:text;not null;default:private"`
+	Version    int        `gorm:"not null;default:1"`
+	Revision   int        `gorm:"not null;default:1"`
+	Chapters   []Chapter  `gorm:"foreignKey:VideoID"`
+	Subtitles  []Subtitle `gorm:"foreignKey:VideoID"`
+
+	SearchLanguage string  `gorm:"type:regconfig;not null;default:simple"`
+	SearchRank     float64 `gorm:"->"`
+	SearchSnippet  string  `gorm:"->"`
 }
 
 type VideoDTO struct {
 	ID           string
 	Filename     string
+	Title        string
+	Description  string
+	Tags         []string
 	Slug         string
 	SizeBytes    int64
 	DurationS    sql.NullInt32
 	ConvertedUrl string
 	Status       string
+	OwnerID      *string
+	Visibility   string
+	Version      int
+	Revision     int
+	Chapters     []ChapterDTO
+	ChaptersUrl  string
+	Subtitles    []SubtitleDTO
+	Rank         *float64 `json:"Rank,omitempty"`
+	Snippet      *string  `json:"Snippet,omitempty"`
 }
 
 type Pagination struct {
-	Limit  uint `json:"limit"`
-	Offset uint `json:"offset"`
+	Limit     uint    `json:"limit"`
+	Offset    uint    `json:"offset"`
+	Cursor    *string `json:"cursor,omitempty"`
+	WithTotal bool    `json:"withTotal"`
 }
 
 type ListPayload[T any] struct {
-	Data       []T   `json:"data"`
-	TotalCount int64 `json:"totalCount"`
+	Data       []T    `json:"data"`
+	TotalCount *int64 `json:"totalCount,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type VideoStatus string
@@ -66,6 +101,45 @@ const (
 	StatusArchived    VideoStatus = "archived"
 )
 
+type Visibility string
+
+const (
+	VisibilityPublic   Visibility = "public"
+	VisibilityUnlisted Visibility = "unlisted"
+	VisibilityPrivate  Visibility = "private"
+)
+
+func ParseVisibility(s string) (Visibility, error) {
+	switch v := Visibility(strings.ToLower(strings.TrimSpace(s))); v {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return v, nil
+	default:
+		return "", &ValidationError{Field: "visibility", Message: "must be one of public, unlisted, private"}
+	}
+}
+
+type VideoMetadata struct {
+	Title       *string
+	Description *string
+	Tags        *[]string
+	Visibility  *string
+}
+
+// SharedVideoDTO is the anonymous view of a video served on share pages.
+type SharedVideoDTO struct {
+	Slug         string
+	Title        string
+	Description  string
+	Tags         []string
+	DurationS    sql.NullInt32
+	Status       string
+	Visibility   string
+	CreatedAt    time.Time
+	ConvertedUrl string
+	PosterUrl    string
+	EmbedUrl     string
+}
+
 type ListFilter string
 
 const (
@@ -78,11 +152,52 @@ func (v Video) ToDto() VideoDTO {
 	return VideoDTO{
 		ID:           v.ID,
 		Filename:     v.Filename,
+		Title:        v.Title,
+		Description:  v.Description,
+		Tags:         TagNames(v.Tags),
 		Slug:         v.Slug,
 		SizeBytes:    v.SizeBytes,
 		DurationS:    v.DurationS,
 		ConvertedUrl: "",
 		Status:       v.Status,
+		OwnerID:      v.OwnerID,
+		Visibility:   v.Visibility,
+		Version:      v.Version,
+		Revision:     v.Revision,
+		Chapters:     ChapterDtos(v.Chapters),
+		Subtitles:    SubtitleDtos(v.Subtitles),
+	}
+}
+
+func (v Video) ToSharedDto() SharedVideoDTO {
+	return SharedVideoDTO{
+		Slug:        v.Slug,
+		Title:       v.Title,
+		Description: v.Description,
+		Tags:        TagNames(v.Tags),
+		DurationS:   v.DurationS,
+		Status:      v.Status,
+		Visibility:  v.Visibility,
+		CreatedAt:   v.CreatedAt,
+	}
+}
+
+func (v Video) SortValue(field SortField) any {
+	switch field {
+	case SortTitle:
+		return v.Title
+	case SortFilename:
+		return v.Filename
+	case SortDuration:
+		return v.DurationS.Int32
+	case SortSize:
+		return v.SizeBytes
+	case SortStatus:
+		return v.Status
+	case SortRelevance:
+		return v.SearchRank
+	default:
+		return v.CreatedAt.UTC().Format(time.RFC3339Nano)
 	}
 }
 
@@ -90,6 +205,10 @@ func (v Video) IsProcessing() bool {
 	return v.Status == string(StatusProcessing)
 }
 
+func (p Pagination) IsCursor() bool {
+	return p.Cursor != nil
+}
+
 func (p *Pagination) Normalize() {
 	if p.Limit == 0 {
 		p.Limit = DefaultLimit
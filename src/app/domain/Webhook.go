@@ -0,0 +1,282 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook is not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery is not found")
+	ErrWebhookDisabled  = errors.New("webhook is disabled")
+	// ErrWebhookAddressNotPublic rejects receivers on loopback, private or
+	// otherwise internal addresses.
+	ErrWebhookAddressNotPublic = errors.New("webhook receiver address is not public")
+)
+
+const (
+	MaxWebhookURLLen         = 2048
+	MaxWebhookDescriptionLen = 500
+	// MaxDeliveryResponseBytes caps how much of a receiver's response is kept
+	// in the delivery log.
+	MaxDeliveryResponseBytes = 4096
+)
+
+type WebhookEvent string
+
+const (
+	EventVideoUploaded   WebhookEvent = "video.uploaded"
+	EventVideoProcessing WebhookEvent = "video.processing"
+	EventVideoReady      WebhookEvent = "video.ready"
+	EventVideoFailed     WebhookEvent = "video.failed"
+	EventVideoArchived   WebhookEvent = "video.archived"
+)
+
+var knownWebhookEvents = map[WebhookEvent]struct{}{
+	EventVideoUploaded:   {},
+	EventVideoProcessing: {},
+	EventVideoReady:      {},
+	EventVideoFailed:     {},
+	EventVideoArchived:   {},
+}
+
+// ParseWebhookEvents normalizes an event filter. An empty filter subscribes
+// to every event.
+func ParseWebhookEvents(raw []string) ([]string, error) {
+	seen := make(map[WebhookEvent]struct{}, len(raw))
+	events := make([]string, 0, len(raw))
+	for _, r := range raw {
+		event := WebhookEvent(strings.ToLower(strings.TrimSpace(r)))
+		if _, ok := knownWebhookEvents[event]; !ok {
+			return nil, &ValidationError{Field: "events", Message: fmt.Sprintf("unknown event %q", r)}
+		}
+		if _, ok := seen[event]; ok {
+			continue
+		}
+		seen[event] = struct{}{}
+		events = append(events, string(event))
+	}
+	return events, nil
+}
+
+// reservedPrefixes are special-purpose ranges that netip does not classify
+// as loopback, private, link-local or multicast.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// IsPublicWebhookAddr reports whether addr is a public unicast address.
+func IsPublicWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// WebhookAddrAllowed reports whether a webhook may be delivered to addr:
+// public addresses always, internal ones only within allowed.
+func WebhookAddrAllowed(addr netip.Addr, allowed []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return IsPublicWebhookAddr(addr)
+}
+
+// ValidateWebhookURL accepts absolute http and https URLs. Hosts given as
+// addresses must be public or within allowed; names are checked again when
+// connecting, as they may resolve differently by then.
+func ValidateWebhookURL(raw string, allowed []netip.Prefix) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", &ValidationError{Field: "url", Message: "must not be empty"}
+	}
+	if len(raw) > MaxWebhookURLLen {
+		return "", &ValidationError{Field: "url", Message: fmt.Sprintf("must be at most %d characters", MaxWebhookURLLen)}
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", &ValidationError{Field: "url", Message: "must be an absolute http or https URL"}
+	}
+	if u.User != nil {
+		return "", &ValidationError{Field: "url", Message: "must not contain credentials"}
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		host = "127.0.0.1"
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !WebhookAddrAllowed(addr, allowed) {
+		return "", &ValidationError{Field: "url", Message: "must not point to an internal address"}
+	}
+	return u.String(), nil
+}
+
+func NormalizeWebhookDescription(raw string) (string, error) {
+	description := strings.TrimSpace(raw)
+	if utf8.RuneCountInString(description) > MaxWebhookDescriptionLen {
+		return "", &ValidationError{Field: "description", Message: fmt.Sprintf("must be at most %d characters", MaxWebhookDescriptionLen)}
+	}
+	return description, nil
+}
+
+// Webhook is a subscription of an external endpoint to video lifecycle
+// events. Deliveries are signed with Secret.
+type Webhook struct {
+	ID          string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	URL         string
+	Description string
+	Events      pq.StringArray `gorm:"type:text[]"`
+	Secret      string
+	Active      bool
+	CreatedBy   *string   `gorm:"type:uuid"`
+	CreatedAt   time.Time `gorm:"not null;default:now()"`
+	UpdatedAt   time.Time `gorm:"not null;default:now()"`
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+func (w Webhook) Subscribes(event WebhookEvent) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if WebhookEvent(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDTO struct {
+	ID          string
+	URL         string
+	Description string
+	Events      []string
+	Active      bool
+	CreatedBy   *string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	// Secret is only populated in the response that creates the webhook.
+	Secret string `json:",omitempty"`
+}
+
+func (w Webhook) ToDto() WebhookDTO {
+	events := []string(w.Events)
+	if events == nil {
+		events = []string{}
+	}
+	return WebhookDTO{
+		ID:          w.ID,
+		URL:         w.URL,
+		Description: w.Description,
+		Events:      events,
+		Active:      w.Active,
+		CreatedBy:   w.CreatedBy,
+		CreatedAt:   w.CreatedAt,
+		UpdatedAt:   w.UpdatedAt,
+	}
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent to one webhook, with the outcome of its
+// latest attempt. Pending deliveries are retried at NextAttemptAt until they
+// succeed or run out of attempts.
+type WebhookDelivery struct {
+	ID             string `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	WebhookID      string `gorm:"type:uuid"`
+	EventID        string `gorm:"type:uuid"`
+	Event          WebhookEvent
+	Payload        string         `gorm:"type:jsonb"`
+	Status         DeliveryStatus `gorm:"type:text;not null;default:pending"`
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode *int
+	LastError      *string
+	ResponseBody   *string
+	CreatedAt      time.Time `gorm:"not null;default:now()"`
+	DeliveredAt    *time.Time
+
+	Webhook *Webhook `gorm:"foreignKey:WebhookID"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+type WebhookDeliveryDTO struct {
+	ID             string
+	WebhookID      string
+	EventID        string
+	Event          WebhookEvent
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastStatusCode *int
+	LastError      *string
+	ResponseBody   *string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+func (d WebhookDelivery) ToDto() WebhookDeliveryDTO {
+	dto := WebhookDeliveryDTO{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		ResponseBody:   d.ResponseBody,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+	if d.Status == DeliveryPending {
+		dto.NextAttemptAt = &d.NextAttemptAt
+	}
+	return dto
+}
+
+// WebhookPayload is the body POSTed to a webhook. ID identifies the event
+// and is shared by every delivery of it, including redeliveries.
+type WebhookPayload struct {
+	ID        string
+	Type      WebhookEvent
+	CreatedAt time.Time
+	Video     VideoDTO
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type AnalyticsHandler struct {
+	service *service.AnalyticsService
+	logger  *zap.Logger
+}
+
+func NewAnalyticsHandler(analyticsService *service.AnalyticsService, logger *zap.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		service: analyticsService,
+		logger:  logger,
+	}
+}
+
+func (h *AnalyticsHandler) GetAnalytics(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	r, err := domain.ParseAnalyticsRange(ctx.Query("from"), ctx.Query("to"), time.Now())
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	analytics, err := h.service.Get(ctx.Request.Context(), util.PrincipalFrom(ctx), id, r)
+	if err != nil {
+		h.logger.Info("error getting video analytics", zap.String("id", id), zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, analytics)
+}
+
+var AnalyticsModule = fx.Module("analytics-handler", fx.Provide(NewAnalyticsHandler))
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type APIKeyHandler struct {
+	service *service.APIKeyService
+	logger  *zap.Logger
+}
+
+type CreateAPIKeyRequestPayload struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	OwnerID   string     `json:"ownerId"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: apiKeyService,
+		logger:  logger,
+	}
+}
+
+func (h *APIKeyHandler) GetAPIKeys(ctx *gin.Context) {
+	keys, err := h.service.GetAll(ctx.Request.Context())
+
+	if err != nil {
+		h.logger.Error("error listing api keys", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	dtos := make([]domain.APIKeyDTO, 0, len(keys))
+	for _, key := range keys {
+		dtos = append(dtos, key.ToDto())
+	}
+	total := int64(len(dtos))
+
+	ctx.JSON(200, domain.ListPayload[domain.APIKeyDTO]{Data: dtos, TotalCount: &total})
+}
+
+func (h *APIKeyHandler) CreateAPIKey(ctx *gin.Context) {
+	var req CreateAPIKeyRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	ownerId := util.PrincipalFrom(ctx).UserID
+	if req.OwnerID != "" {
+		id, err := uuid.Parse(req.OwnerID)
+		if err != nil {
+			_ = ctx.Error(domain.ErrIncorrectUuid)
+			return
+		}
+		ownerId = id.String()
+	}
+
+	key, plaintext, err := h.service.Create(ctx.Request.Context(), service.CreateAPIKeyParams{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		OwnerID:   ownerId,
+		ExpiresAt: req.ExpiresAt,
+	})
+
+	if err != nil {
+		h.logger.Info("error creating api key", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	dto := key.ToDto()
+	dto.Key = plaintext
+	ctx.JSON(http.StatusCreated, dto)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "key_uuid")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Revoke(ctx.Request.Context(), id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(204, gin.H{})
+}
+
+var APIKeyModule = fx.Module("apikey-handler", fx.Provide(NewAPIKeyHandler))
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type AuthHandler struct {
+	service *service.AuthService
+	logger  *zap.Logger
+}
+
+type LoginRequestPayload struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshRequestPayload struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type CreateUserRequestPayload struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role"`
+}
+
+func NewAuthHandler(authService *service.AuthService, logger *zap.Logger) *AuthHandler {
+	return &AuthHandler{
+		service: authService,
+		logger:  logger,
+	}
+}
+
+func (h *AuthHandler) Login(ctx *gin.Context) {
+	var req LoginRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	tokens, err := h.service.Login(ctx.Request.Context(), req.Email, req.Password)
+
+	if err != nil {
+		h.logger.Info("login failed", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, tokens)
+}
+
+func (h *AuthHandler) Refresh(ctx *gin.Context) {
+	var req RefreshRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	tokens, err := h.service.Refresh(ctx.Request.Context(), req.RefreshToken)
+
+	if err != nil {
+		h.logger.Info("refresh failed", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, tokens)
+}
+
+func (h *AuthHandler) Me(ctx *gin.Context) {
+	user, err := h.service.GetUser(ctx.Request.Context(), util.PrincipalFrom(ctx).UserID)
+
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, user.ToDto())
+}
+
+func (h *AuthHandler) CreateUser(ctx *gin.Context) {
+	var req CreateUserRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	role, ok := domain.ParseRole(req.Role)
+	if !ok {
+		_ = ctx.Error(&domain.ValidationError{Field: "role", Message: "must be user or admin"})
+		return
+	}
+
+	user, err := h.service.CreateUser(ctx.Request.Context(), req.Email, req.Password, role)
+
+	if err != nil {
+		h.logger.Info("create user failed", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, user.ToDto())
+}
+
+var AuthModule = fx.Module("auth-handler", fx.Provide(NewAuthHandler))
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// bindJSON decodes the request body into req and attaches a validation
+// problem to the context when that fails.
+func bindJSON(ctx *gin.Context, req any) bool {
+	err := ctx.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		_ = ctx.Error(domain.ErrInvalidJSON)
+		return false
+	}
+
+	problems := make(domain.ValidationErrors, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		message := "is invalid"
+		if fe.Tag() == "required" {
+			message = "is required"
+		}
+		problems = append(problems, domain.ValidationError{Field: fe.Field(), Message: message})
+	}
+	_ = ctx.Error(problems)
+	return false
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type BulkHandler struct {
+	service *service.BulkService
+	logger  *zap.Logger
+}
+
+type BulkRequestPayload struct {
+	Operation string            `json:"operation" binding:"required"`
+	IDs       []string          `json:"ids"`
+	Filter    map[string]string `json:"filter"`
+	Params    domain.BulkParams `json:"params"`
+}
+
+func NewBulkHandler(bulkService *service.BulkService, logger *zap.Logger) *BulkHandler {
+	return &BulkHandler{
+		service: bulkService,
+		logger:  logger,
+	}
+}
+
+func (h *BulkHandler) CreateBulkOperation(ctx *gin.Context) {
+	var req BulkRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	ids := make([]string, 0, len(req.IDs))
+	for _, raw := range req.IDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			_ = ctx.Error(domain.ErrIncorrectUuid)
+			return
+		}
+		ids = append(ids, id.String())
+	}
+
+	var filter url.Values
+	if req.Filter != nil {
+		filter = url.Values{}
+		for key, value := range req.Filter {
+			filter.Set(key, value)
+		}
+	}
+
+	op, err := h.service.Create(ctx.Request.Context(), util.PrincipalFrom(ctx), service.CreateBulkParams{
+		Operation: req.Operation,
+		Params:    req.Params,
+		IDs:       ids,
+		Filter:    filter,
+	})
+
+	if err != nil {
+		h.logger.Info("error creating bulk operation", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	dto := op.ToDto()
+	dto.Items = nil
+	ctx.Header("Location", ctx.FullPath()+"/"+op.ID)
+	ctx.JSON(http.StatusAccepted, dto)
+}
+
+func (h *BulkHandler) GetBulkOperation(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "bulk_uuid")
+	if !ok {
+		return
+	}
+
+	op, err := h.service.Get(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, op.ToDto())
+}
+
+var BulkModule = fx.Module("bulk-handler", fx.Provide(NewBulkHandler))
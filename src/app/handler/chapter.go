@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/util"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ChapterPayload struct {
+	Kind    string `json:"kind"`
+	Title   string `json:"title" binding:"required"`
+	StartMs *int   `json:"startMs" binding:"required"`
+	EndMs   *int   `json:"endMs"`
+}
+
+type PutChaptersRequestPayload struct {
+	Chapters []ChapterPayload `json:"chapters" binding:"required,dive"`
+}
+
+func (h *VideoHandler) GetChapters(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	video, err := h.service.GetVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, video) {
+		return
+	}
+
+	ctx.JSON(200, domain.ListPayload[domain.ChapterDTO]{Data: domain.ChapterDtos(video.Chapters)})
+}
+
+func (h *VideoHandler) PutChapters(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	var req PutChaptersRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	video, err := h.service.GetVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	version, ok := checkIfMatch(ctx, video)
+	if !ok {
+		return
+	}
+
+	chapters := make([]domain.Chapter, 0, len(req.Chapters))
+	for _, c := range req.Chapters {
+		chapters = append(chapters, domain.Chapter{
+			Kind:    domain.ChapterKind(c.Kind),
+			Title:   c.Title,
+			StartMs: *c.StartMs,
+			EndMs:   c.EndMs,
+		})
+	}
+
+	updated, err := h.service.SetChapters(ctx.Request.Context(), util.PrincipalFrom(ctx), video.ID, chapters, version)
+	if err != nil {
+		h.logger.Info("error updating chapters", zap.String("id", video.ID), zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", videoETag(updated))
+	ctx.JSON(200, domain.ListPayload[domain.ChapterDTO]{Data: domain.ChapterDtos(updated.Chapters)})
+}
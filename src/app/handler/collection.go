@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type CollectionHandler struct {
+	cfg     *config.Config
+	service *service.CollectionService
+	links   *MediaLinks
+	logger  *zap.Logger
+}
+
+type CreateCollectionRequestPayload struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+}
+
+type PatchCollectionRequestPayload struct {
+	Title        *string `json:"title"`
+	Description  *string `json:"description"`
+	CoverVideoID *string `json:"coverVideoId"`
+}
+
+type AddCollectionVideoRequestPayload struct {
+	VideoID  string `json:"videoId" binding:"required"`
+	Position *int   `json:"position"`
+}
+
+type ReorderCollectionRequestPayload struct {
+	VideoIDs []string `json:"videoIds" binding:"required"`
+}
+
+func NewCollectionHandler(config *config.Config, collectionService *service.CollectionService, links *MediaLinks, logger *zap.Logger) *CollectionHandler {
+	return &CollectionHandler{
+		cfg:     config,
+		service: collectionService,
+		links:   links,
+		logger:  logger,
+	}
+}
+
+func (h *CollectionHandler) GetCollections(ctx *gin.Context) {
+	var pgn = util.ParsePagination(ctx)
+
+	payload, err := h.service.GetAll(ctx.Request.Context(), util.PrincipalFrom(ctx), pgn)
+
+	if err != nil {
+		h.logger.Error("error listing collections", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	dtos := make([]domain.CollectionDTO, 0, len(payload.Data))
+	for _, collection := range payload.Data {
+		dtos = append(dtos, h.collectionDto(ctx, &collection))
+	}
+
+	ctx.JSON(200, domain.ListPayload[domain.CollectionDTO]{
+		Data:       dtos,
+		TotalCount: payload.TotalCount,
+	})
+}
+
+func (h *CollectionHandler) GetCollection(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "collection_uuid")
+	if !ok {
+		return
+	}
+
+	collection, err := h.service.Get(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, h.collectionDto(ctx, collection))
+}
+
+func (h *CollectionHandler) CreateCollection(ctx *gin.Context) {
+	var req CreateCollectionRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	collection, err := h.service.Create(ctx.Request.Context(), util.PrincipalFrom(ctx), req.Title, req.Description)
+
+	if err != nil {
+		h.logger.Info("error creating collection", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, h.collectionDto(ctx, collection))
+}
+
+func (h *CollectionHandler) UpdateCollection(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "collection_uuid")
+	if !ok {
+		return
+	}
+
+	var req PatchCollectionRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	if req.CoverVideoID != nil {
+		if coverId := strings.TrimSpace(*req.CoverVideoID); coverId != "" {
+			parsed, err := uuid.Parse(coverId)
+			if err != nil {
+				_ = ctx.Error(domain.ErrIncorrectUuid)
+				return
+			}
+			coverId = parsed.String()
+			req.CoverVideoID = &coverId
+		}
+	}
+
+	collection, err := h.service.Update(ctx.Request.Context(), util.PrincipalFrom(ctx), id, domain.CollectionUpdate{
+		Title:        req.Title,
+		Description:  req.Description,
+		CoverVideoID: req.CoverVideoID,
+	})
+
+	if err != nil {
+		h.logger.Info("error updating collection", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, h.collectionDto(ctx, collection))
+}
+
+func (h *CollectionHandler) DeleteCollection(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "collection_uuid")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(ctx.Request.Context(), util.PrincipalFrom(ctx), id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(204, gin.H{})
+}
+
+func (h *CollectionHandler) GetCollectionVideos(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "collection_uuid")
+	if !ok {
+		return
+	}
+
+	videos, err := h.service.GetVideos(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	dtos := make([]domain.VideoDTO, 0, len(videos))
+	for _, video := range videos {
+		dtos = append(dtos, h.links.VideoDto(ctx, &video))
+	}
+	total := int64(len(dtos))
+
+	ctx.JSON(200, domain.ListPayload[domain.VideoDTO]{
+		Data:       dtos,
+		TotalCount: &total,
+	})
+}
+
+func (h *CollectionHandler) AddVideo(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "collection_uuid")
+	if !ok {
+		return
+	}
+
+	var req AddCollectionVideoRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	videoId, err := uuid.Parse(req.VideoID)
+	if err != nil {
+		_ = ctx.Error(domain.ErrIncorrectUuid)
+		return
+	}
+
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
+
+	if err := h.service.AddVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id, videoId.String(), position); err != nil {
+		h.logger.Info("error adding video to collection", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(204, gin.H{})
+}
+
+func (h *CollectionHandler) RemoveVideo(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "collection_uuid")
+	if !ok {
+		return
+	}
+	videoId, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id, videoId); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(204, gin.H{})
+}
+
+func (h *CollectionHandler) ReorderVideos(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "collection_uuid")
+	if !ok {
+		return
+	}
+
+	var req ReorderCollectionRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+	for i, raw := range req.VideoIDs {
+		videoId, err := uuid.Parse(raw)
+		if err != nil {
+			_ = ctx.Error(domain.ErrIncorrectUuid)
+			return
+		}
+		req.VideoIDs[i] = videoId.String()
+	}
+
+	if err := h.service.Reorder(ctx.Request.Context(), util.PrincipalFrom(ctx), id, req.VideoIDs); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(204, gin.H{})
+}
+
+func (h *CollectionHandler) collectionDto(ctx *gin.Context, collection *domain.Collection) domain.CollectionDTO {
+	dto := collection.ToDto()
+	if cover := collection.CoverVideo; cover != nil && cover.ArchivedAt == nil && util.PrincipalFrom(ctx).CanAccess(cover) {
+		dto.CoverUrl = h.links.URL(ctx, cover, "preview.png")
+	} else {
+		dto.CoverVideoID = nil
+	}
+	return dto
+}
+
+func parseUuidParam(ctx *gin.Context, name string) (string, bool) {
+	id, err := uuid.Parse(ctx.Param(name))
+	if err != nil {
+		_ = ctx.Error(domain.ErrIncorrectUuid)
+		return "", false
+	}
+	return id.String(), true
+}
+
+var CollectionModule = fx.Module("collection-handler", fx.Provide(NewCollectionHandler))
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"awesomeProject/src/app/openapi"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+)
+
+type DocsHandler struct{}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+func (h *DocsHandler) GetSpec(ctx *gin.Context) {
+	ctx.Data(200, gin.MIMEJSON, openapi.Spec())
+}
+
+func (h *DocsHandler) GetDocs(ctx *gin.Context) {
+	ctx.HTML(200, "docs.html", gin.H{"SpecUrl": "/api/openapi.json"})
+}
+
+var DocsModule = fx.Module("docs-handler", fx.Provide(NewDocsHandler))
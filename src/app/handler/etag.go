@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// videoETag is a strong validator derived from the row version, which
+// changes with every write to the video.
+func videoETag(video *domain.Video) string {
+	return `"` + strconv.Itoa(video.Version) + `"`
+}
+
+// matchETag reports whether the If-Match or If-None-Match header lists etag.
+// If-Match uses strong comparison, so weak tags only match when weak is set.
+func matchETag(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces an If-Match precondition against video. It returns
+// the version the write must be conditional on, 0 when no header was sent.
+func checkIfMatch(ctx *gin.Context, video *domain.Video) (int, bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	if !matchETag(header, videoETag(video), false) {
+		_ = ctx.Error(domain.ErrVersionMismatch)
+		return 0, false
+	}
+	return video.Version, true
+}
+
+// requireIfMatch loads the video only when the request carries If-Match and
+// returns the version the write must be conditional on, 0 without a header.
+func requireIfMatch(ctx *gin.Context, videos *service.VideoService, id string) (int, bool) {
+	if ctx.GetHeader("If-Match") == "" {
+		return 0, true
+	}
+	video, err := videos.GetVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return 0, false
+	}
+	return checkIfMatch(ctx, video)
+}
+
+// notModified sets the ETag of video and reports whether the request's
+// If-None-Match already names it, in which case a 304 has been written.
+func notModified(ctx *gin.Context, video *domain.Video) bool {
+	etag := videoETag(video)
+	ctx.Header("ETag", etag)
+
+	if header := ctx.GetHeader("If-None-Match"); header != "" && matchETag(header, etag, true) {
+		ctx.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/util"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newETagContext(header, value string) (*gin.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest("GET", "/api/video/v1", nil)
+	if value != "" {
+		ctx.Request.Header.Set(header, value)
+	}
+	return ctx, recorder
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	video := &domain.Video{ID: "v1", Version: 3}
+
+	tests := []struct {
+		header  string
+		version int
+		ok      bool
+	}{
+		{"", 0, true},
+		{"*", 0, true},
+		{`"3"`, 3, true},
+		{`"2", "3"`, 3, true},
+		{`"2"`, 0, false},
+		{`W/"3"`, 0, false},
+		{"3", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			ctx, _ := newETagContext("If-Match", tt.header)
+
+			version, ok := checkIfMatch(ctx, video)
+			if version != tt.version || ok != tt.ok {
+				t.Errorf("got version %d, %t, want %d, %t", version, ok, tt.version, tt.ok)
+			}
+			if tt.ok {
+				if len(ctx.Errors) != 0 {
+					t.Errorf("errors = %v", ctx.Errors)
+				}
+				return
+			}
+			if status := util.ProblemFromError(ctx.Errors.Last().Err).Status; status != http.StatusPreconditionFailed {
+				t.Errorf("status = %d, want 412", status)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	video := &domain.Video{ID: "v1", Version: 3}
+
+	tests := []struct {
+		header      string
+		notModified bool
+	}{
+		{"", false},
+		{`"3"`, true},
+		{`W/"3"`, true},
+		{`"1", "3"`, true},
+		{"*", true},
+		{`"2"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			ctx, recorder := newETagContext("If-None-Match", tt.header)
+
+			if got := notModified(ctx, video); got != tt.notModified {
+				t.Fatalf("notModified = %t, want %t", got, tt.notModified)
+			}
+			if etag := recorder.Header().Get("ETag"); etag != `"3"` {
+				t.Errorf("ETag = %q", etag)
+			}
+			if tt.notModified {
+				ctx.Writer.WriteHeaderNow()
+				if recorder.Code != http.StatusNotModified {
+					t.Errorf("status = %d, want 304", recorder.Code)
+				}
+			}
+		})
+	}
+}
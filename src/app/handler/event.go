@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	eventStreamContentType = "text/event-stream"
+	// eventRetry is the reconnect delay suggested to EventSource clients.
+	eventRetry = 3 * time.Second
+	// eventReset tells a resumed client that the missed events were not
+	// replayed and it should reload what it shows.
+	eventReset = "reset"
+)
+
+type EventHandler struct {
+	service *service.EventService
+	config  *config.Config
+	logger  *zap.Logger
+}
+
+func NewEventHandler(eventService *service.EventService, cfg *config.Config, logger *zap.Logger) *EventHandler {
+	return &EventHandler{
+		service: eventService,
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// Stream sends video events as Server-Sent Events until the client goes
+// away. A resumed stream first replays what was missed since Last-Event-ID.
+func (h *EventHandler) Stream(ctx *gin.Context) {
+	query, err := domain.ParseEventStreamQuery(ctx.Request.URL.Query(), ctx.GetHeader(domain.LastEventIDHeader))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	filter, err := h.service.Filter(ctx.Request.Context(), util.PrincipalFrom(ctx), query.VideoIDs)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	// subscribe before replaying, so nothing falls between the two
+	sub := h.service.Subscribe(filter)
+	defer h.service.Unsubscribe(sub)
+
+	var replayed map[int64]struct{}
+	var backlog []domain.VideoEvent
+	var resetID int64
+	if query.After != nil {
+		if backlog, resetID, err = h.service.Replay(ctx.Request.Context(), filter, *query.After); err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+		replayed = make(map[int64]struct{}, len(backlog))
+		for _, e := range backlog {
+			replayed[e.ID] = struct{}{}
+		}
+	}
+
+	// the stream outlives the server's write timeout
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("cannot lift the write deadline of an event stream", zap.Error(err))
+	}
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", eventStreamContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(ctx.Writer, "retry: %d\n\n", eventRetry.Milliseconds()); err != nil {
+		return
+	}
+	if resetID > 0 {
+		// too much was missed to replay; the client reloads the state instead
+		if _, err := fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: {}\n\n", resetID, eventReset); err != nil {
+			return
+		}
+	}
+	for _, e := range backlog {
+		if !h.write(ctx, e) {
+			return
+		}
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.config.Events.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case e, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if _, dup := replayed[e.ID]; dup {
+				continue
+			}
+			if !h.write(ctx, e) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func (h *EventHandler) write(ctx *gin.Context, e domain.VideoEvent) bool {
+	data, err := json.Marshal(e.ToDto())
+	if err != nil {
+		h.logger.Error("failed to encode video event", zap.Int64("id", e.ID), zap.Error(err))
+		return true
+	}
+	_, err = fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err == nil
+}
+
+var EventModule = fx.Module("event-handler", fx.Provide(NewEventHandler))
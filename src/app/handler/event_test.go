@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"awesomeProject/src/app/bus"
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/app/testdb"
+	"awesomeProject/src/util"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	eventOwner = "6d1f3c2e-0a4b-4c7d-9e8f-1a2b3c4d5e6f"
+	eventOther = "0b9e8d7c-6f5a-4e3d-8c2b-1a0f9e8d7c6b"
+	eventVideo = "3c4d5e6f-7a8b-4c9d-8e0f-1a2b3c4d5e6f"
+)
+
+// flushRecorder ends the stream at its first flush, once the replay is
+// written.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+}
+
+func (r *flushRecorder) Flush() {
+	r.ResponseRecorder.Flush()
+	r.cancel()
+}
+
+func newEventHandler(t *testing.T) (*EventHandler, *gorm.DB) {
+	t.Helper()
+	db := testdb.Open(t)
+	log := zap.NewNop()
+	cfg := &config.Config{Events: config.EventsConfig{PollInterval: time.Hour, Heartbeat: time.Hour}}
+	events := service.NewEventService(cfg, repository.NewEventRepository(db, log), nil, bus.New(cfg, log), log)
+	return NewEventHandler(events, cfg, log), db
+}
+
+// storeEvents appends n events of the video owned by owner and returns
+// their IDs.
+func storeEvents(t *testing.T, db *gorm.DB, owner string, n int) []int64 {
+	t.Helper()
+	var ids []int64
+	err := db.Raw(`INSERT INTO video_events (video_id, owner_id, type, status)
+		SELECT ?, ?, ?, 'processing' FROM generate_series(1, ?) RETURNING id`,
+		eventVideo, owner, string(domain.VideoEventProgress), n).Scan(&ids).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// stream opens an event stream as the owner and returns what was sent
+// before the first flush.
+func stream(h *EventHandler, lastEventID string) (*gin.Context, string) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recorder := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), cancel: cancel}
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/api/events", nil).WithContext(reqCtx)
+	if lastEventID != "" {
+		ctx.Request.Header.Set(domain.LastEventIDHeader, lastEventID)
+	}
+	util.SetPrincipal(ctx, domain.Principal{UserID: eventOwner, Role: domain.RoleUser})
+
+	h.Stream(ctx)
+	return ctx, recorder.Body.String()
+}
+
+// sentEvents lists the events in an SSE body as "id event" pairs.
+func sentEvents(body string) []string {
+	var sent []string
+	for _, frame := range strings.Split(body, "\n\n") {
+		var id, event string
+		for _, line := range strings.Split(frame, "\n") {
+			if v, ok := strings.CutPrefix(line, "id: "); ok {
+				id = v
+			}
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				event = v
+			}
+		}
+		if id != "" {
+			sent = append(sent, id+" "+event)
+		}
+	}
+	return sent
+}
+
+func TestStreamReplaysFromLastEventID(t *testing.T) {
+	h, db := newEventHandler(t)
+	seen := storeEvents(t, db, eventOwner, 2)
+	storeEvents(t, db, eventOther, 1)
+	missed := storeEvents(t, db, eventOwner, 2)
+
+	_, body := stream(h, fmt.Sprint(seen[1]))
+	if !strings.HasPrefix(body, "retry: ") {
+		t.Errorf("stream starts with %q, want the retry delay", body)
+	}
+	want := []string{
+		fmt.Sprintf("%d %s", missed[0], domain.VideoEventProgress),
+		fmt.Sprintf("%d %s", missed[1], domain.VideoEventProgress),
+	}
+	if got := sentEvents(body); !slices.Equal(got, want) {
+		t.Errorf("replayed %v, want the owner's events after %d: %v", got, seen[1], want)
+	}
+
+	if _, body := stream(h, ""); len(sentEvents(body)) != 0 {
+		t.Errorf("a new stream replayed %v", sentEvents(body))
+	}
+
+	ctx, _ := stream(h, "last")
+	if len(ctx.Errors) == 0 || util.ProblemFromError(ctx.Errors.Last().Err).Status != http.StatusBadRequest {
+		t.Errorf("invalid Last-Event-ID: errors = %v, want 400", ctx.Errors)
+	}
+}
+
+func TestStreamResetsClientsTooFarBehind(t *testing.T) {
+	h, db := newEventHandler(t)
+	// far more than a stream replays
+	ids := storeEvents(t, db, eventOwner, 2000)
+
+	_, body := stream(h, "0")
+	want := []string{fmt.Sprintf("%d reset", ids[len(ids)-1])}
+	if got := sentEvents(body); !slices.Equal(got, want) {
+		t.Errorf("sent %v, want only %v", got, want)
+	}
+	if !strings.Contains(body, "event: reset\ndata: {}\n\n") {
+		t.Errorf("reset frame is malformed: %q", body)
+	}
+}
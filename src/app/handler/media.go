@@ -1,36 +1,251 @@
 package handler
 
 import (
+	"awesomeProject/src/app/auth"
 	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/hls"
 	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
+const playlistContentType = "application/vnd.apple.mpegurl"
+
+const subtitleGroup = "subs"
+
 type MediaHandler struct {
-	cfg     *config.Config
-	service *service.VideoService
-	logger  *zap.Logger
+	cfg       *config.Config
+	service   *service.VideoService
+	subtitles *service.SubtitleService
+	analytics *service.AnalyticsService
+	signer    *auth.PlaybackSigner
+	logger    *zap.Logger
 }
 
-func NewMediaHandler(config *config.Config, logger *zap.Logger) *MediaHandler {
+func NewMediaHandler(config *config.Config, videoService *service.VideoService, subtitleService *service.SubtitleService, analyticsService *service.AnalyticsService, signer *auth.PlaybackSigner, logger *zap.Logger) *MediaHandler {
 	return &MediaHandler{
-		cfg:    config,
-		logger: logger,
+		cfg:       config,
+		service:   videoService,
+		subtitles: subtitleService,
+		analytics: analyticsService,
+		signer:    signer,
+		logger:    logger,
 	}
 }
 
 func (h *MediaHandler) Register(router gin.IRouter) {
-	fs := gin.Dir(h.cfg.Conv.ConvDir, false)
+	mount := "/"
+	if u, err := url.Parse(h.cfg.Http.PublicMediaUrl); err == nil && u.Path != "" {
+		mount = u.Path
+	}
+	relative := path.Join(mount, "/*filepath")
+
+	router.GET(relative, h.ServeMedia)
+	router.HEAD(relative, h.ServeMedia)
+}
+
+func (h *MediaHandler) ServeMedia(ctx *gin.Context) {
+	ctx.Header("cache-control", "no-cache")
+
+	rel := strings.TrimPrefix(path.Clean("/"+ctx.Param("filepath")), "/")
+	token := ctx.Query("token")
+
+	claims, err := h.signer.Parse(token, ctx.ClientIP(), time.Now())
+	if err != nil {
+		util.AbortWithError(ctx, err)
+		return
+	}
+	if !strings.HasPrefix(rel, claims.Dir+"/") {
+		util.AbortWithError(ctx, domain.ErrForbidden)
+		return
+	}
+
+	video, err := h.service.GetPlayable(ctx.Request.Context(), claims.VideoID)
+	if err != nil {
+		util.AbortWithError(ctx, err)
+		return
+	}
 
-	router.Use(func(c *gin.Context) {
-		c.Header("cache-control", "no-cache")
-		c.Next()
+	name := strings.TrimPrefix(rel, claims.Dir+"/")
+	if ctx.Request.Method == http.MethodGet {
+		defer h.track(ctx, claims, name, token)
+	}
+
+	// chapters, subtitles and the master playlist referencing them are
+	// rendered from the database rather than stored with the output, so
+	// edits apply without reconverting
+	switch {
+	case name == hls.ChaptersFile:
+		ctx.Data(http.StatusOK, hls.WebVTTContentType, hls.WriteChapters(chapterCues(video, true)))
+		return
+	case name == hls.MasterPlaylist:
+		h.servePlaylist(ctx, masterPlaylist(video), token)
+		return
+	case strings.HasPrefix(name, hls.SubtitlesDir+"/"):
+		h.serveSubtitles(ctx, video, strings.TrimPrefix(name, hls.SubtitlesDir+"/"), token)
+		return
+	}
+
+	file := filepath.Join(h.cfg.Conv.ConvDir, filepath.FromSlash(rel))
+	if path.Ext(rel) != ".m3u8" {
+		ctx.File(file)
+		return
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			util.AbortWithError(ctx, domain.ErrVideoNotFound)
+			return
+		}
+		h.logger.Error("error reading playlist", zap.String("path", file), zap.Error(err))
+		util.AbortWithError(ctx, err)
+		return
+	}
+
+	h.servePlaylist(ctx, hls.InsertDateRanges(data, video.CreatedAt, chapterCues(video, false)), token)
+}
+
+// track reports a successful fetch to the playback analytics.
+func (h *MediaHandler) track(ctx *gin.Context, claims *auth.PlaybackClaims, name string, token string) {
+	if ctx.Writer.Status() >= http.StatusBadRequest {
+		return
+	}
+	h.analytics.Track(service.PlaybackHit{
+		VideoID:   claims.VideoID,
+		Dir:       claims.Dir,
+		File:      name,
+		Token:     token,
+		ClientIP:  ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		At:        time.Now().UTC(),
 	})
-	router.StaticFS(h.cfg.Http.PublicMediaUrl, fs)
+}
 
+func (h *MediaHandler) servePlaylist(ctx *gin.Context, data []byte, token string) {
+	ctx.Data(http.StatusOK, playlistContentType, hls.RewriteURIs(data, func(uri string) string {
+		return withToken(uri, token)
+	}))
+}
+
+// serveSubtitles serves a track below the subtitles directory: the whole
+// file as <lang>.vtt, its rendition as <lang>.m3u8 and segments as
+// <lang>/<n>.vtt.
+func (h *MediaHandler) serveSubtitles(ctx *gin.Context, video *domain.Video, name string, token string) {
+	language, segment, isSegment := strings.Cut(strings.TrimSuffix(name, path.Ext(name)), "/")
+	if !hasSubtitles(video, language) {
+		util.AbortWithError(ctx, domain.ErrSubtitleNotFound)
+		return
+	}
+
+	cues, err := h.subtitles.Cues(ctx.Request.Context(), video.ID, language)
+	if err != nil {
+		h.logger.Error("error loading subtitles", zap.String("id", video.ID), zap.String("language", language), zap.Error(err))
+		util.AbortWithError(ctx, err)
+		return
+	}
+	total := subtitleDuration(video, cues)
+
+	switch {
+	case path.Ext(name) == ".m3u8" && !isSegment:
+		h.servePlaylist(ctx, hls.WriteSubtitlePlaylist(total, func(i int) string {
+			return language + "/" + strconv.Itoa(i) + ".vtt"
+		}), token)
+	case path.Ext(name) == ".vtt" && !isSegment:
+		ctx.Data(http.StatusOK, hls.WebVTTContentType, hls.WriteSubtitles(cues))
+	case path.Ext(name) == ".vtt":
+		n, err := strconv.Atoi(segment)
+		if err != nil || n < 0 || n >= hls.SubtitleSegments(total) || strconv.Itoa(n) != segment {
+			util.AbortWithError(ctx, domain.ErrSubtitleNotFound)
+			return
+		}
+		ctx.Data(http.StatusOK, hls.WebVTTContentType, hls.WriteSubtitleSegment(cues, n))
+	default:
+		util.AbortWithError(ctx, domain.ErrSubtitleNotFound)
+	}
+}
+
+func hasSubtitles(video *domain.Video, language string) bool {
+	for _, s := range video.Subtitles {
+		if s.Language == language {
+			return true
+		}
+	}
+	return false
+}
+
+// subtitleDuration is the length a rendition has to cover: the video, or
+// the last cue while the duration is not known.
+func subtitleDuration(video *domain.Video, cues []hls.SubtitleCue) time.Duration {
+	if video.DurationS.Valid {
+		return time.Duration(video.DurationS.Int32) * time.Second
+	}
+	var total time.Duration
+	for _, c := range cues {
+		total = max(total, c.End)
+	}
+	return total
+}
+
+func masterPlaylist(video *domain.Video) []byte {
+	renditions := make([]hls.Rendition, 0, len(video.Subtitles))
+	for _, s := range video.Subtitles {
+		renditions = append(renditions, hls.Rendition{
+			Type:     "SUBTITLES",
+			GroupID:  subtitleGroup,
+			Name:     s.Label,
+			Language: s.Language,
+			URI:      path.Join(hls.SubtitlesDir, s.Language+".m3u8"),
+			Default:  s.IsDefault,
+		})
+	}
+	return hls.WriteMaster(hls.Variant{URI: "index.m3u8", Bandwidth: hls.StreamBandwidth}, subtitleGroup, renditions)
+}
+
+// chapterCues lists the chapters of video on the media timeline, leaving
+// out markers when only navigable chapters are wanted.
+func chapterCues(video *domain.Video, chaptersOnly bool) []hls.Cue {
+	spans := domain.ChapterSpans(video.Chapters, video.DurationS)
+
+	cues := make([]hls.Cue, 0, len(spans))
+	for _, s := range spans {
+		if chaptersOnly && s.Kind != domain.KindChapter {
+			continue
+		}
+		cues = append(cues, hls.Cue{
+			ID:    s.ID,
+			Class: string(s.Kind),
+			Title: s.Title,
+			Start: time.Duration(s.StartMs) * time.Millisecond,
+			End:   time.Duration(s.End) * time.Millisecond,
+		})
+	}
+	return cues
+}
+
+// withToken propagates the playback token to relative URIs so that nested
+// playlists and segments are authorized by the same grant.
+func withToken(uri, token string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.IsAbs() || strings.HasPrefix(u.Path, "/") {
+		return uri
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
 }
 
-var MediaModule = fx.Module("media-handler", fx.Provide(NewMediaHandler))
+var MediaModule = fx.Module("media-handler", fx.Provide(NewMediaHandler, NewMediaLinks))
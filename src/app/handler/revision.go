@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type RevisionHandler struct {
+	service *service.RevisionService
+	videos  *service.VideoService
+	links   *MediaLinks
+	logger  *zap.Logger
+}
+
+func NewRevisionHandler(revisionService *service.RevisionService, videoService *service.VideoService, links *MediaLinks, logger *zap.Logger) *RevisionHandler {
+	return &RevisionHandler{
+		service: revisionService,
+		videos:  videoService,
+		links:   links,
+		logger:  logger,
+	}
+}
+
+func (h *RevisionHandler) ReplaceSource(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("video")
+	if err != nil {
+		_ = ctx.Error(&domain.ValidationError{Field: "video", Message: "file is required"})
+		return
+	}
+
+	if _, ok := requireIfMatch(ctx, h.videos, id); !ok {
+		return
+	}
+
+	revision, err := h.service.ReplaceSource(ctx.Request.Context(), util.PrincipalFrom(ctx), id, fileHeader)
+	if err != nil {
+		h.logger.Info("error replacing video source", zap.String("id", id), zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Location", "/api/video/"+id+"/revisions")
+	ctx.JSON(http.StatusAccepted, revision.ToDto(0))
+}
+
+func (h *RevisionHandler) GetRevisions(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	video, revisions, err := h.service.GetRevisions(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	dtos := make([]domain.VideoRevisionDTO, 0, len(revisions))
+	for _, revision := range revisions {
+		dtos = append(dtos, revision.ToDto(video.Revision))
+	}
+	total := int64(len(dtos))
+
+	ctx.JSON(http.StatusOK, domain.ListPayload[domain.VideoRevisionDTO]{Data: dtos, TotalCount: &total})
+}
+
+func (h *RevisionHandler) Rollback(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(ctx.Param("revision"))
+	if err != nil || number < 1 {
+		_ = ctx.Error(&domain.ValidationError{Field: "revision", Message: "must be a positive integer"})
+		return
+	}
+
+	if _, ok := requireIfMatch(ctx, h.videos, id); !ok {
+		return
+	}
+
+	video, err := h.service.Rollback(ctx.Request.Context(), util.PrincipalFrom(ctx), id, number)
+	if err != nil {
+		h.logger.Info("error rolling back video", zap.String("id", id), zap.Int("revision", number), zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", videoETag(video))
+	ctx.JSON(http.StatusOK, h.links.VideoDto(ctx, video))
+}
+
+var RevisionModule = fx.Module("revision-handler", fx.Provide(NewRevisionHandler))
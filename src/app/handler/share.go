@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	SharePath       = "/v"
+	ShareAssetsPath = SharePath + "/assets"
+)
+
+type ShareHandler struct {
+	service *service.VideoService
+	links   *MediaLinks
+	logger  *zap.Logger
+}
+
+type sharePage struct {
+	Video     *domain.SharedVideoDTO
+	Error     string
+	Embed     bool
+	AssetsUrl string
+}
+
+func NewShareHandler(videoService *service.VideoService, links *MediaLinks, logger *zap.Logger) *ShareHandler {
+	return &ShareHandler{
+		service: videoService,
+		links:   links,
+		logger:  logger,
+	}
+}
+
+func (h *ShareHandler) GetPublicVideos(ctx *gin.Context) {
+	var pgn = util.ParsePagination(ctx)
+
+	query, err := domain.ParseVideoQuery(ctx.Request.URL.Query())
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	payloadVideos, err := h.service.GetPublicVideos(ctx.Request.Context(), pgn, query)
+	if err != nil {
+		h.logger.Error("error listing public videos", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	dtos := make([]domain.SharedVideoDTO, 0, len(payloadVideos.Data))
+	for _, video := range payloadVideos.Data {
+		dtos = append(dtos, h.sharedDto(ctx, &video))
+	}
+
+	ctx.JSON(200, domain.ListPayload[domain.SharedVideoDTO]{
+		Data:       dtos,
+		TotalCount: payloadVideos.TotalCount,
+		NextCursor: payloadVideos.NextCursor,
+	})
+}
+
+// GetSharedVideo serves JSON to API clients and the player page to browsers.
+func (h *ShareHandler) GetSharedVideo(ctx *gin.Context) {
+	wantsJSON := ctx.Query("format") == "json" ||
+		ctx.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+
+	video, err := h.service.GetShared(ctx.Request.Context(), util.PrincipalFrom(ctx), ctx.Param("slug"))
+	if err != nil {
+		if wantsJSON {
+			_ = ctx.Error(err)
+			return
+		}
+		problem := util.ProblemFromError(err)
+		ctx.HTML(problem.Status, "player.html", sharePage{
+			Error:     problem.Title,
+			AssetsUrl: ShareAssetsPath,
+		})
+		return
+	}
+
+	dto := h.sharedDto(ctx, video)
+	if wantsJSON {
+		ctx.JSON(200, dto)
+		return
+	}
+
+	if domain.Visibility(video.Visibility) == domain.VisibilityPrivate {
+		ctx.Header("cache-control", "private, no-store")
+	}
+	ctx.HTML(200, "player.html", sharePage{
+		Video:     &dto,
+		Embed:     ctx.Query("embed") == "1",
+		AssetsUrl: ShareAssetsPath,
+	})
+}
+
+func (h *ShareHandler) sharedDto(ctx *gin.Context, video *domain.Video) domain.SharedVideoDTO {
+	dto := video.ToSharedDto()
+	if video.Status == string(domain.StatusComplete) {
+		dto.ConvertedUrl = h.links.PlaylistURL(ctx, video)
+		dto.PosterUrl = h.links.URL(ctx, video, "preview.png")
+	}
+	dto.EmbedUrl = SharePath + "/" + video.Slug + "?embed=1"
+	return dto
+}
+
+var ShareModule = fx.Module("share-handler", fx.Provide(NewShareHandler))
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type StatsHandler struct {
+	service *service.VideoService
+	logger  *zap.Logger
+}
+
+func NewStatsHandler(videoService *service.VideoService, logger *zap.Logger) *StatsHandler {
+	return &StatsHandler{
+		service: videoService,
+		logger:  logger,
+	}
+}
+
+func (h *StatsHandler) GetStorageStats(ctx *gin.Context) {
+	top := domain.DefaultStatsTop
+	if v := ctx.Query("top"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			top = min(n, domain.MaxStatsTop)
+		}
+	}
+
+	stats, err := h.service.StorageStats(ctx.Request.Context(), top)
+
+	if err != nil {
+		h.logger.Error("error computing storage stats", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, stats)
+}
+
+var StatsModule = fx.Module("stats-handler", fx.Provide(NewStatsHandler))
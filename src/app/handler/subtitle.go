@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type SubtitleHandler struct {
+	service *service.SubtitleService
+	videos  *service.VideoService
+	links   *MediaLinks
+	logger  *zap.Logger
+}
+
+func NewSubtitleHandler(subtitleService *service.SubtitleService, videoService *service.VideoService, links *MediaLinks, logger *zap.Logger) *SubtitleHandler {
+	return &SubtitleHandler{
+		service: subtitleService,
+		videos:  videoService,
+		links:   links,
+		logger:  logger,
+	}
+}
+
+func (h *SubtitleHandler) GetSubtitles(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	video, err := h.videos.GetVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	if notModified(ctx, video) {
+		return
+	}
+
+	dto := h.links.VideoDto(ctx, video)
+	ctx.JSON(http.StatusOK, domain.ListPayload[domain.SubtitleDTO]{Data: dto.Subtitles})
+}
+
+func (h *SubtitleHandler) PutSubtitle(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		_ = ctx.Error(&domain.ValidationError{Field: "file", Message: "file is required"})
+		return
+	}
+
+	params := service.PutSubtitleParams{
+		Language: ctx.Param("language"),
+		Label:    ctx.PostForm("label"),
+		File:     fileHeader,
+	}
+	if value, ok := ctx.GetPostForm("default"); ok {
+		params.Default, err = strconv.ParseBool(value)
+		if err != nil {
+			_ = ctx.Error(&domain.ValidationError{Field: "default", Message: "must be true or false"})
+			return
+		}
+	}
+
+	video, err := h.videos.GetVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	params.Version, ok = checkIfMatch(ctx, video)
+	if !ok {
+		return
+	}
+
+	updated, subtitle, err := h.service.Put(ctx.Request.Context(), util.PrincipalFrom(ctx), video.ID, params)
+	if err != nil {
+		h.logger.Info("error storing subtitles", zap.String("id", video.ID), zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	dto := subtitle.ToDto()
+	dto.Url = h.links.SubtitleURL(ctx, updated, subtitle.Language)
+
+	ctx.Header("ETag", videoETag(updated))
+	ctx.JSON(http.StatusOK, dto)
+}
+
+func (h *SubtitleHandler) DeleteSubtitle(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "video_uuid")
+	if !ok {
+		return
+	}
+
+	video, err := h.videos.GetVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	version, ok := checkIfMatch(ctx, video)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.Delete(ctx.Request.Context(), util.PrincipalFrom(ctx), video.ID, ctx.Param("language"), version)
+	if err != nil {
+		h.logger.Info("error removing subtitles", zap.String("id", video.ID), zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", videoETag(updated))
+	ctx.JSON(204, gin.H{})
+}
+
+var SubtitleModule = fx.Module("subtitle-handler", fx.Provide(NewSubtitleHandler))
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"awesomeProject/src/app/auth"
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/hls"
+	"awesomeProject/src/util"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MediaLinks builds playback URLs carrying a signed token scoped to the
+// video's converted directory.
+type MediaLinks struct {
+	cfg    *config.Config
+	signer *auth.PlaybackSigner
+}
+
+func NewMediaLinks(cfg *config.Config, signer *auth.PlaybackSigner) *MediaLinks {
+	return &MediaLinks{
+		cfg:    cfg,
+		signer: signer,
+	}
+}
+
+func (l *MediaLinks) URL(ctx *gin.Context, video *domain.Video, file string) string {
+	if video.ArchivedAt != nil {
+		return ""
+	}
+	dir := mediaDir(video)
+	token := l.signer.Sign(video.ID, dir, ctx.ClientIP(), time.Now())
+	return util.JoinURL(l.cfg.Http.PublicMediaUrl, dir, file) + "?token=" + url.QueryEscape(token)
+}
+
+// PlaylistURL links the entry playlist of the video. Subtitle renditions
+// are only reachable through a master playlist, which is served instead of
+// the media playlist once the video has tracks.
+func (l *MediaLinks) PlaylistURL(ctx *gin.Context, video *domain.Video) string {
+	if len(video.Subtitles) > 0 {
+		return l.URL(ctx, video, hls.MasterPlaylist)
+	}
+	return l.URL(ctx, video, "index.m3u8")
+}
+
+// SubtitleURL links the complete WebVTT file of a track, for players that
+// take a plain text track instead of the HLS rendition.
+func (l *MediaLinks) SubtitleURL(ctx *gin.Context, video *domain.Video, language string) string {
+	return l.URL(ctx, video, path.Join(hls.SubtitlesDir, language+".vtt"))
+}
+
+func (l *MediaLinks) VideoDto(ctx *gin.Context, video *domain.Video) domain.VideoDTO {
+	dto := video.ToDto()
+	dto.ConvertedUrl = l.PlaylistURL(ctx, video)
+	if hasChapters(video) {
+		dto.ChaptersUrl = l.URL(ctx, video, hls.ChaptersFile)
+	}
+	for i := range dto.Subtitles {
+		dto.Subtitles[i].Url = l.SubtitleURL(ctx, video, dto.Subtitles[i].Language)
+	}
+	return dto
+}
+
+func hasChapters(video *domain.Video) bool {
+	for _, c := range video.Chapters {
+		if c.Kind == domain.KindChapter {
+			return true
+		}
+	}
+	return false
+}
+
+func mediaDir(video *domain.Video) string {
+	return path.Join(video.CreatedAt.Format("2006/01/02"), domain.RevisionDirName(video.Slug, video.Revision))
+}
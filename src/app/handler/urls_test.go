@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"awesomeProject/src/app/auth"
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestMediaLinksSignShareURLs(t *testing.T) {
+	cfg := &config.Config{
+		Http:     config.HttpConfig{PublicMediaUrl: "https://cdn.example.com/media"},
+		Playback: config.PlaybackConfig{Secret: "playback", TTL: time.Hour, BindIP: true},
+	}
+	signer, err := auth.NewPlaybackSigner(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	links := NewMediaLinks(cfg, signer)
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/v/holiday", nil)
+	ctx.Request.RemoteAddr = "198.51.100.7:51234"
+
+	created := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	archived := created.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		video   domain.Video
+		file    func(*domain.Video) string
+		wantURL string
+		wantDir string
+	}{
+		{
+			name:    "media playlist",
+			video:   domain.Video{ID: "v1", Slug: "holiday", Revision: 1, CreatedAt: created},
+			file:    func(v *domain.Video) string { return links.PlaylistURL(ctx, v) },
+			wantURL: "https://cdn.example.com/media/2024/01/02/holiday/index.m3u8",
+			wantDir: "2024/01/02/holiday",
+		},
+		{
+			name:    "master playlist once there are subtitles",
+			video:   domain.Video{ID: "v1", Slug: "holiday", Revision: 3, CreatedAt: created, Subtitles: []domain.Subtitle{{Language: "en"}}},
+			file:    func(v *domain.Video) string { return links.PlaylistURL(ctx, v) },
+			wantURL: "https://cdn.example.com/media/2024/01/02/holiday.r3/master.m3u8",
+			wantDir: "2024/01/02/holiday.r3",
+		},
+		{
+			name:    "subtitle file",
+			video:   domain.Video{ID: "v1", Slug: "holiday", Revision: 1, CreatedAt: created},
+			file:    func(v *domain.Video) string { return links.SubtitleURL(ctx, v, "pt-BR") },
+			wantURL: "https://cdn.example.com/media/2024/01/02/holiday/subtitles/pt-BR.vtt",
+			wantDir: "2024/01/02/holiday",
+		},
+		{
+			name:  "archived videos get no link",
+			video: domain.Video{ID: "v1", Slug: "holiday", Revision: 1, CreatedAt: created, ArchivedAt: &archived},
+			file:  func(v *domain.Video) string { return links.PlaylistURL(ctx, v) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := tt.file(&tt.video)
+			if tt.wantURL == "" {
+				if link != "" {
+					t.Errorf("link = %q, want none", link)
+				}
+				return
+			}
+
+			base, query, _ := strings.Cut(link, "?")
+			if base != tt.wantURL {
+				t.Errorf("url = %q, want %q", base, tt.wantURL)
+			}
+			values, err := url.ParseQuery(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := signer.Parse(values.Get("token"), "198.51.100.7", time.Now())
+			if err != nil {
+				t.Fatalf("token does not verify: %v", err)
+			}
+			if claims.VideoID != tt.video.ID || claims.Dir != tt.wantDir {
+				t.Errorf("claims = %+v, want video %s in %s", *claims, tt.video.ID, tt.wantDir)
+			}
+			if _, err := signer.Parse(values.Get("token"), "198.51.100.8", time.Now()); err == nil {
+				t.Error("token verifies for another client address")
+			}
+		})
+	}
+}
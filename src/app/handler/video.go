@@ -5,9 +5,7 @@ import (
 	"awesomeProject/src/app/domain"
 	"awesomeProject/src/app/service"
 	"awesomeProject/src/util"
-	"net/http"
 	"strings"
-	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -18,17 +16,22 @@ import (
 type VideoHandler struct {
 	cfg     *config.Config
 	service *service.VideoService
+	links   *MediaLinks
 	logger  *zap.Logger
 }
 
 type PatchVideoRequestPayload struct {
-	Filename string `json:"filename" binding:"required"`
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	Tags        *[]string `json:"tags"`
+	Visibility  *string   `json:"visibility"`
 }
 
-func NewVideoHandler(config *config.Config, videoService *service.VideoService, logger *zap.Logger) *VideoHandler {
+func NewVideoHandler(config *config.Config, videoService *service.VideoService, links *MediaLinks, logger *zap.Logger) *VideoHandler {
 	return &VideoHandler{
 		cfg:     config,
 		service: videoService,
+		links:   links,
 		logger:  logger,
 	}
 }
@@ -37,14 +40,32 @@ func (h *VideoHandler) AddVideo(ctx *gin.Context) {
 	fileHeader, err := ctx.FormFile("video")
 
 	if err != nil {
-		ctx.JSON(400, gin.H{"message": err})
+		_ = ctx.Error(&domain.ValidationError{Field: "video", Message: "file is required"})
 		return
 	}
 
-	path, err := h.service.Save(ctx.Request.Context(), fileHeader)
+	var meta domain.VideoMetadata
+	if title, ok := ctx.GetPostForm("title"); ok {
+		meta.Title = &title
+	}
+	if description, ok := ctx.GetPostForm("description"); ok {
+		meta.Description = &description
+	}
+	if visibility, ok := ctx.GetPostForm("visibility"); ok {
+		meta.Visibility = &visibility
+	}
+	if values, ok := ctx.GetPostFormArray("tags"); ok {
+		var tags []string
+		for _, v := range values {
+			tags = append(tags, strings.Split(v, ",")...)
+		}
+		meta.Tags = &tags
+	}
+
+	path, err := h.service.Save(ctx.Request.Context(), util.PrincipalFrom(ctx), fileHeader, meta)
 
 	if err != nil {
-		ctx.JSON(400, gin.H{"message": err})
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -56,46 +77,53 @@ func (h *VideoHandler) UpdateVideo(ctx *gin.Context) {
 	id, err := uuid.Parse(ctx.Param("video_uuid"))
 
 	if err != nil {
-		ctx.JSON(util.HttpResponseFromError(domain.ErrIncorrectUuid))
+		_ = ctx.Error(domain.ErrIncorrectUuid)
 		return
 	}
 
 	var req PatchVideoRequestPayload
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
-	name := strings.TrimSpace(req.Filename)
-	if name == "" {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "filename must not be empty"})
+	if req.Title == nil && req.Description == nil && req.Tags == nil && req.Visibility == nil {
+		_ = ctx.Error(&domain.ValidationError{Field: "body", Message: "nothing to update"})
 		return
 	}
-	if utf8.RuneCountInString(name) > 200 {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "filename is too long (max 200 chars)"})
+
+	video, err := h.service.GetVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id.String())
+
+	if err != nil {
+		_ = ctx.Error(err)
 		return
 	}
 
-	video, err := h.service.GetVideo(ctx.Request.Context(), id.String())
+	version, ok := checkIfMatch(ctx, video)
+	if !ok {
+		return
+	}
 
 	if video.ArchivedAt != nil || video.Status == string(domain.StatusArchived) {
-		ctx.JSON(400, gin.H{"message": "video is archived"})
+		_ = ctx.Error(domain.ErrVideoArchived)
 		return
 	}
 
-	updatedVideo, err := h.service.UpdateVideoTitle(ctx.Request.Context(), video.ID, req.Filename)
+	updatedVideo, err := h.service.UpdateMetadata(ctx.Request.Context(), video.ID, domain.VideoMetadata{
+		Title:       req.Title,
+		Description: req.Description,
+		Tags:        req.Tags,
+		Visibility:  req.Visibility,
+	}, version)
 
 	if err != nil {
-		h.logger.Error("update title failed", zap.String("id", video.ID), zap.Error(err))
-		ctx.JSON(util.HttpResponseFromError(err))
+		h.logger.Error("update metadata failed", zap.String("id", video.ID), zap.Error(err))
+		_ = ctx.Error(err)
 		return
 	}
 
-	dto := updatedVideo.ToDto()
-	datePath := video.CreatedAt.Format("2006/01/02")
-
-	dto.ConvertedUrl = util.JoinURL(h.cfg.Http.PublicMediaUrl, datePath, video.Slug, "index.m3u8")
+	dto := h.links.VideoDto(ctx, updatedVideo)
 
+	ctx.Header("ETag", videoETag(updatedVideo))
 	ctx.JSON(200, dto)
 
 }
@@ -103,26 +131,31 @@ func (h *VideoHandler) UpdateVideo(ctx *gin.Context) {
 func (h *VideoHandler) GetVideos(ctx *gin.Context) {
 	var pgn = util.ParsePagination(ctx)
 
-	status, ok := domain.ParseListFilter(ctx.Query("status"))
+	query, err := domain.ParseVideoQuery(ctx.Request.URL.Query())
 
-	if !ok {
-		ctx.JSON(400, gin.H{"message": "incorrect status"})
+	if err != nil {
+		_ = ctx.Error(err)
 		return
 	}
 
-	payloadVideos, err := h.service.GetAllVideos(ctx.Request.Context(), pgn, status)
+	if principal := util.PrincipalFrom(ctx); !principal.IsAdmin() {
+		query.OwnerID = &principal.UserID
+	}
+
+	payloadVideos, err := h.service.GetAllVideos(ctx.Request.Context(), pgn, query)
 
 	if err != nil {
-		ctx.JSON(400, gin.H{"message": err})
+		_ = ctx.Error(err)
 		return
 	}
 
 	dtos := make([]domain.VideoDTO, 0, len(payloadVideos.Data))
 
 	for _, video := range payloadVideos.Data {
-		videoDTO := video.ToDto()
-		datePath := video.CreatedAt.Format("2006/01/02")
-		videoDTO.ConvertedUrl = util.JoinURL(h.cfg.Http.PublicMediaUrl, datePath, video.Slug, "index.m3u8")
+		videoDTO := h.links.VideoDto(ctx, &video)
+		if query.Search != "" {
+			videoDTO.Rank, videoDTO.Snippet = &video.SearchRank, &video.SearchSnippet
+		}
 
 		dtos = append(dtos, videoDTO)
 	}
@@ -130,6 +163,7 @@ func (h *VideoHandler) GetVideos(ctx *gin.Context) {
 	payload := domain.ListPayload[domain.VideoDTO]{
 		Data:       dtos,
 		TotalCount: payloadVideos.TotalCount,
+		NextCursor: payloadVideos.NextCursor,
 	}
 
 	ctx.JSON(200, payload)
@@ -141,22 +175,23 @@ func (h *VideoHandler) GetVideo(ctx *gin.Context) {
 	id, err := uuid.Parse(ctx.Param("video_uuid"))
 
 	if err != nil {
-		ctx.JSON(util.HttpResponseFromError(domain.ErrIncorrectUuid))
+		_ = ctx.Error(domain.ErrIncorrectUuid)
 		return
 	}
 
-	video, err := h.service.GetVideo(ctx.Request.Context(), id.String())
+	video, err := h.service.GetVideo(ctx.Request.Context(), util.PrincipalFrom(ctx), id.String())
 
 	if err != nil {
 		h.logger.Info("error getting video by id")
-		ctx.JSON(util.HttpResponseFromError(err))
+		_ = ctx.Error(err)
 		return
 	}
 
-	dto := video.ToDto()
-	datePath := video.CreatedAt.Format("2006/01/02")
+	if notModified(ctx, video) {
+		return
+	}
 
-	dto.ConvertedUrl = util.JoinURL(h.cfg.Http.PublicMediaUrl, datePath, video.Slug, "index.m3u8")
+	dto := h.links.VideoDto(ctx, video)
 
 	ctx.JSON(200, dto)
 
@@ -166,13 +201,32 @@ func (h *VideoHandler) ArchiveVideo(ctx *gin.Context) {
 	id, err := uuid.Parse(ctx.Param("video_uuid"))
 
 	if err != nil {
-		ctx.JSON(util.HttpResponseFromError(domain.ErrIncorrectUuid))
+		_ = ctx.Error(domain.ErrIncorrectUuid)
+		return
+	}
+
+	version, ok := requireIfMatch(ctx, h.service, id.String())
+	if !ok {
+		return
+	}
+
+	if ctx.Query("purge") == "true" {
+		if !util.PrincipalFrom(ctx).IsAdmin() {
+			_ = ctx.Error(domain.ErrForbidden)
+			return
+		}
+		if err := h.service.Purge(ctx.Request.Context(), id.String(), util.PrincipalFrom(ctx).Actor(), version); err != nil {
+			h.logger.Info("error purging video", zap.Error(err))
+			_ = ctx.Error(err)
+			return
+		}
+		ctx.JSON(204, gin.H{})
 		return
 	}
 
-	if err := h.service.Archive(ctx.Request.Context(), id.String()); err != nil {
+	if err := h.service.Archive(ctx.Request.Context(), util.PrincipalFrom(ctx), id.String(), version); err != nil {
 		h.logger.Info("error archiving video", zap.Error(err))
-		ctx.JSON(util.HttpResponseFromError(err))
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -180,4 +234,23 @@ func (h *VideoHandler) ArchiveVideo(ctx *gin.Context) {
 
 }
 
+func (h *VideoHandler) VerifyVideo(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("video_uuid"))
+
+	if err != nil {
+		_ = ctx.Error(domain.ErrIncorrectUuid)
+		return
+	}
+
+	report, err := h.service.Verify(ctx.Request.Context(), util.PrincipalFrom(ctx), id.String())
+
+	if err != nil {
+		h.logger.Info("error verifying video", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, report)
+}
+
 var VideoModule = fx.Module("video-handler", fx.Provide(NewVideoHandler))
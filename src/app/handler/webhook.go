@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+	logger  *zap.Logger
+}
+
+type CreateWebhookRequestPayload struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events"`
+	Secret      string   `json:"secret"`
+	Description string   `json:"description"`
+}
+
+type PatchWebhookRequestPayload struct {
+	URL         *string   `json:"url"`
+	Events      *[]string `json:"events"`
+	Active      *bool     `json:"active"`
+	Description *string   `json:"description"`
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		service: webhookService,
+		logger:  logger,
+	}
+}
+
+func (h *WebhookHandler) GetWebhooks(ctx *gin.Context) {
+	webhooks, err := h.service.GetAll(ctx.Request.Context())
+	if err != nil {
+		h.logger.Error("error listing webhooks", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	dtos := make([]domain.WebhookDTO, 0, len(webhooks))
+	for _, w := range webhooks {
+		dtos = append(dtos, w.ToDto())
+	}
+	total := int64(len(dtos))
+
+	ctx.JSON(200, domain.ListPayload[domain.WebhookDTO]{Data: dtos, TotalCount: &total})
+}
+
+func (h *WebhookHandler) CreateWebhook(ctx *gin.Context) {
+	var req CreateWebhookRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	webhook, err := h.service.Create(ctx.Request.Context(), service.CreateWebhookParams{
+		URL:         req.URL,
+		Events:      req.Events,
+		Secret:      req.Secret,
+		Description: req.Description,
+		CreatedBy:   util.PrincipalFrom(ctx).UserID,
+	})
+	if err != nil {
+		h.logger.Info("error creating webhook", zap.Error(err))
+		_ = ctx.Error(err)
+		return
+	}
+
+	dto := webhook.ToDto()
+	dto.Secret = webhook.Secret
+	ctx.JSON(http.StatusCreated, dto)
+}
+
+func (h *WebhookHandler) GetWebhook(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "webhook_uuid")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.service.Get(ctx.Request.Context(), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, webhook.ToDto())
+}
+
+func (h *WebhookHandler) UpdateWebhook(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "webhook_uuid")
+	if !ok {
+		return
+	}
+
+	var req PatchWebhookRequestPayload
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	if req.URL == nil && req.Events == nil && req.Active == nil && req.Description == nil {
+		_ = ctx.Error(&domain.ValidationError{Field: "body", Message: "nothing to update"})
+		return
+	}
+
+	webhook, err := h.service.Update(ctx.Request.Context(), id, service.UpdateWebhookParams{
+		URL:         req.URL,
+		Events:      req.Events,
+		Active:      req.Active,
+		Description: req.Description,
+	})
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(200, webhook.ToDto())
+}
+
+func (h *WebhookHandler) DeleteWebhook(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "webhook_uuid")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(ctx.Request.Context(), id); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(204, gin.H{})
+}
+
+func (h *WebhookHandler) GetDeliveries(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "webhook_uuid")
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(ctx.Request.Context(), id)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	dtos := make([]domain.WebhookDeliveryDTO, 0, len(deliveries))
+	for _, d := range deliveries {
+		dtos = append(dtos, d.ToDto())
+	}
+
+	ctx.JSON(200, domain.ListPayload[domain.WebhookDeliveryDTO]{Data: dtos})
+}
+
+func (h *WebhookHandler) Redeliver(ctx *gin.Context) {
+	id, ok := parseUuidParam(ctx, "webhook_uuid")
+	if !ok {
+		return
+	}
+	deliveryId, ok := parseUuidParam(ctx, "delivery_uuid")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Redeliver(ctx.Request.Context(), id, deliveryId)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, delivery.ToDto())
+}
+
+var WebhookModule = fx.Module("webhook-handler", fx.Provide(NewWebhookHandler))
@@ -0,0 +1,114 @@
+package hls
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ChaptersFile      = "chapters.vtt"
+	WebVTTContentType = "text/vtt; charset=utf-8"
+)
+
+const dateRangeTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Cue is a titled range of the media timeline. Point markers have End equal
+// to Start.
+type Cue struct {
+	ID    string
+	Class string
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// WriteChapters renders cues as a WebVTT chapters track.
+func WriteChapters(cues []Cue) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("WEBVTT\n")
+
+	for _, c := range cues {
+		buf.WriteString("\n")
+		if c.ID != "" {
+			buf.WriteString(strings.ReplaceAll(singleLine(c.ID), "-->", "->") + "\n")
+		}
+		fmt.Fprintf(&buf, "%s --> %s\n", vttTimestamp(c.Start), vttTimestamp(c.End))
+		buf.WriteString(vttEscaper.Replace(singleLine(c.Title)) + "\n")
+	}
+	return buf.Bytes()
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func vttTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// InsertDateRanges adds an EXT-X-DATERANGE tag per cue to a media playlist.
+// Date ranges need a wall clock, so the first segment is pinned to anchor
+// unless the playlist already carries EXT-X-PROGRAM-DATE-TIME. Master
+// playlists are returned unchanged.
+func InsertDateRanges(data []byte, anchor time.Time, cues []Cue) []byte {
+	if len(cues) == 0 {
+		return data
+	}
+
+	lines := strings.Split(string(data), "\n")
+	first := -1
+	pinned := false
+	for i, line := range lines {
+		tag, value, _ := strings.Cut(strings.TrimSpace(line), ":")
+		switch tag {
+		case "#EXT-X-STREAM-INF":
+			return data
+		case "#EXT-X-PROGRAM-DATE-TIME":
+			if t, err := time.Parse(time.RFC3339Nano, value); err == nil && first < 0 {
+				anchor, pinned = t, true
+			}
+		case "#EXTINF":
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		return data
+	}
+
+	tags := make([]string, 0, len(cues)+1)
+	for _, c := range cues {
+		attrs := []string{
+			`ID="` + quotedAttr(c.ID) + `"`,
+			`CLASS="` + quotedAttr(c.Class) + `"`,
+			`START-DATE="` + anchor.Add(c.Start).UTC().Format(dateRangeTimeFormat) + `"`,
+		}
+		if c.End > c.Start {
+			attrs = append(attrs, "DURATION="+strconv.FormatFloat((c.End-c.Start).Seconds(), 'f', 3, 64))
+		}
+		attrs = append(attrs, `X-TITLE="`+quotedAttr(c.Title)+`"`)
+		tags = append(tags, "#EXT-X-DATERANGE:"+strings.Join(attrs, ","))
+	}
+	if !pinned {
+		tags = append(tags, "#EXT-X-PROGRAM-DATE-TIME:"+anchor.UTC().Format(dateRangeTimeFormat))
+	}
+
+	out := make([]string, 0, len(lines)+len(tags))
+	out = append(out, lines[:first]...)
+	out = append(out, tags...)
+	out = append(out, lines[first:]...)
+	return []byte(strings.Join(out, "\n"))
+}
+
+// quotedAttr makes s safe for a quoted-string attribute, which may not
+// contain double quotes or line breaks.
+func quotedAttr(s string) string {
+	return strings.ReplaceAll(singleLine(s), `"`, "'")
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
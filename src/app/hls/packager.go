@@ -1,16 +1,26 @@
 package hls
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"go.uber.org/fx"
 )
 
+// StreamBandwidth is the peak bitrate of the packaged stream: the video
+// -maxrate plus the audio bitrate below.
+const StreamBandwidth = 5_000_000 + 128_000
+
+// ProgressFunc receives how much of the source has been packaged so far.
+type ProgressFunc func(done time.Duration)
+
 type Packager interface {
-	PackageHLS(ctx context.Context, inPath string, outDir string) error
+	PackageHLS(ctx context.Context, inPath string, outDir string, progress ProgressFunc) error
 }
 
 type FFmpegPackager struct {
@@ -20,7 +30,7 @@ func NewFFmpegPackager() Packager {
 	return &FFmpegPackager{}
 }
 
-func (*FFmpegPackager) PackageHLS(ctx context.Context, inPath string, outDir string) error {
+func (*FFmpegPackager) PackageHLS(ctx context.Context, inPath string, outDir string, progress ProgressFunc) error {
 	if err := os.MkdirAll(outDir, 0o755); err != nil {
 		return err
 	}
@@ -30,6 +40,8 @@ func (*FFmpegPackager) PackageHLS(ctx context.Context, inPath string, outDir str
 
 	args := []string{
 		"-y",
+		"-progress", "pipe:1",
+		"-nostats",
 		"-hwaccel", "cuda",
 		"-hwaccel_output_format", "cuda",
 		"-i", inPath,
@@ -55,7 +67,7 @@ func (*FFmpegPackager) PackageHLS(ctx context.Context, inPath string, outDir str
 		filepath.Join(outDir, "index.m3u8"),
 	}
 
-	if err := runFFmpeg(ctx, args...); err != nil {
+	if err := runFFmpegWithProgress(ctx, progress, args...); err != nil {
 		return err
 	}
 
@@ -81,4 +93,39 @@ func runFFmpeg(ctx context.Context, args ...string) error {
 	return cmd.Run()
 }
 
+// runFFmpegWithProgress runs ffmpeg with -progress pipe:1 and reports the
+// out_time_us values it prints.
+func runFFmpegWithProgress(ctx context.Context, progress ProgressFunc, args ...string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stdout, cmd.Stderr = &progressWriter{report: progress}, os.Stderr
+	return cmd.Run()
+}
+
+// progressWriter parses the key=value lines of ffmpeg -progress output.
+type progressWriter struct {
+	report  ProgressFunc
+	pending []byte
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		line, rest, ok := bytes.Cut(w.pending, []byte("\n"))
+		if !ok {
+			break
+		}
+		w.pending = rest
+		value, found := bytes.CutPrefix(bytes.TrimSpace(line), []byte("out_time_us="))
+		if !found || w.report == nil {
+			continue
+		}
+		if us, err := strconv.ParseInt(string(value), 10, 64); err == nil && us >= 0 {
+			w.report(time.Duration(us) * time.Microsecond)
+		}
+	}
+	// copy the partial line so the buffer does not grow with the output
+	w.pending = append(w.pending[:0:0], w.pending...)
+	return len(p), nil
+}
+
 var FFmpegPackagerModule = fx.Module("ffmpeg", fx.Provide(NewFFmpegPackager))
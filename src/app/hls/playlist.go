@@ -0,0 +1,178 @@
+package hls
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPlaylist = errors.New("invalid m3u8 playlist")
+
+type Segment struct {
+	URI      string
+	Duration float64
+}
+
+type Variant struct {
+	URI        string
+	Bandwidth  int
+	Resolution string
+}
+
+type Rendition struct {
+	Type     string
+	GroupID  string
+	Name     string
+	Language string
+	URI      string
+	Default  bool
+}
+
+type Playlist struct {
+	Master         bool
+	TargetDuration int
+	EndList        bool
+	Segments       []Segment
+	Variants       []Variant
+	Renditions     []Rendition
+}
+
+func (p *Playlist) TotalDuration() float64 {
+	var total float64
+	for _, s := range p.Segments {
+		total += s.Duration
+	}
+	return total
+}
+
+func ParsePlaylistFile(path string) (*Playlist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParsePlaylist(f)
+}
+
+func ParsePlaylist(r io.Reader) (*Playlist, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	p := &Playlist{}
+	header := false
+	lineNo := 0
+
+	var pendingDuration *float64
+	var pendingVariant *Variant
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if !header {
+			if line != "#EXTM3U" {
+				return nil, fmt.Errorf("%w: missing #EXTM3U header", ErrInvalidPlaylist)
+			}
+			header = true
+			continue
+		}
+
+		if !strings.HasPrefix(line, "#") {
+			switch {
+			case pendingVariant != nil:
+				pendingVariant.URI = line
+				p.Variants = append(p.Variants, *pendingVariant)
+				pendingVariant = nil
+			case pendingDuration != nil:
+				p.Segments = append(p.Segments, Segment{URI: line, Duration: *pendingDuration})
+				pendingDuration = nil
+			default:
+				return nil, fmt.Errorf("%w: line %d: uri without #EXTINF", ErrInvalidPlaylist, lineNo)
+			}
+			continue
+		}
+
+		tag, value, _ := strings.Cut(line, ":")
+		switch tag {
+		case "#EXT-X-TARGETDURATION":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%w: line %d: bad target duration %q", ErrInvalidPlaylist, lineNo, value)
+			}
+			p.TargetDuration = n
+		case "#EXTINF":
+			durStr, _, _ := strings.Cut(value, ",")
+			d, err := strconv.ParseFloat(durStr, 64)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("%w: line %d: bad segment duration %q", ErrInvalidPlaylist, lineNo, durStr)
+			}
+			pendingDuration = &d
+		case "#EXT-X-ENDLIST":
+			p.EndList = true
+		case "#EXT-X-STREAM-INF":
+			p.Master = true
+			attrs := ParseAttributes(value)
+			bw, _ := strconv.Atoi(attrs["BANDWIDTH"])
+			pendingVariant = &Variant{Bandwidth: bw, Resolution: attrs["RESOLUTION"]}
+		case "#EXT-X-MEDIA":
+			p.Master = true
+			attrs := ParseAttributes(value)
+			p.Renditions = append(p.Renditions, Rendition{
+				Type:     attrs["TYPE"],
+				GroupID:  attrs["GROUP-ID"],
+				Name:     attrs["NAME"],
+				Language: attrs["LANGUAGE"],
+				URI:      attrs["URI"],
+				Default:  attrs["DEFAULT"] == "YES",
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, fmt.Errorf("%w: empty playlist", ErrInvalidPlaylist)
+	}
+	if pendingDuration != nil || pendingVariant != nil {
+		return nil, fmt.Errorf("%w: playlist ends with a dangling tag", ErrInvalidPlaylist)
+	}
+
+	return p, nil
+}
+
+func ParseAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+
+	for len(s) > 0 {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.TrimSpace(key)
+
+		var val string
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				val, rest = rest[1:], ""
+			} else {
+				val, rest = rest[1:end+1], rest[end+2:]
+			}
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			val, rest, _ = strings.Cut(rest, ",")
+		}
+
+		attrs[key] = val
+		s = rest
+	}
+	return attrs
+}
@@ -0,0 +1,29 @@
+package hls
+
+import (
+	"regexp"
+	"strings"
+)
+
+var uriAttribute = regexp.MustCompile(`URI="([^"]*)"`)
+
+// RewriteURIs applies fn to every URI in a playlist: plain URI lines as well
+// as URI attributes of tags such as EXT-X-MEDIA and EXT-X-MAP.
+func RewriteURIs(data []byte, fn func(uri string) string) []byte {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\r")
+		switch {
+		case trimmed == "":
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			lines[i] = uriAttribute.ReplaceAllStringFunc(line, func(attr string) string {
+				uri := uriAttribute.FindStringSubmatch(attr)[1]
+				return `URI="` + fn(uri) + `"`
+			})
+		default:
+			lines[i] = fn(trimmed) + line[len(trimmed):]
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,269 @@
+package hls
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSubtitles = errors.New("invalid subtitle file")
+
+const (
+	MasterPlaylist       = "master.m3u8"
+	SubtitlesDir         = "subtitles"
+	SubtitleSegmentLimit = 6 * time.Second
+
+	// mpegtsOffset is where ffmpeg starts the timestamps of MPEG-TS output
+	// (1.4s at 90kHz); WebVTT segments are mapped onto it to stay in sync.
+	mpegtsOffset = 126000
+)
+
+// SubtitleCue is one timed caption. Settings holds WebVTT cue settings such
+// as position or alignment, Text the payload with its markup.
+type SubtitleCue struct {
+	ID       string
+	Start    time.Duration
+	End      time.Duration
+	Settings string
+	Text     string
+}
+
+var (
+	srtTagPattern    = regexp.MustCompile(`(?i)</?font[^>]*>|\{\\[^}]*\}`)
+	srtEntityPattern = regexp.MustCompile(`^&(#\d+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+	blankLine        = regexp.MustCompile(`\n[ \t]*\n`)
+)
+
+// ParseSubtitles reads a WebVTT or SubRip file; the format is detected from
+// the WEBVTT signature.
+func ParseSubtitles(data []byte) ([]SubtitleCue, error) {
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	webvtt := IsWebVTT(data)
+	blocks := splitBlocks(text)
+	if webvtt {
+		// the first block is the WEBVTT header and its metadata
+		blocks = blocks[1:]
+	}
+
+	var cues []SubtitleCue
+	for _, block := range blocks {
+		lines := strings.Split(block, "\n")
+		if webvtt && isVTTMetaBlock(lines[0]) {
+			continue
+		}
+
+		var cue SubtitleCue
+		if !strings.Contains(lines[0], "-->") {
+			cue.ID = strings.TrimSpace(lines[0])
+			lines = lines[1:]
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("%w: cue %q has no timing", ErrInvalidSubtitles, cue.ID)
+		}
+
+		start, rest, ok := strings.Cut(lines[0], "-->")
+		if !ok {
+			return nil, fmt.Errorf("%w: bad timing line %q", ErrInvalidSubtitles, lines[0])
+		}
+		end, settings, _ := strings.Cut(strings.TrimSpace(rest), " ")
+
+		var err error
+		if cue.Start, err = parseTimestamp(start); err != nil {
+			return nil, err
+		}
+		if cue.End, err = parseTimestamp(end); err != nil {
+			return nil, err
+		}
+		if cue.End <= cue.Start {
+			return nil, fmt.Errorf("%w: cue at %s ends before it starts", ErrInvalidSubtitles, vttTimestamp(cue.Start))
+		}
+
+		payload := strings.Join(lines[1:], "\n")
+		if webvtt {
+			cue.Settings = strings.TrimSpace(settings)
+		} else {
+			// SubRip coordinates have no WebVTT equivalent and are dropped
+			payload = srtTagPattern.ReplaceAllString(payload, "")
+			payload = escapeAmpersands(payload)
+		}
+		cue.Text = strings.ReplaceAll(payload, "-->", "--&gt;")
+		cues = append(cues, cue)
+	}
+
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("%w: no cues found", ErrInvalidSubtitles)
+	}
+	return cues, nil
+}
+
+func IsWebVTT(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+	if !bytes.HasPrefix(data, []byte("WEBVTT")) {
+		return false
+	}
+	rest := data[len("WEBVTT"):]
+	return len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' || rest[0] == '\r'
+}
+
+func splitBlocks(text string) []string {
+	var blocks []string
+	for _, block := range blankLine.Split(text, -1) {
+		if block = strings.Trim(block, "\n"); strings.TrimSpace(block) != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	if len(blocks) == 0 {
+		return []string{""}
+	}
+	return blocks
+}
+
+func isVTTMetaBlock(first string) bool {
+	for _, keyword := range []string{"NOTE", "STYLE", "REGION"} {
+		if first == keyword || strings.HasPrefix(first, keyword+" ") || strings.HasPrefix(first, keyword+"\t") {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeAmpersands(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '&' && !srtEntityPattern.MatchString(s[i:]) {
+			b.WriteString("&amp;")
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parseTimestamp accepts WebVTT (mm:ss.ttt, hh:mm:ss.ttt) and SubRip
+// (hh:mm:ss,ttt) timestamps.
+func parseTimestamp(s string) (time.Duration, error) {
+	s = strings.TrimSpace(strings.Replace(s, ",", ".", 1))
+	clock, frac, ok := strings.Cut(s, ".")
+	if !ok || len(frac) != 3 {
+		return 0, fmt.Errorf("%w: bad timestamp %q", ErrInvalidSubtitles, s)
+	}
+	parts := strings.Split(clock, ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("%w: bad timestamp %q", ErrInvalidSubtitles, s)
+	}
+
+	var fields [4]int
+	for i, p := range append(parts, frac) {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (i == 1 || i == 2) && n > 59 {
+			return 0, fmt.Errorf("%w: bad timestamp %q", ErrInvalidSubtitles, s)
+		}
+		fields[i] = n
+	}
+	return time.Duration(fields[0])*time.Hour + time.Duration(fields[1])*time.Minute +
+		time.Duration(fields[2])*time.Second + time.Duration(fields[3])*time.Millisecond, nil
+}
+
+// WriteSubtitles renders cues as a WebVTT file.
+func WriteSubtitles(cues []SubtitleCue) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("WEBVTT\n")
+	writeCues(&buf, cues)
+	return buf.Bytes()
+}
+
+func writeCues(buf *bytes.Buffer, cues []SubtitleCue) {
+	for _, c := range cues {
+		buf.WriteString("\n")
+		if c.ID != "" {
+			buf.WriteString(strings.ReplaceAll(singleLine(c.ID), "-->", "->") + "\n")
+		}
+		fmt.Fprintf(buf, "%s --> %s", vttTimestamp(c.Start), vttTimestamp(c.End))
+		if c.Settings != "" {
+			buf.WriteString(" " + c.Settings)
+		}
+		buf.WriteString("\n" + c.Text + "\n")
+	}
+}
+
+// SubtitleSegments is the number of segments a subtitle rendition of a
+// video lasting total is split into.
+func SubtitleSegments(total time.Duration) int {
+	n := int((total + SubtitleSegmentLimit - 1) / SubtitleSegmentLimit)
+	return max(n, 1)
+}
+
+// WriteSubtitlePlaylist renders the media playlist of a subtitle rendition
+// whose segments are named by uri.
+func WriteSubtitlePlaylist(total time.Duration, uri func(i int) string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&buf, "#EXT-X-TARGETDURATION:%d\n", int(SubtitleSegmentLimit.Seconds()))
+	buf.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n")
+
+	n := SubtitleSegments(total)
+	for i := 0; i < n; i++ {
+		length := min(SubtitleSegmentLimit, total-time.Duration(i)*SubtitleSegmentLimit)
+		if length <= 0 {
+			length = SubtitleSegmentLimit
+		}
+		fmt.Fprintf(&buf, "#EXTINF:%.3f,\n%s\n", length.Seconds(), uri(i))
+	}
+	buf.WriteString("#EXT-X-ENDLIST\n")
+	return buf.Bytes()
+}
+
+// WriteSubtitleSegment renders segment i: every cue that overlaps it, with
+// the timestamp map that aligns cue times with the video segments.
+func WriteSubtitleSegment(cues []SubtitleCue, i int) []byte {
+	from := time.Duration(i) * SubtitleSegmentLimit
+	to := from + SubtitleSegmentLimit
+
+	var overlapping []SubtitleCue
+	for _, c := range cues {
+		if c.Start < to && c.End > from {
+			overlapping = append(overlapping, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "WEBVTT\nX-TIMESTAMP-MAP=MPEGTS:%d,LOCAL:00:00:00.000\n", mpegtsOffset)
+	writeCues(&buf, overlapping)
+	return buf.Bytes()
+}
+
+// WriteMaster renders a master playlist with a single variant and its
+// alternative renditions.
+func WriteMaster(variant Variant, group string, renditions []Rendition) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+
+	for _, r := range renditions {
+		flag := "NO"
+		if r.Default {
+			flag = "YES"
+		}
+		fmt.Fprintf(&buf, "#EXT-X-MEDIA:TYPE=%s,GROUP-ID=\"%s\",NAME=\"%s\",LANGUAGE=\"%s\",DEFAULT=%s,AUTOSELECT=YES,URI=\"%s\"\n",
+			r.Type, quotedAttr(r.GroupID), quotedAttr(r.Name), quotedAttr(r.Language), flag, quotedAttr(r.URI))
+	}
+
+	fmt.Fprintf(&buf, "#EXT-X-STREAM-INF:BANDWIDTH=%d", variant.Bandwidth)
+	if variant.Resolution != "" {
+		fmt.Fprintf(&buf, ",RESOLUTION=%s", variant.Resolution)
+	}
+	if len(renditions) > 0 {
+		fmt.Fprintf(&buf, ",SUBTITLES=\"%s\"", quotedAttr(group))
+	}
+	buf.WriteString("\n" + variant.URI + "\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,94 @@
+package hls
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSubtitlesConvertsSRT(t *testing.T) {
+	tests := []struct {
+		name string
+		srt  string
+		want string
+	}{
+		{
+			name: "basic cues",
+			srt:  "1\n00:00:01,000 --> 00:00:02,500\nHello\n\n2\n00:00:03,000 --> 00:00:04,000\nTwo\nlines\n",
+			want: "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.500\nHello\n\n2\n00:00:03.000 --> 00:00:04.000\nTwo\nlines\n",
+		},
+		{
+			name: "byte order mark and CRLF",
+			srt:  "\uFEFF1\r\n00:00:01,000 --> 00:00:02,000\r\nHi\r\n\r\n",
+			want: "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\nHi\n",
+		},
+		{
+			name: "coordinates and font tags are dropped",
+			srt:  "1\n00:00:01,000 --> 00:00:02,000 X1:10 X2:20 Y1:5 Y2:6\n<font color=\"red\">{\\an8}Red</font> <i>it</i>\n",
+			want: "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\nRed <i>it</i>\n",
+		},
+		{
+			name: "bare ampersands are escaped, entities kept",
+			srt:  "1\n00:00:01,000 --> 00:00:02,000\nTom & Jerry &amp; &#38;\n",
+			want: "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\nTom &amp; Jerry &amp; &#38;\n",
+		},
+		{
+			name: "arrows in the text cannot end the cue",
+			srt:  "1\n00:00:01,000 --> 00:00:02,000\na --> b\n",
+			want: "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\na --&gt; b\n",
+		},
+		{
+			name: "hours past 99 and no cue numbers",
+			srt:  "100:00:00,000 --> 100:00:01,000\nLate\n",
+			want: "WEBVTT\n\n100:00:00.000 --> 100:00:01.000\nLate\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues, err := ParseSubtitles([]byte(tt.srt))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if got := string(WriteSubtitles(cues)); got != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSubtitlesKeepsWebVTT(t *testing.T) {
+	vtt := "WEBVTT - title\n\nNOTE a comment\n\nSTYLE\n::cue { color: red }\n\nintro\n01:02.500 --> 01:04.000 align:start line:0\n<b>Bold</b> & co\n"
+	cues, err := ParseSubtitles([]byte(vtt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 1 {
+		t.Fatalf("got %d cues, want 1", len(cues))
+	}
+	want := SubtitleCue{ID: "intro", Start: time.Minute + 2500*time.Millisecond, End: time.Minute + 4*time.Second, Settings: "align:start line:0", Text: "<b>Bold</b> & co"}
+	if cues[0] != want {
+		t.Errorf("got %+v, want %+v", cues[0], want)
+	}
+}
+
+func TestParseSubtitlesRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"header only", "WEBVTT\n"},
+		{"no timing", "1\nHello\n"},
+		{"missing milliseconds", "1\n00:00:01 --> 00:00:02\nHi\n"},
+		{"minutes out of range", "1\n00:61:00,000 --> 00:62:00,000\nHi\n"},
+		{"ends before it starts", "1\n00:00:02,000 --> 00:00:01,000\nHi\n"},
+		{"not a number", "1\naa:00:01,000 --> 00:00:02,000\nHi\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSubtitles([]byte(tt.data)); !errors.Is(err, ErrInvalidSubtitles) {
+				t.Errorf("error = %v, want ErrInvalidSubtitles", err)
+			}
+		})
+	}
+}
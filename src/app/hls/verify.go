@@ -0,0 +1,141 @@
+package hls
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrInvalidOutput = errors.New("hls output is inconsistent")
+
+const (
+	minDurationTolerance = 2 * time.Second
+	durationToleranceRel = 0.01
+)
+
+type VerifyReport struct {
+	Valid             bool     `json:"valid"`
+	Playlists         []string `json:"playlists"`
+	Segments          int      `json:"segments"`
+	TotalDurationS    float64  `json:"totalDurationS"`
+	ExpectedDurationS float64  `json:"expectedDurationS"`
+	Problems          []string `json:"problems"`
+
+	measured bool
+}
+
+func (r *VerifyReport) addProblem(format string, args ...any) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// Verify checks the playlist at dir/name and everything it references.
+// expected is the probed source duration; zero skips the duration check.
+func Verify(dir string, name string, expected time.Duration) (*VerifyReport, error) {
+	report := &VerifyReport{
+		Playlists:         []string{},
+		Problems:          []string{},
+		ExpectedDurationS: expected.Seconds(),
+	}
+
+	verifyPlaylist(report, dir, name, expected, true)
+
+	report.Valid = len(report.Problems) == 0
+	if !report.Valid {
+		return report, fmt.Errorf("%w: %s", ErrInvalidOutput, strings.Join(report.Problems, "; "))
+	}
+	return report, nil
+}
+
+func verifyPlaylist(report *VerifyReport, dir string, name string, expected time.Duration, checkDuration bool) {
+	path, ok := resolve(dir, name)
+	if !ok {
+		report.addProblem("%s: uri escapes output directory", name)
+		return
+	}
+	report.Playlists = append(report.Playlists, name)
+
+	p, err := ParsePlaylistFile(path)
+	if err != nil {
+		report.addProblem("%s: %v", name, err)
+		return
+	}
+
+	if p.Master {
+		if len(p.Variants) == 0 {
+			report.addProblem("%s: master playlist has no variants", name)
+		}
+		base := filepath.Dir(name)
+		for _, v := range p.Variants {
+			verifyPlaylist(report, dir, filepath.Join(base, v.URI), expected, checkDuration)
+		}
+		for _, r := range p.Renditions {
+			if r.URI != "" {
+				verifyPlaylist(report, dir, filepath.Join(base, r.URI), expected, false)
+			}
+		}
+		return
+	}
+
+	if !p.EndList {
+		report.addProblem("%s: missing #EXT-X-ENDLIST", name)
+	}
+	if p.TargetDuration <= 0 {
+		report.addProblem("%s: missing #EXT-X-TARGETDURATION", name)
+	}
+	if len(p.Segments) == 0 {
+		report.addProblem("%s: no segments", name)
+		return
+	}
+
+	base := filepath.Dir(name)
+	for i, s := range p.Segments {
+		if p.TargetDuration > 0 && int(math.Round(s.Duration)) > p.TargetDuration {
+			report.addProblem("%s: segment %d lasts %.3fs, above target duration %ds", name, i, s.Duration, p.TargetDuration)
+		}
+		segPath, ok := resolve(dir, filepath.Join(base, s.URI))
+		if !ok {
+			report.addProblem("%s: segment %q escapes output directory", name, s.URI)
+			continue
+		}
+		info, err := os.Stat(segPath)
+		switch {
+		case err != nil:
+			report.addProblem("%s: segment %q is missing", name, s.URI)
+		case info.Size() == 0:
+			report.addProblem("%s: segment %q is empty", name, s.URI)
+		}
+	}
+
+	if !checkDuration {
+		return
+	}
+
+	total := p.TotalDuration()
+	report.Segments += len(p.Segments)
+	// variants share a timeline; the first one is the reference
+	if !report.measured {
+		report.TotalDurationS, report.measured = total, true
+	}
+
+	if expected > 0 {
+		tolerance := math.Max(minDurationTolerance.Seconds(), expected.Seconds()*durationToleranceRel)
+		if math.Abs(total-expected.Seconds()) > tolerance {
+			report.addProblem("%s: total duration %.3fs differs from source duration %.0fs", name, total, expected.Seconds())
+		}
+	}
+}
+
+func resolve(dir string, name string) (string, bool) {
+	if strings.Contains(name, "://") || filepath.IsAbs(name) {
+		return "", false
+	}
+	clean := filepath.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(dir, clean), true
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type AuthMiddleware struct {
+	service *service.AuthService
+	apiKeys *service.APIKeyService
+	logger  *zap.Logger
+}
+
+func NewAuthMiddleware(authService *service.AuthService, apiKeys *service.APIKeyService, logger *zap.Logger) *AuthMiddleware {
+	return &AuthMiddleware{
+		service: authService,
+		apiKeys: apiKeys,
+		logger:  logger,
+	}
+}
+
+func (m *AuthMiddleware) RequireAuth(ctx *gin.Context) {
+	token, ok := authorizationToken(ctx)
+	if !ok {
+		util.AbortWithError(ctx, domain.ErrUnauthorized)
+		return
+	}
+
+	principal, err := m.authenticate(ctx, token)
+	if err != nil {
+		util.AbortWithError(ctx, err)
+		return
+	}
+
+	util.SetPrincipal(ctx, principal)
+	ctx.Next()
+}
+
+// OptionalAuth identifies the caller when credentials are present but lets
+// anonymous requests through; invalid credentials are still rejected.
+func (m *AuthMiddleware) OptionalAuth(ctx *gin.Context) {
+	token, ok := authorizationToken(ctx)
+	if !ok {
+		ctx.Next()
+		return
+	}
+
+	principal, err := m.authenticate(ctx, token)
+	if err != nil {
+		util.AbortWithError(ctx, err)
+		return
+	}
+
+	util.SetPrincipal(ctx, principal)
+	ctx.Next()
+}
+
+func (m *AuthMiddleware) authenticate(ctx *gin.Context, token string) (domain.Principal, error) {
+	if strings.HasPrefix(token, domain.APIKeyPrefix) {
+		return m.apiKeys.Authenticate(ctx.Request.Context(), token)
+	}
+	return m.service.Authenticate(ctx.Request.Context(), token)
+}
+
+func (m *AuthMiddleware) RequireAdmin(ctx *gin.Context) {
+	if !util.PrincipalFrom(ctx).IsAdmin() {
+		util.AbortWithError(ctx, domain.ErrForbidden)
+		return
+	}
+	ctx.Next()
+}
+
+func (m *AuthMiddleware) RequireScope(scope domain.Scope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !util.PrincipalFrom(ctx).HasScope(scope) {
+			util.AbortWithError(ctx, domain.ErrForbidden)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// authorizationToken accepts both "Bearer <jwt|key>" and "ApiKey <key>".
+func authorizationToken(ctx *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(ctx.GetHeader("Authorization"), " ")
+	if !ok || !(strings.EqualFold(scheme, "Bearer") || strings.EqualFold(scheme, "ApiKey")) {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+var Module = fx.Module("middleware", fx.Provide(NewAuthMiddleware, NewOpenAPIValidator, NewErrorRenderer, NewIdempotency))
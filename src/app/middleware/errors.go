@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"awesomeProject/src/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ErrorRenderer turns the last error attached with ctx.Error into a
+// problem+json response, unless the handler already wrote one.
+type ErrorRenderer struct {
+	logger *zap.Logger
+}
+
+func NewErrorRenderer(logger *zap.Logger) *ErrorRenderer {
+	return &ErrorRenderer{logger: logger}
+}
+
+func (m *ErrorRenderer) Render(ctx *gin.Context) {
+	ctx.Next()
+
+	if len(ctx.Errors) == 0 {
+		return
+	}
+
+	err := ctx.Errors.Last().Err
+	problem := util.ProblemFromError(err)
+	fields := []zap.Field{
+		zap.String("method", ctx.Request.Method),
+		zap.String("path", ctx.Request.URL.Path),
+		zap.String("code", problem.Code),
+		zap.Error(err),
+	}
+	if problem.Status >= http.StatusInternalServerError {
+		m.logger.Error("request failed", fields...)
+	} else {
+		m.logger.Info("request rejected", fields...)
+	}
+
+	if !ctx.Writer.Written() {
+		util.WriteProblem(ctx, problem)
+	}
+}
@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/util"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const replayedHeader = "Idempotent-Replayed"
+
+// replayHeaders are stored with a response; everything else is produced
+// again by the middleware chain on replay.
+var replayHeaders = []string{"Location", "ETag"}
+
+// Idempotency makes mutating requests that carry an Idempotency-Key safe to
+// retry: the first response is stored and replayed for the same key, and a
+// key reused with a different request is rejected.
+type Idempotency struct {
+	service *service.IdempotencyService
+	logger  *zap.Logger
+}
+
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func NewIdempotency(idempotencyService *service.IdempotencyService, logger *zap.Logger) *Idempotency {
+	return &Idempotency{
+		service: idempotencyService,
+		logger:  logger,
+	}
+}
+
+func (m *Idempotency) Handle(ctx *gin.Context) {
+	key := ctx.GetHeader(domain.IdempotencyHeader)
+	if key == "" || !m.service.Enabled() || !isMutating(ctx.Request.Method) {
+		ctx.Next()
+		return
+	}
+	if !domain.ValidIdempotencyKey(key) {
+		util.AbortWithError(ctx, domain.ErrInvalidIdempotencyKey)
+		return
+	}
+
+	fingerprint, cleanup, err := fingerprintRequest(ctx.Request)
+	if err != nil {
+		m.logger.Error("failed to read request body", zap.Error(err))
+		util.AbortWithError(ctx, err)
+		return
+	}
+	defer cleanup()
+
+	record := &domain.IdempotencyRecord{
+		Owner:       util.PrincipalFrom(ctx).UserID,
+		Key:         key,
+		Method:      ctx.Request.Method,
+		Path:        ctx.Request.URL.Path,
+		Fingerprint: fingerprint,
+	}
+	stored, err := m.service.Begin(ctx.Request.Context(), record)
+	if err != nil {
+		util.AbortWithError(ctx, err)
+		return
+	}
+	if stored != nil {
+		replay(ctx, stored)
+		return
+	}
+
+	// the reservation is dropped unless a response was stored, so requests
+	// that failed or panicked can be retried with the same key
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		if err := m.service.Release(context.WithoutCancel(ctx.Request.Context()), record); err != nil {
+			m.logger.Error("failed to release idempotency key", zap.String("key", key), zap.Error(err))
+		}
+	}()
+
+	writer := &recordingWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer = writer
+	ctx.Next()
+	ctx.Writer = writer.ResponseWriter
+
+	status := writer.Status()
+	if len(ctx.Errors) > 0 || status >= http.StatusInternalServerError || !writer.Written() {
+		return
+	}
+
+	record.StatusCode = &status
+	if contentType := writer.Header().Get("Content-Type"); contentType != "" {
+		record.ResponseType = &contentType
+	}
+	record.Headers = map[string]string{}
+	for _, name := range replayHeaders {
+		if value := writer.Header().Get(name); value != "" {
+			record.Headers[name] = value
+		}
+	}
+	record.Body = writer.body.Bytes()
+
+	if err := m.service.Complete(context.WithoutCancel(ctx.Request.Context()), record); err != nil {
+		m.logger.Error("failed to store idempotent response", zap.String("key", key), zap.Error(err))
+		return
+	}
+	completed = true
+}
+
+func replay(ctx *gin.Context, stored *domain.IdempotencyRecord) {
+	for name, value := range stored.Headers {
+		ctx.Header(name, value)
+	}
+	ctx.Header(replayedHeader, "true")
+
+	if stored.ResponseType != nil && len(stored.Body) > 0 {
+		ctx.Data(*stored.StatusCode, *stored.ResponseType, stored.Body)
+	} else {
+		ctx.Status(*stored.StatusCode)
+	}
+	ctx.Abort()
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// fingerprintRequest hashes the method, URL and body of req. The body is
+// spooled to a temporary file so handlers can still read it afterwards.
+func fingerprintRequest(req *http.Request) (string, func(), error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL.RequestURI())
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return hex.EncodeToString(h.Sum(nil)), func() {}, nil
+	}
+
+	file, err := os.CreateTemp("", "idempotency-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	if _, err := io.Copy(file, req.Body); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if err := hashBody(h, file, req.Header.Get("Content-Type")); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	req.Body = file
+	return hex.EncodeToString(h.Sum(nil)), cleanup, nil
+}
+
+// hashBody writes a digest of the spooled body to h. Multipart bodies are
+// hashed part by part because clients choose a new boundary on every retry.
+func hashBody(h hash.Hash, file *os.File, contentType string) error {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType == "multipart/form-data" && params["boundary"] != "" {
+		if digest, err := multipartDigest(file, params["boundary"]); err == nil {
+			h.Write(digest)
+			return nil
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.Copy(h, file)
+	return err
+}
+
+func multipartDigest(file *os.File, boundary string) ([]byte, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	reader := multipart.NewReader(file, boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return h.Sum(nil), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(h, "%q %q\n", part.FormName(), part.FileName())
+		partHash := sha256.New()
+		if _, err := io.Copy(partHash, part); err != nil {
+			return nil, err
+		}
+		h.Write(partHash.Sum(nil))
+	}
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"awesomeProject/src/app/service"
+	"awesomeProject/src/app/testdb"
+	"awesomeProject/src/util"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// newIdempotentRouter serves a handler that creates a numbered resource on
+// every call it actually receives. The caller is named by X-User.
+func newIdempotentRouter(t *testing.T) (*gin.Engine, *atomic.Int32) {
+	db := testdb.Open(t)
+	log := zap.NewNop()
+	cfg := &config.Config{Idempotency: config.IdempotencyConfig{TTL: time.Hour}}
+	idempotency := NewIdempotency(service.NewIdempotencyService(cfg, repository.NewIdempotencyRepository(db, log), log), log)
+
+	calls := &atomic.Int32{}
+	r := gin.New()
+	r.Use(NewErrorRenderer(log).Render)
+	api := r.Group("/api", func(ctx *gin.Context) {
+		util.SetPrincipal(ctx, domain.Principal{UserID: ctx.GetHeader("X-User")})
+	}, idempotency.Handle)
+	api.POST("/video", func(ctx *gin.Context) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(ctx.Request.Body)
+		if strings.Contains(string(body), "invalid") {
+			_ = ctx.Error(&domain.ValidationError{Field: "title", Message: "is invalid"})
+			return
+		}
+		ctx.Header("Location", "/api/video/"+strconv.Itoa(int(n)))
+		ctx.JSON(http.StatusCreated, gin.H{"id": n, "body": string(body)})
+	})
+	return r, calls
+}
+
+func sendIdempotent(r *gin.Engine, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/video", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(domain.IdempotencyHeader, key)
+	}
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func problemCode(t *testing.T, recorder *httptest.ResponseRecorder) string {
+	t.Helper()
+	var problem util.Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("response %q is not a problem: %v", recorder.Body.String(), err)
+	}
+	return problem.Code
+}
+
+func TestIdempotencyReplaysTheFirstResponse(t *testing.T) {
+	r, calls := newIdempotentRouter(t)
+
+	first := sendIdempotent(r, "u1", "upload-1", `{"title":"a"}`)
+	if first.Code != http.StatusCreated || first.Header().Get(replayedHeader) != "" {
+		t.Fatalf("first request: %d %v", first.Code, first.Header())
+	}
+
+	retry := sendIdempotent(r, "u1", "upload-1", `{"title":"a"}`)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry got %d %q, want %d %q", retry.Code, retry.Body.String(), first.Code, first.Body.String())
+	}
+	if retry.Header().Get(replayedHeader) != "true" || retry.Header().Get("Location") != first.Header().Get("Location") {
+		t.Errorf("retry headers = %v", retry.Header())
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want once", n)
+	}
+
+	// keys are only unique per caller
+	if other := sendIdempotent(r, "u2", "upload-1", `{"title":"a"}`); other.Code != http.StatusCreated || other.Header().Get(replayedHeader) != "" {
+		t.Errorf("another caller's request was replayed: %d %v", other.Code, other.Header())
+	}
+	if plain := sendIdempotent(r, "u1", "", `{"title":"a"}`); plain.Code != http.StatusCreated {
+		t.Errorf("request without a key: %d", plain.Code)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("handler ran %d times, want 3", n)
+	}
+}
+
+func TestIdempotencyRejectsAReusedKey(t *testing.T) {
+	r, calls := newIdempotentRouter(t)
+
+	if first := sendIdempotent(r, "u1", "upload-1", `{"title":"a"}`); first.Code != http.StatusCreated {
+		t.Fatalf("first request: %d", first.Code)
+	}
+	reused := sendIdempotent(r, "u1", "upload-1", `{"title":"b"}`)
+	if reused.Code != http.StatusConflict || problemCode(t, reused) != "idempotency_key_reused" {
+		t.Errorf("reused key got %d %q, want 409 idempotency_key_reused", reused.Code, reused.Body.String())
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want once", n)
+	}
+
+	if invalid := sendIdempotent(r, "u1", "not a key", `{"title":"a"}`); invalid.Code != http.StatusBadRequest || problemCode(t, invalid) != "invalid_idempotency_key" {
+		t.Errorf("invalid key got %d %q", invalid.Code, invalid.Body.String())
+	}
+}
+
+func TestIdempotencyDoesNotStoreFailures(t *testing.T) {
+	r, calls := newIdempotentRouter(t)
+
+	for i := 0; i < 2; i++ {
+		if res := sendIdempotent(r, "u1", "upload-2", `{"title":"invalid"}`); res.Code != http.StatusBadRequest || res.Header().Get(replayedHeader) != "" {
+			t.Errorf("attempt %d: %d %v", i+1, res.Code, res.Header())
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("handler ran %d times, want 2: a failed request must be retried", n)
+	}
+}
+
+func multipartRequest(t *testing.T, boundary string, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.SetBoundary(boundary); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.WriteField("title", "Holiday")
+	part, _ := w.CreateFormFile("file", "holiday.mp4")
+	_, _ = io.WriteString(part, content)
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/video", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestFingerprintIgnoresMultipartBoundary(t *testing.T) {
+	fingerprint := func(req *http.Request) string {
+		t.Helper()
+		fp, cleanup, err := fingerprintRequest(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+		if err := req.ParseMultipartForm(1 << 20); err != nil || req.FormValue("title") != "Holiday" {
+			t.Errorf("body is not readable after fingerprinting: %v", err)
+		}
+		return fp
+	}
+
+	first := fingerprint(multipartRequest(t, "boundary-one", "frames"))
+	if retry := fingerprint(multipartRequest(t, "boundary-two", "frames")); retry != first {
+		t.Error("a retry with a new boundary has another fingerprint")
+	}
+	if other := fingerprint(multipartRequest(t, "boundary-one", "other frames")); other == first {
+		t.Error("another file has the same fingerprint")
+	}
+}
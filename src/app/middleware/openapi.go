@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/openapi"
+	"awesomeProject/src/util"
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const violationHeader = "X-OpenAPI-Violation"
+
+// OpenAPIValidator checks traffic against the published OpenAPI document.
+// It is meant for development and tests: invalid requests are rejected with
+// 400, invalid responses are logged and flagged with a header.
+type OpenAPIValidator struct {
+	doc     *openapi.Document
+	enabled bool
+	logger  *zap.Logger
+}
+
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func NewOpenAPIValidator(cfg *config.Config, logger *zap.Logger) (*OpenAPIValidator, error) {
+	doc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAPIValidator{
+		doc:     doc,
+		enabled: cfg.OpenAPI.Validate,
+		logger:  logger,
+	}, nil
+}
+
+func (v *OpenAPIValidator) Validate(ctx *gin.Context) {
+	if !v.enabled {
+		ctx.Next()
+		return
+	}
+
+	op, ok := v.doc.Operation(ctx.Request.Method, ctx.FullPath())
+	if !ok {
+		if ctx.FullPath() != "" {
+			v.logger.Warn("route is missing from the openapi document",
+				zap.String("method", ctx.Request.Method), zap.String("route", ctx.FullPath()))
+		}
+		ctx.Next()
+		return
+	}
+
+	if problems := v.doc.ValidateRequest(op, ctx.Request, ctx.Params); len(problems) > 0 {
+		problem := util.Problem{
+			Type:   "/problems/request_invalid",
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: "request does not match the API specification",
+			Code:   "request_invalid",
+		}
+		for _, p := range problems {
+			field, message, _ := strings.Cut(p, ": ")
+			problem.Errors = append(problem.Errors, util.ProblemField{Field: field, Message: message})
+		}
+		util.WriteProblem(ctx, problem)
+		return
+	}
+
+	if !op.ProducesJSON() {
+		ctx.Next()
+		return
+	}
+
+	original := ctx.Writer
+	writer := &bufferedWriter{ResponseWriter: original}
+	ctx.Writer = writer
+	ctx.Next()
+	ctx.Writer = original
+
+	body := writer.body.Bytes()
+	if problems := v.doc.ValidateResponse(op, original.Status(), original.Header().Get("Content-Type"), body); len(problems) > 0 {
+		v.logger.Error("response does not match the openapi document",
+			zap.String("method", ctx.Request.Method),
+			zap.String("route", ctx.FullPath()),
+			zap.Int("status", original.Status()),
+			zap.Strings("problems", problems))
+		if !original.Written() {
+			original.Header().Set(violationHeader, strings.Join(problems, "; "))
+		}
+	}
+
+	if len(body) > 0 {
+		_, _ = original.Write(body)
+	}
+}
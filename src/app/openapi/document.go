@@ -0,0 +1,99 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Document is the subset of an OpenAPI 3.0 document needed to validate
+// traffic; the raw specification is served unchanged.
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas"`
+	Parameters map[string]*Parameter `json:"parameters"`
+}
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Parameters  []Parameter         `json:"parameters"`
+	RequestBody *RequestBody        `json:"requestBody"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+func Spec() []byte {
+	return spec
+}
+
+func Load() (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi document: %w", err)
+	}
+	return &doc, nil
+}
+
+// Operation looks up an operation by HTTP method and gin route pattern,
+// e.g. "/api/video/:video_uuid".
+func (d *Document) Operation(method, route string) (*Operation, bool) {
+	item, ok := d.Paths[templatePath(route)]
+	if !ok {
+		return nil, false
+	}
+	op, ok := item[strings.ToLower(method)]
+	if !ok {
+		return nil, false
+	}
+	return &op, true
+}
+
+// Response returns the declared response for status, falling back to
+// "default".
+func (op *Operation) Response(status int) (Response, bool) {
+	if r, ok := op.Responses[fmt.Sprint(status)]; ok {
+		return r, true
+	}
+	r, ok := op.Responses["default"]
+	return r, ok
+}
+
+func templatePath(route string) string {
+	segs := strings.Split(route, "/")
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			segs[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
@@ -0,0 +1,177 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateRequest checks path and query parameters and, for JSON bodies,
+// the request payload. The body is restored so handlers can read it again.
+func (d *Document) ValidateRequest(op *Operation, req *http.Request, params gin.Params) []string {
+	var problems []string
+	query := req.URL.Query()
+
+	for _, p := range op.Parameters {
+		if p.Ref != "" {
+			ref, ok := d.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+			if !ok {
+				continue
+			}
+			p = *ref
+		}
+
+		var raw string
+		var present bool
+		switch p.In {
+		case "path":
+			raw, present = params.Get(p.Name)
+		case "query":
+			present = query.Has(p.Name)
+			raw = query.Get(p.Name)
+		case "header":
+			raw = req.Header.Get(p.Name)
+			present = raw != ""
+		default:
+			continue
+		}
+		if !present {
+			if p.Required {
+				problems = append(problems, fmt.Sprintf("%s parameter %q: is required", p.In, p.Name))
+			}
+			continue
+		}
+		for _, msg := range d.validateParameter(p.Schema, raw) {
+			problems = append(problems, fmt.Sprintf("%s parameter %q: %s", p.In, p.Name, msg))
+		}
+	}
+
+	if op.RequestBody == nil {
+		return problems
+	}
+	if req.ContentLength == 0 {
+		if op.RequestBody.Required {
+			problems = append(problems, "request body: is required")
+		}
+		return problems
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	content, ok := op.RequestBody.Content[mediaType]
+	if !ok {
+		return append(problems, fmt.Sprintf("request body: unsupported content type %q", mediaType))
+	}
+	if !isJSON(mediaType) || content.Schema == nil {
+		return problems
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return append(problems, "request body: "+err.Error())
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	value, err := decode(body)
+	if err != nil {
+		return append(problems, "request body: invalid json")
+	}
+	for _, msg := range d.Validate(content.Schema, value) {
+		problems = append(problems, "request body "+msg)
+	}
+	return problems
+}
+
+// ValidateResponse checks a JSON response body against the schema declared
+// for its status code. Non-JSON responses are only checked for status.
+func (d *Document) ValidateResponse(op *Operation, status int, contentType string, body []byte) []string {
+	resp, ok := op.Response(status)
+	if !ok {
+		return []string{fmt.Sprintf("response: undocumented status %d", status)}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if len(body) == 0 || !isJSON(mediaType) {
+		return nil
+	}
+	content, ok := resp.Content[mediaType]
+	if !ok {
+		return []string{fmt.Sprintf("response: status %d does not declare a %s body", status, mediaType)}
+	}
+
+	value, err := decode(body)
+	if err != nil {
+		return []string{"response: invalid json"}
+	}
+
+	var problems []string
+	for _, msg := range d.Validate(content.Schema, value) {
+		problems = append(problems, "response body "+msg)
+	}
+	return problems
+}
+
+// ProducesJSON reports whether a successful response of the operation has a
+// JSON body; other operations (media, HTML pages, streams) are not buffered.
+func (op *Operation) ProducesJSON() bool {
+	for status, r := range op.Responses {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		for mediaType := range r.Content {
+			if isJSON(mediaType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == gin.MIMEJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+func (d *Document) validateParameter(schema *Schema, raw string) []string {
+	schema = d.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+
+	var value any = raw
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return []string{"must be a number"}
+		}
+		value = json.Number(raw)
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return []string{"must be a boolean"}
+		}
+		value = b
+	}
+
+	var problems []string
+	for _, msg := range d.Validate(schema, value) {
+		problems = append(problems, strings.TrimPrefix(msg, "$: "))
+	}
+	return problems
+}
+
+func decode(body []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
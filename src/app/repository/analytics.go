@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AnalyticsRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+type DailyStatsRow struct {
+	Day           string
+	Views         int64
+	UniqueViewers int64
+	WatchMs       int64
+}
+
+type RetentionRow struct {
+	Segment  int
+	StartMs  int64
+	Sessions int64
+}
+
+type playbackSession struct {
+	videoID string
+	key     string
+}
+
+func NewAnalyticsRepository(db *gorm.DB, logger *zap.Logger) *AnalyticsRepository {
+	return &AnalyticsRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+// Record applies a batch of playback events to the sessions and rollups.
+// Each session is written in its own savepoint, so a video deleted in the
+// meantime only drops its own events.
+func (repo *AnalyticsRepository) Record(ctx context.Context, events []domain.PlaybackEvent) error {
+	groups := make(map[playbackSession][]domain.PlaybackEvent)
+	var order []playbackSession
+	for _, e := range events {
+		key := playbackSession{videoID: e.VideoID, key: e.SessionKey}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], e)
+	}
+
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, key := range order {
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				return recordSession(tx, groups[key])
+			})
+			if err != nil {
+				repo.Logger.Warn("dropped playback events", zap.String("videoId", key.videoID), zap.Int("events", len(groups[key])), zap.Error(err))
+			}
+		}
+		return nil
+	})
+}
+
+func recordSession(tx *gorm.DB, events []domain.PlaybackEvent) error {
+	first, last := events[0].At, events[0].At
+	watched := make(map[int]domain.PlaybackEvent)
+	for _, e := range events {
+		first, last = minTime(first, e.At), maxTime(last, e.At)
+		if e.Segment != domain.NoSegment {
+			watched[e.Segment] = e
+		}
+	}
+	e := events[0]
+
+	// the upsert locks the session row, so concurrent batches of the same
+	// session from other instances are applied one after another
+	var session struct {
+		Day      string
+		Segments int
+	}
+	err := tx.Raw(`INSERT INTO playback_sessions (video_id, session_key, viewer_key, day, started_at, last_seen_at)
+		VALUES (?, ?, ?, ?::date, ?, ?)
+		ON CONFLICT (video_id, session_key) DO UPDATE SET last_seen_at = GREATEST(playback_sessions.last_seen_at, EXCLUDED.last_seen_at)
+		RETURNING day::text AS day, segments`,
+		e.VideoID, e.SessionKey, e.ViewerKey, domain.FormatAnalyticsDate(first), first, last).Scan(&session).Error
+	if err != nil || len(watched) == 0 {
+		return err
+	}
+
+	values := make([]string, 0, len(watched))
+	args := make([]any, 0, 3*len(watched))
+	for segment := range watched {
+		values = append(values, "(?, ?, ?)")
+		args = append(args, e.VideoID, e.SessionKey, segment)
+	}
+	var inserted []int
+	err = tx.Raw(`INSERT INTO playback_session_segments (video_id, session_key, segment) VALUES `+strings.Join(values, ", ")+`
+		ON CONFLICT DO NOTHING RETURNING segment`, args...).Scan(&inserted).Error
+	if err != nil || len(inserted) == 0 {
+		return err
+	}
+
+	var watchMs int64
+	values, args = values[:0], args[:0]
+	for _, segment := range inserted {
+		watchMs += watched[segment].DurationMs
+		values = append(values, "(?, ?::date, ?, ?, 1)")
+		args = append(args, e.VideoID, session.Day, segment, watched[segment].StartMs)
+	}
+
+	err = tx.Exec(`UPDATE playback_sessions SET segments = segments + ?, watch_ms = watch_ms + ?
+		WHERE video_id = ? AND session_key = ?`, len(inserted), watchMs, e.VideoID, e.SessionKey).Error
+	if err != nil {
+		return err
+	}
+
+	// a session becomes a view with its first segment
+	var views, uniques int64
+	if session.Segments == 0 {
+		views = 1
+		res := tx.Exec(`INSERT INTO video_daily_viewers (video_id, day, viewer_key) VALUES (?, ?::date, ?) ON CONFLICT DO NOTHING`,
+			e.VideoID, session.Day, e.ViewerKey)
+		if res.Error != nil {
+			return res.Error
+		}
+		uniques = res.RowsAffected
+	}
+
+	err = tx.Exec(`INSERT INTO video_daily_stats (video_id, day, views, unique_viewers, watch_ms) VALUES (?, ?::date, ?, ?, ?)
+		ON CONFLICT (video_id, day) DO UPDATE SET
+			views = video_daily_stats.views + EXCLUDED.views,
+			unique_viewers = video_daily_stats.unique_viewers + EXCLUDED.unique_viewers,
+			watch_ms = video_daily_stats.watch_ms + EXCLUDED.watch_ms`,
+		e.VideoID, session.Day, views, uniques, watchMs).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Exec(`INSERT INTO video_segment_retention (video_id, day, segment, start_ms, sessions) VALUES `+strings.Join(values, ", ")+`
+		ON CONFLICT (video_id, day, segment) DO UPDATE SET
+			sessions = video_segment_retention.sessions + 1,
+			start_ms = EXCLUDED.start_ms`, args...).Error
+}
+
+func (repo *AnalyticsRepository) GetDaily(ctx context.Context, videoId string, from, to string) ([]DailyStatsRow, error) {
+	var rows []DailyStatsRow
+
+	err := repo.DB.WithContext(ctx).Raw(`SELECT day::text AS day, views, unique_viewers, watch_ms FROM video_daily_stats
+		WHERE video_id = ? AND day BETWEEN ?::date AND ?::date ORDER BY day`, videoId, from, to).Scan(&rows).Error
+	return rows, err
+}
+
+// CountViewers counts distinct viewers over the range; daily counts cannot
+// simply be added up. Days whose viewer keys were pruned only have their
+// daily count left, so viewers returning on those days are counted again.
+func (repo *AnalyticsRepository) CountViewers(ctx context.Context, videoId string, from, to string) (int64, error) {
+	var count int64
+
+	err := repo.DB.WithContext(ctx).Raw(`SELECT
+		(SELECT COUNT(DISTINCT viewer_key) FROM video_daily_viewers
+			WHERE video_id = ? AND day BETWEEN ?::date AND ?::date)
+		+ (SELECT COALESCE(SUM(s.unique_viewers), 0) FROM video_daily_stats s
+			WHERE s.video_id = ? AND s.day BETWEEN ?::date AND ?::date
+			AND NOT EXISTS (SELECT 1 FROM video_daily_viewers v WHERE v.video_id = s.video_id AND v.day = s.day))`,
+		videoId, from, to, videoId, from, to).Scan(&count).Error
+	return count, err
+}
+
+func (repo *AnalyticsRepository) GetRetention(ctx context.Context, videoId string, from, to string) ([]RetentionRow, error) {
+	var rows []RetentionRow
+
+	err := repo.DB.WithContext(ctx).Raw(`SELECT segment, MAX(start_ms) AS start_ms, SUM(sessions) AS sessions FROM video_segment_retention
+		WHERE video_id = ? AND day BETWEEN ?::date AND ?::date GROUP BY segment ORDER BY segment`, videoId, from, to).Scan(&rows).Error
+	return rows, err
+}
+
+// DeleteSessionsBefore prunes raw sessions; the rollups are kept.
+func (repo *AnalyticsRepository) DeleteSessionsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res := repo.DB.WithContext(ctx).Exec(`DELETE FROM playback_sessions WHERE last_seen_at < ?`, cutoff)
+	return res.RowsAffected, res.Error
+}
+
+// DeleteViewersBefore prunes the viewer keys of days before cutoff. Their
+// distinct counts are folded into the daily rollup first, in the same
+// transaction, so the daily figures survive the keys.
+func (repo *AnalyticsRepository) DeleteViewersBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	day := domain.FormatAnalyticsDate(cutoff)
+	var deleted int64
+
+	err := repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`UPDATE video_daily_stats s SET unique_viewers = v.viewers
+			FROM (SELECT video_id, day, COUNT(*) AS viewers FROM video_daily_viewers WHERE day < ?::date GROUP BY video_id, day) v
+			WHERE s.video_id = v.video_id AND s.day = v.day`, day).Error
+		if err != nil {
+			return err
+		}
+		res := tx.Exec(`DELETE FROM video_daily_viewers WHERE day < ?::date`, day)
+		deleted = res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+var AnalyticsRepoModule = fx.Module("analytics-repository", fx.Provide(NewAnalyticsRepository))
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const lastUsedResolution = time.Minute
+
+type APIKeyRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewAPIKeyRepository(db *gorm.DB, logger *zap.Logger) *APIKeyRepository {
+	return &APIKeyRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+func (repo *APIKeyRepository) GetAll(ctx context.Context) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+
+	if err := repo.DB.WithContext(ctx).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (repo *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	var key domain.APIKey
+
+	if err := repo.DB.WithContext(ctx).First(&key, "prefix = ?", prefix).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (repo *APIKeyRepository) Insert(ctx context.Context, key *domain.APIKey) (string, error) {
+	if err := repo.DB.WithContext(ctx).Create(key).Error; err != nil {
+		return "", err
+	}
+	return key.ID, nil
+}
+
+func (repo *APIKeyRepository) Revoke(ctx context.Context, id string) error {
+	res := repo.DB.WithContext(ctx).
+		Model(&domain.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed only writes when the stored value is older than a minute,
+// so busy keys do not turn every request into an UPDATE.
+func (repo *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, now time.Time) error {
+	return repo.DB.WithContext(ctx).
+		Model(&domain.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-lastUsedResolution)).
+		Update("last_used_at", now).Error
+}
+
+var APIKeyRepoModule = fx.Module("apikey-repository", fx.Provide(NewAPIKeyRepository))
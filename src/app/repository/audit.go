@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewAuditRepository(db *gorm.DB, logger *zap.Logger) *AuditRepository {
+	return &AuditRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+func (repo *AuditRepository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	if err := repo.DB.WithContext(ctx).Create(entry).Error; err != nil {
+		repo.Logger.Error("failed to write audit entry",
+			zap.String("video_id", entry.VideoID),
+			zap.String("action", entry.Action),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+func (repo *AuditRepository) GetByVideoId(ctx context.Context, videoId string) ([]domain.AuditEntry, error) {
+	var entries []domain.AuditEntry
+
+	if err := repo.DB.WithContext(ctx).
+		Where("video_id = ?", videoId).
+		Order("created_at ASC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+var AuditRepoModule = fx.Module("audit-repository", fx.Provide(NewAuditRepository))
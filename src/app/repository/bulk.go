@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type BulkRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewBulkRepository(db *gorm.DB, logger *zap.Logger) *BulkRepository {
+	return &BulkRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+func (repo *BulkRepository) Insert(ctx context.Context, op *domain.BulkOperation) error {
+	return repo.DB.WithContext(ctx).Create(op).Error
+}
+
+func (repo *BulkRepository) GetById(ctx context.Context, id string) (*domain.BulkOperation, error) {
+	var op domain.BulkOperation
+
+	err := repo.DB.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		First(&op, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrBulkOperationNotFound
+		}
+		return nil, err
+	}
+	return &op, nil
+}
+
+func (repo *BulkRepository) GetUnfinished(ctx context.Context) ([]domain.BulkOperation, error) {
+	var ops []domain.BulkOperation
+
+	err := repo.DB.WithContext(ctx).
+		Where("status <> ?", domain.BulkCompleted).
+		Order("created_at").
+		Find(&ops).Error
+	return ops, err
+}
+
+func (repo *BulkRepository) GetPendingItems(ctx context.Context, operationId string) ([]domain.BulkItem, error) {
+	var items []domain.BulkItem
+
+	err := repo.DB.WithContext(ctx).
+		Where("operation_id = ? AND status = ?", operationId, domain.BulkItemPending).
+		Order("position").
+		Find(&items).Error
+	return items, err
+}
+
+// Claim marks an operation as running under a lease until leaseExpiresAt.
+// Only queued operations and running ones whose lease has run out can be
+// claimed, so an operation is not executed by two processes at once; false
+// is returned when the operation was not claimed.
+func (repo *BulkRepository) Claim(ctx context.Context, id string, now time.Time, leaseExpiresAt time.Time) (bool, error) {
+	res := repo.DB.WithContext(ctx).
+		Model(&domain.BulkOperation{}).
+		Where("id = ?", id).
+		Where("status = ? OR (status = ? AND (lease_expires_at IS NULL OR lease_expires_at < ?))", domain.BulkQueued, domain.BulkRunning, now).
+		Updates(map[string]interface{}{
+			"status":           domain.BulkRunning,
+			"started_at":       gorm.Expr("COALESCE(started_at, ?)", now),
+			"lease_expires_at": leaseExpiresAt,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// ExtendLease keeps a claimed operation from being taken over while it is
+// still being executed.
+func (repo *BulkRepository) ExtendLease(ctx context.Context, id string, leaseExpiresAt time.Time) error {
+	return repo.DB.WithContext(ctx).
+		Model(&domain.BulkOperation{}).
+		Where("id = ? AND status = ?", id, domain.BulkRunning).
+		Update("lease_expires_at", leaseExpiresAt).Error
+}
+
+func (repo *BulkRepository) SetCompleted(ctx context.Context, id string, finishedAt time.Time) error {
+	return repo.DB.WithContext(ctx).
+		Model(&domain.BulkOperation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":           domain.BulkCompleted,
+			"finished_at":      finishedAt,
+			"lease_expires_at": nil,
+		}).Error
+}
+
+// FinishItem records the outcome of one item and bumps the matching counter
+// in the same transaction so progress never disagrees with the items.
+func (repo *BulkRepository) FinishItem(ctx context.Context, item *domain.BulkItem) error {
+	counter := "succeeded"
+	if item.Status == domain.BulkItemFailed {
+		counter = "failed"
+	}
+
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.BulkItem{}).
+			Where("operation_id = ? AND video_id = ? AND status = ?", item.OperationID, item.VideoID, domain.BulkItemPending).
+			Updates(map[string]interface{}{
+				"status":     item.Status,
+				"error":      item.Error,
+				"code":       item.Code,
+				"updated_at": time.Now().UTC(),
+			})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return tx.Model(&domain.BulkOperation{}).
+			Where("id = ?", item.OperationID).
+			Update(counter, gorm.Expr(counter+" + 1")).Error
+	})
+}
+
+var BulkRepoModule = fx.Module("bulk-repository", fx.Provide(NewBulkRepository))
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CollectionRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewCollectionRepository(db *gorm.DB, logger *zap.Logger) *CollectionRepository {
+	return &CollectionRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+const visibleVideoCount = `(SELECT COUNT(*) FROM collection_videos cv
+	JOIN videos v ON v.id = cv.video_id
+	WHERE cv.collection_id = collections.id AND v.archived_at IS NULL) AS video_count`
+
+// GetAll lists collections, limited to those of ownerId when it is set.
+func (repo *CollectionRepository) GetAll(ctx context.Context, ownerId *string, pagination domain.Pagination) (domain.ListPayload[domain.Collection], error) {
+	var result []domain.Collection
+	var total int64
+
+	query := repo.DB.WithContext(ctx).Model(&domain.Collection{})
+	if ownerId != nil {
+		query = query.Where("owner_id = ?", *ownerId)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return domain.ListPayload[domain.Collection]{}, err
+	}
+
+	err := query.
+		Select("collections.*, " + visibleVideoCount).
+		Preload("CoverVideo").
+		Order("created_at DESC").
+		Limit(int(pagination.Limit)).
+		Offset(int(pagination.Offset)).
+		Find(&result).Error
+	if err != nil {
+		return domain.ListPayload[domain.Collection]{}, err
+	}
+
+	return domain.ListPayload[domain.Collection]{Data: result, TotalCount: &total}, nil
+}
+
+func (repo *CollectionRepository) GetById(ctx context.Context, id string) (*domain.Collection, error) {
+	var collection domain.Collection
+
+	err := repo.DB.WithContext(ctx).
+		Select("collections.*, "+visibleVideoCount).
+		Preload("CoverVideo").
+		First(&collection, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCollectionNotFound
+		}
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (repo *CollectionRepository) Insert(ctx context.Context, collection *domain.Collection) (string, error) {
+	if err := repo.DB.WithContext(ctx).Omit("CoverVideo").Create(collection).Error; err != nil {
+		return "", err
+	}
+	return collection.ID, nil
+}
+
+func (repo *CollectionRepository) UpdateById(ctx context.Context, id string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now().UTC()
+
+	res := repo.DB.WithContext(ctx).
+		Model(&domain.Collection{}).
+		Where("id = ?", id).
+		Updates(updates)
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrCollectionNotFound
+	}
+	return nil
+}
+
+func (repo *CollectionRepository) Delete(ctx context.Context, id string) error {
+	res := repo.DB.WithContext(ctx).Where("id = ?", id).Delete(&domain.Collection{})
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrCollectionNotFound
+	}
+	return nil
+}
+
+func (repo *CollectionRepository) IsMember(ctx context.Context, collectionId string, videoId string) (bool, error) {
+	var count int64
+
+	err := repo.DB.WithContext(ctx).
+		Model(&domain.CollectionVideo{}).
+		Where("collection_id = ? AND video_id = ?", collectionId, videoId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (repo *CollectionRepository) GetVideos(ctx context.Context, collectionId string) ([]domain.Video, error) {
+	var videos []domain.Video
+
+	err := repo.DB.WithContext(ctx).
+		Model(&domain.Video{}).
+		Select("videos.*").
+		Joins("JOIN collection_videos cv ON cv.video_id = videos.id").
+		Where("cv.collection_id = ? AND videos.archived_at IS NULL", collectionId).
+		Preload("Tags").
+		Order("cv.position ASC").
+		Find(&videos).Error
+	if err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// AddVideo inserts the video at position, shifting the videos after it;
+// a negative position appends to the end.
+func (repo *CollectionRepository) AddVideo(ctx context.Context, collectionId string, videoId string, position int) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCollection(tx, collectionId); err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&domain.CollectionVideo{}).
+			Where("collection_id = ? AND video_id = ?", collectionId, videoId).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return domain.ErrVideoAlreadyInCollection
+		}
+
+		var count int64
+		if err := tx.Model(&domain.CollectionVideo{}).Where("collection_id = ?", collectionId).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if position < 0 || int64(position) > count {
+			position = int(count)
+		} else {
+			if err := tx.Model(&domain.CollectionVideo{}).
+				Where("collection_id = ? AND position >= ?", collectionId, position).
+				Update("position", gorm.Expr("position + 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&domain.CollectionVideo{
+			CollectionID: collectionId,
+			VideoID:      videoId,
+			Position:     position,
+		}).Error; err != nil {
+			return err
+		}
+		return touchCollection(tx, collectionId)
+	})
+}
+
+func (repo *CollectionRepository) RemoveVideo(ctx context.Context, collectionId string, videoId string) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCollection(tx, collectionId); err != nil {
+			return err
+		}
+
+		var member domain.CollectionVideo
+		if err := tx.First(&member, "collection_id = ? AND video_id = ?", collectionId, videoId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrVideoNotInCollection
+			}
+			return err
+		}
+
+		if err := tx.Delete(&member).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.CollectionVideo{}).
+			Where("collection_id = ? AND position > ?", collectionId, member.Position).
+			Update("position", gorm.Expr("position - 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Collection{}).
+			Where("id = ? AND cover_video_id = ?", collectionId, videoId).
+			Update("cover_video_id", nil).Error; err != nil {
+			return err
+		}
+		return touchCollection(tx, collectionId)
+	})
+}
+
+// Reorder expects videoIds to be a permutation of the visible membership.
+// Archived members are hidden from clients, so they keep their relative
+// order behind the listed videos.
+func (repo *CollectionRepository) Reorder(ctx context.Context, collectionId string, videoIds []string) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCollection(tx, collectionId); err != nil {
+			return err
+		}
+
+		var current, archived []string
+		if err := tx.Model(&domain.CollectionVideo{}).
+			Joins("JOIN videos v ON v.id = collection_videos.video_id").
+			Where("collection_videos.collection_id = ? AND v.archived_at IS NULL", collectionId).
+			Pluck("collection_videos.video_id", &current).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.CollectionVideo{}).
+			Joins("JOIN videos v ON v.id = collection_videos.video_id").
+			Where("collection_videos.collection_id = ? AND v.archived_at IS NOT NULL", collectionId).
+			Order("collection_videos.position ASC").
+			Pluck("collection_videos.video_id", &archived).Error; err != nil {
+			return err
+		}
+
+		if len(current) != len(videoIds) {
+			return &domain.ValidationError{Field: "videoIds", Message: "must list every video of the collection exactly once"}
+		}
+		members := make(map[string]bool, len(current))
+		for _, id := range current {
+			members[id] = false
+		}
+		for _, id := range videoIds {
+			seen, ok := members[id]
+			if !ok || seen {
+				return &domain.ValidationError{Field: "videoIds", Message: "must list every video of the collection exactly once"}
+			}
+			members[id] = true
+		}
+
+		for position, id := range append(videoIds, archived...) {
+			if err := tx.Model(&domain.CollectionVideo{}).
+				Where("collection_id = ? AND video_id = ?", collectionId, id).
+				Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return touchCollection(tx, collectionId)
+	})
+}
+
+func lockCollection(tx *gorm.DB, id string) error {
+	var collection domain.Collection
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&collection, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrCollectionNotFound
+	}
+	return err
+}
+
+func touchCollection(tx *gorm.DB, id string) error {
+	return tx.Model(&domain.Collection{}).Where("id = ?", id).Update("updated_at", time.Now().UTC()).Error
+}
+
+var CollectionRepoModule = fx.Module("collection-repository", fx.Provide(NewCollectionRepository))
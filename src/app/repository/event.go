@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"awesomeProject/src/app/bus"
+	"awesomeProject/src/app/domain"
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type EventRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewEventRepository(db *gorm.DB, logger *zap.Logger) *EventRepository {
+	return &EventRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+// recordEvent appends an event for a video, taking its owner and status from
+// the row as tx sees it, so that it commits together with the change. Every
+// instance is told about the event once it commits.
+func recordEvent(tx *gorm.DB, videoId string, event domain.VideoEventType, progress *float64) error {
+	err := tx.Exec(`INSERT INTO video_events (video_id, owner_id, type, status, progress)
+		SELECT id, owner_id, ?, status, ? FROM videos WHERE id = ?`, string(event), progress, videoId).Error
+	if err != nil {
+		return err
+	}
+	return bus.Notify(tx, bus.Message{Topic: bus.TopicVideoEvent, VideoID: videoId})
+}
+
+func (repo *EventRepository) LatestID(ctx context.Context) (int64, error) {
+	var id int64
+
+	err := repo.DB.WithContext(ctx).Model(&domain.VideoEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
+
+// GetAfter returns up to limit events numbered after id that match filter,
+// oldest first.
+func (repo *EventRepository) GetAfter(ctx context.Context, id int64, filter domain.EventFilter, limit int) ([]domain.VideoEvent, error) {
+	var events []domain.VideoEvent
+
+	db := repo.DB.WithContext(ctx).Where("id > ?", id)
+	if filter.OwnerID != "" {
+		db = db.Where("owner_id = ?", filter.OwnerID)
+	}
+	if len(filter.VideoIDs) > 0 {
+		db = db.Where("video_id IN ?", filter.VideoIDs)
+	}
+	err := db.Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// GetLate returns recent events numbered at most id. Sequence numbers are
+// taken when a transaction inserts but become visible when it commits, so a
+// slow commit can surface an event below the highest number already seen.
+func (repo *EventRepository) GetLate(ctx context.Context, id int64, window time.Duration) ([]domain.VideoEvent, error) {
+	var events []domain.VideoEvent
+
+	err := repo.DB.WithContext(ctx).
+		Where("id <= ? AND created_at >= now() - make_interval(secs => ?)", id, window.Seconds()).
+		Order("id").
+		Find(&events).Error
+	return events, err
+}
+
+func (repo *EventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := repo.DB.WithContext(ctx).Where("created_at < ?", before).Delete(&domain.VideoEvent{})
+	return res.RowsAffected, res.Error
+}
+
+var EventRepoModule = fx.Module("event-repository", fx.Provide(NewEventRepository))
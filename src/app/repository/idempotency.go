@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewIdempotencyRepository(db *gorm.DB, logger *zap.Logger) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+// Reserve inserts record unless its key is already taken, in which case the
+// existing record is returned. Expired records and reservations older than
+// staleBefore (left behind by a crashed request) do not block the key.
+func (repo *IdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord, now time.Time, staleBefore time.Time) (*domain.IdempotencyRecord, error) {
+	db := repo.DB.WithContext(ctx)
+
+	err := db.
+		Where("owner = ? AND key = ?", record.Owner, record.Key).
+		Where("expires_at < ? OR (status_code IS NULL AND created_at < ?)", now, staleBefore).
+		Delete(&domain.IdempotencyRecord{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var existing domain.IdempotencyRecord
+	if err := db.First(&existing, "owner = ? AND key = ?", record.Owner, record.Key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// released between the insert and the read; the caller may retry
+			return nil, domain.ErrIdempotencyInProgress
+		}
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// Complete stores the response of a reserved request so retries replay it.
+func (repo *IdempotencyRepository) Complete(ctx context.Context, record *domain.IdempotencyRecord, now time.Time) error {
+	record.CompletedAt = &now
+
+	return repo.DB.WithContext(ctx).
+		Model(record).
+		Where("status_code IS NULL").
+		Select("status_code", "response_type", "headers", "body", "completed_at").
+		Updates(record).Error
+}
+
+// Release drops an unfinished reservation so the request can be retried.
+func (repo *IdempotencyRepository) Release(ctx context.Context, owner, key string) error {
+	return repo.DB.WithContext(ctx).
+		Where("owner = ? AND key = ? AND status_code IS NULL", owner, key).
+		Delete(&domain.IdempotencyRecord{}).Error
+}
+
+func (repo *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res := repo.DB.WithContext(ctx).
+		Where("expires_at < ?", now).
+		Delete(&domain.IdempotencyRecord{})
+	return res.RowsAffected, res.Error
+}
+
+var IdempotencyRepoModule = fx.Module("idempotency-repository", fx.Provide(NewIdempotencyRepository))
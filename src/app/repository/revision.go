@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RevisionRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewRevisionRepository(db *gorm.DB, logger *zap.Logger) *RevisionRepository {
+	return &RevisionRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+func (repo *RevisionRepository) GetByVideo(ctx context.Context, videoId string) ([]domain.VideoRevision, error) {
+	var revisions []domain.VideoRevision
+
+	err := repo.DB.WithContext(ctx).
+		Where("video_id = ?", videoId).
+		Order("number DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+func (repo *RevisionRepository) GetAll(ctx context.Context) ([]domain.VideoRevision, error) {
+	var revisions []domain.VideoRevision
+
+	err := repo.DB.WithContext(ctx).Order("video_id, number").Find(&revisions).Error
+	return revisions, err
+}
+
+func (repo *RevisionRepository) Get(ctx context.Context, videoId string, number int) (*domain.VideoRevision, error) {
+	var revision domain.VideoRevision
+
+	err := repo.DB.WithContext(ctx).First(&revision, "video_id = ? AND number = ?", videoId, number).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// Insert numbers the revision after the latest one of its video. It fails
+// with ErrRevisionPending while another replacement is being converted.
+func (repo *RevisionRepository) Insert(ctx context.Context, revision *domain.VideoRevision) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// serialize uploads of the same video on its row
+		if err := tx.Exec("SELECT 1 FROM videos WHERE id = ? FOR UPDATE", revision.VideoID).Error; err != nil {
+			return err
+		}
+
+		pending, err := hasPending(tx, revision.VideoID)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return domain.ErrRevisionPending
+		}
+
+		var latest int
+		err = tx.Model(&domain.VideoRevision{}).
+			Where("video_id = ?", revision.VideoID).
+			Select("COALESCE(MAX(number), 0)").
+			Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+
+		revision.Number = latest + 1
+		return tx.Create(revision).Error
+	})
+}
+
+// HasPending reports whether a replacement source of the video is still
+// being converted. The current revision follows the video's own status.
+func (repo *RevisionRepository) HasPending(ctx context.Context, videoId string) (bool, error) {
+	return hasPending(repo.DB.WithContext(ctx), videoId)
+}
+
+// GetUnfinished returns the replacement sources that are still pending or
+// processing, across all videos.
+func (repo *RevisionRepository) GetUnfinished(ctx context.Context) ([]domain.VideoRevision, error) {
+	var revisions []domain.VideoRevision
+
+	err := repo.DB.WithContext(ctx).
+		Where("status IN ?", []domain.RevisionStatus{domain.RevisionPending, domain.RevisionProcessing}).
+		Where("number <> (SELECT revision FROM videos WHERE videos.id = video_revisions.video_id)").
+		Order("created_at").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+func hasPending(db *gorm.DB, videoId string) (bool, error) {
+	var count int64
+
+	err := db.Model(&domain.VideoRevision{}).
+		Where("video_id = ? AND status IN ?", videoId, []domain.RevisionStatus{domain.RevisionPending, domain.RevisionProcessing}).
+		Where("number <> (SELECT revision FROM videos WHERE id = ?)", videoId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (repo *RevisionRepository) SetDuration(ctx context.Context, videoId string, number int, duration sql.NullInt32) error {
+	return repo.update(ctx, videoId, number, map[string]any{"duration_s": duration})
+}
+
+func (repo *RevisionRepository) SetProcessing(ctx context.Context, videoId string, number int) error {
+	return repo.update(ctx, videoId, number, map[string]any{
+		"status":         string(domain.RevisionProcessing),
+		"failure_reason": nil,
+	})
+}
+
+func (repo *RevisionRepository) SetReady(ctx context.Context, videoId string, number int, readyAt time.Time, convertedSize *int64) error {
+	return repo.update(ctx, videoId, number, map[string]any{
+		"status":               string(domain.RevisionReady),
+		"ready_at":             readyAt,
+		"converted_size_bytes": convertedSize,
+		"failure_reason":       nil,
+	})
+}
+
+func (repo *RevisionRepository) SetFailed(ctx context.Context, videoId string, number int, reason error) error {
+	return repo.update(ctx, videoId, number, map[string]any{
+		"status":         string(domain.RevisionFailed),
+		"failure_reason": reason.Error(),
+	})
+}
+
+func (repo *RevisionRepository) update(ctx context.Context, videoId string, number int, updates map[string]any) error {
+	res := repo.DB.WithContext(ctx).
+		Model(&domain.VideoRevision{}).
+		Where("video_id = ? AND number = ?", videoId, number).
+		Updates(updates)
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrRevisionNotFound
+	}
+	return nil
+}
+
+var RevisionRepoModule = fx.Module("revision-repository", fx.Provide(NewRevisionRepository))
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"awesomeProject/src/app/cache"
+	"awesomeProject/src/app/domain"
+	"context"
+	"errors"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SubtitleRepository struct {
+	Cache  *cache.VideoCache
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewSubtitleRepository(db *gorm.DB, logger *zap.Logger, cache *cache.VideoCache) *SubtitleRepository {
+	return &SubtitleRepository{
+		Cache:  cache,
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+func (repo *SubtitleRepository) Get(ctx context.Context, videoId string, language string) (*domain.Subtitle, error) {
+	var subtitle domain.Subtitle
+
+	err := repo.DB.WithContext(ctx).First(&subtitle, "video_id = ? AND language = ?", videoId, language).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSubtitleNotFound
+		}
+		return nil, err
+	}
+	return &subtitle, nil
+}
+
+// Put creates or replaces the track of subtitle's language. Tracks are part
+// of the video representation, so the video version is bumped as well.
+func (repo *SubtitleRepository) Put(ctx context.Context, subtitle *domain.Subtitle, version int) error {
+	err := repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, subtitle.VideoID, nil, version); err != nil {
+			return err
+		}
+
+		if subtitle.IsDefault {
+			err := tx.Model(&domain.Subtitle{}).
+				Where("video_id = ? AND language <> ? AND is_default", subtitle.VideoID, subtitle.Language).
+				Update("is_default", false).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "video_id"}, {Name: "language"}},
+			DoUpdates: clause.AssignmentColumns([]string{"label", "format", "is_default", "cue_count", "content", "updated_at"}),
+		}).Create(subtitle).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	repo.Cache.Delete(subtitle.VideoID)
+	return nil
+}
+
+func (repo *SubtitleRepository) Delete(ctx context.Context, videoId string, language string, version int) error {
+	err := repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, videoId, nil, version); err != nil {
+			return err
+		}
+
+		res := tx.Where("video_id = ? AND language = ?", videoId, language).Delete(&domain.Subtitle{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return domain.ErrSubtitleNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	repo.Cache.Delete(videoId)
+	return nil
+}
+
+var SubtitleRepoModule = fx.Module("subtitle-repository", fx.Provide(NewSubtitleRepository))
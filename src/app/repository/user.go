@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type UserRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewUserRepository(db *gorm.DB, logger *zap.Logger) *UserRepository {
+	return &UserRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+func (repo *UserRepository) GetById(ctx context.Context, id string) (*domain.User, error) {
+	var user domain.User
+
+	if err := repo.DB.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUnauthorized
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (repo *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	var user domain.User
+
+	if err := repo.DB.WithContext(ctx).First(&user, "email = ?", email).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (repo *UserRepository) Insert(ctx context.Context, user *domain.User) (string, error) {
+	if err := repo.DB.WithContext(ctx).Create(user).Error; err != nil {
+		if isUniqueViolation(err) {
+			return "", domain.ErrUserExists
+		}
+		return "", err
+	}
+	return user.ID, nil
+}
+
+func (repo *UserRepository) CountAdmins(ctx context.Context) (int64, error) {
+	var count int64
+
+	err := repo.DB.WithContext(ctx).
+		Model(&domain.User{}).
+		Where("role = ?", string(domain.RoleAdmin)).
+		Count(&count).Error
+	return count, err
+}
+
+func (repo *UserRepository) InsertRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	return repo.DB.WithContext(ctx).Create(token).Error
+}
+
+func (repo *UserRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+
+	if err := repo.DB.WithContext(ctx).First(&token, "token_hash = ?", tokenHash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeRefreshToken returns ErrInvalidToken when the token was already
+// revoked, so concurrent refreshes cannot both succeed.
+func (repo *UserRepository) RevokeRefreshToken(ctx context.Context, id string) error {
+	res := repo.DB.WithContext(ctx).
+		Model(&domain.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrInvalidToken
+	}
+	return nil
+}
+
+func (repo *UserRepository) RevokeAllRefreshTokens(ctx context.Context, userId string) error {
+	return repo.DB.WithContext(ctx).
+		Model(&domain.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", time.Now().UTC()).Error
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+var UserRepoModule = fx.Module("user-repository", fx.Provide(NewUserRepository))
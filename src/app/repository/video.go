@@ -1,10 +1,14 @@
 package repository
 
 import (
+	"awesomeProject/src/app/bus"
 	"awesomeProject/src/app/cache"
+	"awesomeProject/src/app/config"
 	"awesomeProject/src/app/domain"
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/fx"
@@ -14,70 +18,283 @@ import (
 )
 
 type VideoRepository struct {
-	Cache  *cache.VideoCache
-	DB     *gorm.DB
-	Logger *zap.Logger
+	Cache          *cache.VideoCache
+	DB             *gorm.DB
+	Logger         *zap.Logger
+	SearchLanguage string
 }
 
-func NewVideoRepository(db *gorm.DB, logger *zap.Logger, cache *cache.VideoCache) *VideoRepository {
+// nextVersion is written with every change to a video row, so the version
+// (and the ETag derived from it) follows the stored representation.
+var nextVersion = gorm.Expr("version + 1")
+
+func orderedChapters(db *gorm.DB) *gorm.DB {
+	return db.Order("start_ms, created_at")
+}
+
+// subtitleTracks loads the tracks of videos without their content, which is
+// only read when a rendition is served.
+func subtitleTracks(db *gorm.DB) *gorm.DB {
+	return db.Omit("content").Order("language")
+}
+
+func NewVideoRepository(db *gorm.DB, logger *zap.Logger, cache *cache.VideoCache, cfg *config.Config) *VideoRepository {
 	return &VideoRepository{
-		DB:     db,
-		Logger: logger,
-		Cache:  cache,
+		DB:             db,
+		Logger:         logger,
+		Cache:          cache,
+		SearchLanguage: cfg.Search.Language,
 	}
 }
 
-func (repo *VideoRepository) GetAll(ctx context.Context, pagination domain.Pagination, status domain.ListFilter) (domain.ListPayload[domain.Video], error) {
+func (repo *VideoRepository) GetAll(ctx context.Context, pagination domain.Pagination, spec domain.VideoQuery) (domain.ListPayload[domain.Video], error) {
 	var result []domain.Video
-	var total int64
+	var payload domain.ListPayload[domain.Video]
+
+	query := repo.applyFilters(repo.DB.WithContext(ctx).Model(&domain.Video{}), spec)
+
+	if pagination.WithTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return payload, err
+		}
+		payload.TotalCount = &total
+	}
+
+	sortSQL, sortVars := repo.sortExpression(spec)
+
+	if pagination.IsCursor() {
+		if *pagination.Cursor != "" {
+			cursor, err := domain.DecodeCursor(*pagination.Cursor, spec)
+			if err != nil {
+				return payload, err
+			}
+			value, err := cursor.QueryValue()
+			if err != nil {
+				return payload, err
+			}
+			op := ">"
+			if spec.Desc {
+				op = "<"
+			}
+			vars := append(append([]any{}, sortVars...), value, cursor.ID)
+			query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?::uuid)", sortSQL, op), vars...)
+		}
+		query = query.Limit(int(pagination.Limit) + 1)
+	} else {
+		query = query.Limit(int(pagination.Limit)).Offset(int(pagination.Offset))
+	}
 
-	query := repo.DB.WithContext(ctx).Model(&domain.Video{})
+	query = query.Preload("Tags").Preload("Chapters", orderedChapters).Preload("Subtitles", subtitleTracks)
+
+	if spec.Search != "" {
+		query = query.
+			Select(`videos.*,
+				ts_rank_cd(search_vector, websearch_to_tsquery(?::regconfig, ?)) AS search_rank,
+				`+searchSnippet(`ts_headline(?::regconfig, translate(concat_ws(' ', title, description, tags_text), `+snippetStart+` || `+snippetStop+`, ''),
+					websearch_to_tsquery(?::regconfig, ?),
+					'StartSel=' || `+snippetStart+` || ', StopSel=' || `+snippetStop+` || ', MaxWords=35, MinWords=15, MaxFragments=2')`)+` AS search_snippet`,
+				repo.SearchLanguage, spec.Search, repo.SearchLanguage, repo.SearchLanguage, spec.Search)
+	}
+
+	dir := "ASC"
+	if spec.Desc {
+		dir = "DESC"
+	}
+	query = query.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:  fmt.Sprintf("%s %s, id %s", sortSQL, dir, dir),
+		Vars: sortVars,
+	}})
+
+	if err := query.Find(&result).Error; err != nil {
+		return payload, err
+	}
+
+	if pagination.IsCursor() && len(result) > int(pagination.Limit) {
+		result = result[:pagination.Limit]
+		payload.NextCursor = domain.NewCursor(spec, result[len(result)-1]).Encode()
+	}
+
+	payload.Data = result
+	return payload, nil
+}
 
-	switch status {
+// GetIds returns the ids of videos matching spec, at most limit of them.
+func (repo *VideoRepository) GetIds(ctx context.Context, spec domain.VideoQuery, limit int) ([]string, error) {
+	var ids []string
+
+	err := repo.applyFilters(repo.DB.WithContext(ctx).Model(&domain.Video{}), spec).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (repo *VideoRepository) sortExpression(spec domain.VideoQuery) (string, []any) {
+	if spec.Sort == domain.SortRelevance {
+		return "ts_rank_cd(search_vector, websearch_to_tsquery(?::regconfig, ?))", []any{repo.SearchLanguage, spec.Search}
+	}
+	return spec.SortColumn(), nil
+}
+
+func (repo *VideoRepository) applyFilters(query *gorm.DB, spec domain.VideoQuery) *gorm.DB {
+	switch spec.Archive {
 	case domain.FilterActive:
 		query = query.Where("archived_at IS NULL")
 	case domain.FilterArchived:
 		query = query.Where("archived_at IS NOT NULL")
 	}
 
-	if err := query.Count(&total).Error; err != nil {
-		return domain.ListPayload[domain.Video]{}, err
+	if spec.OwnerID != nil {
+		query = query.Where("owner_id = ?", *spec.OwnerID)
+	}
+	if len(spec.Visibility) > 0 {
+		query = query.Where("visibility IN ?", spec.Visibility)
+	}
+	if len(spec.Statuses) > 0 {
+		query = query.Where("status IN ?", spec.Statuses)
+	}
+	if spec.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *spec.CreatedFrom)
+	}
+	if spec.CreatedTo != nil {
+		query = query.Where("created_at <= ?", *spec.CreatedTo)
+	}
+	if spec.MinDurationS != nil {
+		query = query.Where("duration_s >= ?", *spec.MinDurationS)
+	}
+	if spec.MaxDurationS != nil {
+		query = query.Where("duration_s <= ?", *spec.MaxDurationS)
+	}
+	if spec.MinSizeBytes != nil {
+		query = query.Where("size_bytes >= ?", *spec.MinSizeBytes)
+	}
+	if spec.MaxSizeBytes != nil {
+		query = query.Where("size_bytes <= ?", *spec.MaxSizeBytes)
+	}
+	if len(spec.Tags) > 0 {
+		query = query.Where(`id IN (
+			SELECT vt.video_id FROM video_tags vt
+			JOIN tags t ON t.id = vt.tag_id
+			WHERE t.name IN ?
+			GROUP BY vt.video_id
+			HAVING COUNT(DISTINCT t.id) = ?)`, spec.Tags, len(spec.Tags))
+	}
+	if spec.Search != "" {
+		query = query.Where("search_vector @@ websearch_to_tsquery(?::regconfig, ?)", repo.SearchLanguage, spec.Search)
 	}
 
-	query = query.Limit(int(pagination.Limit)).Offset(int(pagination.Offset)).Order("created_at DESC")
+	return query
+}
 
-	if err := query.Find(&result).Error; err != nil {
-		return domain.ListPayload[domain.Video]{}, err
+// UpdateById applies updates and bumps the row version. A non-zero version
+// makes the update conditional on it; ErrVersionMismatch is returned when the
+// row was changed in the meantime.
+func (repo *VideoRepository) UpdateById(ctx context.Context, id string, updates map[string]interface{}, tags *[]string, version int) (*domain.Video, error) {
+	err := repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, id, updates, version); err != nil {
+			return err
+		}
+
+		if tags != nil {
+			return replaceTags(tx, id, *tags)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return domain.ListPayload[domain.Video]{Data: result, TotalCount: total}, nil
+	repo.Cache.Delete(id)
+
+	return repo.GetById(ctx, id)
 }
 
-func (repo *VideoRepository) UpdateById(ctx context.Context, id string, updates map[string]interface{}) (*domain.Video, error) {
-	var video domain.Video
+// ReplaceChapters swaps all chapters of the video for chapters. Like
+// UpdateById it bumps the version and honors a non-zero expected version.
+func (repo *VideoRepository) ReplaceChapters(ctx context.Context, id string, chapters []domain.Chapter, version int) (*domain.Video, error) {
+	err := repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, id, nil, version); err != nil {
+			return err
+		}
+		if err := tx.Where("video_id = ?", id).Delete(&domain.Chapter{}).Error; err != nil {
+			return err
+		}
+		if len(chapters) == 0 {
+			return nil
+		}
+		for i := range chapters {
+			chapters[i].ID = ""
+			chapters[i].VideoID = id
+		}
+		return tx.Create(&chapters).Error
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	res := repo.DB.WithContext(ctx).
-		Model(&video).
-		Where("id = ?", id).
-		Clauses(clause.Returning{}).
-		Updates(updates)
+	repo.Cache.Delete(id)
+
+	return repo.GetById(ctx, id)
+}
 
+// ts_headline marks matches with these control characters rather than with
+// HTML, so that it parses the raw text and the snippet can be escaped
+// afterwards. They are removed from the text beforehand.
+const (
+	snippetStart = "chr(2)"
+	snippetStop  = "chr(3)"
+)
+
+// searchSnippet escapes the output of ts_headline for embedding in HTML and
+// turns the match markers into <mark> elements.
+func searchSnippet(headline string) string {
+	return fmt.Sprintf("replace(replace(%s, %s, '<mark>'), %s, '</mark>')", escapeHTML(headline), snippetStart, snippetStop)
+}
+
+func escapeHTML(expr string) string {
+	for _, r := range [][2]string{{"&", "&amp;"}, {"<", "&lt;"}, {">", "&gt;"}, {`"`, "&quot;"}, {"''", "&#39;"}} {
+		expr = fmt.Sprintf("replace(%s, '%s', '%s')", expr, r[0], r[1])
+	}
+	return expr
+}
+
+func updateVersioned(tx *gorm.DB, id string, updates map[string]interface{}, version int) error {
+	values := map[string]interface{}{"version": nextVersion}
+	for column, value := range updates {
+		values[column] = value
+	}
+
+	query := tx.Model(&domain.Video{}).Where("id = ?", id)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+	res := query.Updates(values)
 	if res.Error != nil {
-		return nil, res.Error
+		return res.Error
 	}
 	if res.RowsAffected == 0 {
-		return nil, domain.ErrVideoNotFound
+		return missingOrMismatch(tx, id)
 	}
+	return bus.Notify(tx, bus.Message{Topic: bus.TopicVideoChanged, VideoID: id})
+}
 
-	repo.Cache.Delete(id)
-
-	return &video, nil
+// missingOrMismatch explains why a versioned statement matched no row.
+func missingOrMismatch(tx *gorm.DB, id string) error {
+	var count int64
+	if err := tx.Model(&domain.Video{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return domain.ErrVersionMismatch
+	}
+	return domain.ErrVideoNotFound
 }
 
 func (repo *VideoRepository) GetBySlug(ctx context.Context, slug string) (*domain.Video, error) {
 	var video domain.Video
 
-	if err := repo.DB.WithContext(ctx).First(&video, "slug = ?", slug).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).Preload("Tags").Preload("Chapters", orderedChapters).Preload("Subtitles", subtitleTracks).First(&video, "slug = ?", slug).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, domain.ErrVideoNotFound
 		}
@@ -87,71 +304,107 @@ func (repo *VideoRepository) GetBySlug(ctx context.Context, slug string) (*domai
 }
 
 func (repo *VideoRepository) Insert(ctx context.Context, video *domain.Video) (string, error) {
-	if err := repo.DB.WithContext(ctx).Create(&video).Error; err != nil {
+	tags := domain.TagNames(video.Tags)
+
+	if video.SearchLanguage == "" {
+		video.SearchLanguage = repo.SearchLanguage
+	}
+
+	err := repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tags").Create(video).Error; err != nil {
+			return err
+		}
+		revision := &domain.VideoRevision{
+			VideoID:   video.ID,
+			Number:    1,
+			Filename:  video.Filename,
+			SizeBytes: video.SizeBytes,
+			DurationS: video.DurationS,
+			Status:    domain.RevisionPending,
+			CreatedBy: video.OwnerID,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return replaceTags(tx, video.ID, tags)
+	})
+	if err != nil {
 		return "", err
 	}
 
 	return video.ID, nil
 }
 
-func (repo *VideoRepository) SetProcessing(ctx context.Context, id string, time time.Time) error {
-	res := repo.DB.WithContext(ctx).
-		Model(&domain.Video{}).
-		Where("id = ?", id).
-		Update("status", domain.StatusProcessing).
-		Update("processing_started_at", time)
+func replaceTags(tx *gorm.DB, videoId string, names []string) error {
+	tags := make([]domain.Tag, 0, len(names))
 
-	if res.Error != nil {
-		return res.Error
+	if len(names) > 0 {
+		for _, name := range names {
+			tags = append(tags, domain.Tag{Name: name})
+		}
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+			Create(&tags).Error; err != nil {
+			return err
+		}
+		tags = tags[:0]
+		if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+			return err
+		}
 	}
-	if res.RowsAffected == 0 {
-		return domain.ErrVideoNotFound
+
+	if err := tx.Model(&domain.Video{ID: videoId}).Association("Tags").Replace(tags); err != nil {
+		return err
 	}
-	repo.Cache.Delete(id)
-	return nil
+
+	return tx.Model(&domain.Video{}).
+		Where("id = ?", videoId).
+		Update("tags_text", strings.Join(names, " ")).Error
 }
 
-func (repo *VideoRepository) SetReady(ctx context.Context, id string, time time.Time) error {
-	updates := map[string]any{
-		"status":       string(domain.StatusComplete),
-		"hls_ready_at": time,
-	}
+// The conversion states below only apply to videos that are not archived;
+// an archive that lands while a job is queued or running wins, and
+// ErrVideoArchived is returned.
 
-	res := repo.DB.WithContext(ctx).
-		Model(&domain.Video{}).
-		Where("id = ?", id).
-		Updates(updates)
+func (repo *VideoRepository) SetProcessing(ctx context.Context, id string, time time.Time) error {
+	return repo.transition(ctx, id, domain.VideoEventProcessing, domain.ErrVideoArchived, map[string]any{
+		"status":                string(domain.StatusProcessing),
+		"processing_started_at": time,
+		"version":               nextVersion,
+	}, "archived_at IS NULL")
+}
 
-	if res.Error != nil {
-		return res.Error
-	}
-	if res.RowsAffected == 0 {
-		return domain.ErrVideoNotFound
-	}
-	repo.Cache.Delete(id)
-	return nil
+func (repo *VideoRepository) SetReady(ctx context.Context, id string, time time.Time, convertedSize *int64) error {
+	return repo.transition(ctx, id, domain.VideoEventReady, domain.ErrVideoArchived, map[string]any{
+		"status":               string(domain.StatusComplete),
+		"hls_ready_at":         time,
+		"converted_size_bytes": convertedSize,
+		"version":              nextVersion,
+	}, "archived_at IS NULL")
 }
 
 func (repo *VideoRepository) SetInterrupted(ctx context.Context, id string, reason error) error {
-	updates := map[string]any{
+	return repo.transition(ctx, id, domain.VideoEventFailed, domain.ErrVideoArchived, map[string]any{
 		"status":         string(domain.StatusInterrupted),
 		"failure_reason": reason.Error(),
 		"retry_attempt":  gorm.Expr("retry_attempt + 1"),
-	}
+		"version":        nextVersion,
+	}, "archived_at IS NULL")
+}
 
-	res := repo.DB.WithContext(ctx).
-		Model(&domain.Video{}).
-		Where("id = ?", id).
-		Updates(updates)
+// SetReprocessFailed records a failed reprocess. The video goes back to
+// complete, as its previous output is still in place and being served.
+func (repo *VideoRepository) SetReprocessFailed(ctx context.Context, id string, reason error) error {
+	return repo.transition(ctx, id, domain.VideoEventFailed, domain.ErrVideoArchived, map[string]any{
+		"status":         string(domain.StatusComplete),
+		"failure_reason": reason.Error(),
+		"version":        nextVersion,
+	}, "archived_at IS NULL")
+}
 
-	if res.Error != nil {
-		return res.Error
-	}
-	if res.RowsAffected == 0 {
-		return domain.ErrVideoNotFound
-	}
-	repo.Cache.Delete(id)
-	return nil
+// RecordProgress publishes how far the conversion of a video has come,
+// between 0 and 1. Progress is not stored on the video itself.
+func (repo *VideoRepository) RecordProgress(ctx context.Context, id string, progress float64) error {
+	return recordEvent(repo.DB.WithContext(ctx), id, domain.VideoEventProgress, &progress)
 }
 
 func (repo *VideoRepository) GetById(ctx context.Context, id string) (*domain.Video, error) {
@@ -162,7 +415,7 @@ func (repo *VideoRepository) GetById(ctx context.Context, id string) (*domain.Vi
 	}
 
 	var video domain.Video
-	if err := repo.DB.WithContext(ctx).First(&video, "id = ?", id).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).Preload("Tags").Preload("Chapters", orderedChapters).Preload("Subtitles", subtitleTracks).First(&video, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, domain.ErrVideoNotFound
 		}
@@ -176,17 +429,212 @@ func (repo *VideoRepository) GetById(ctx context.Context, id string) (*domain.Vi
 
 }
 
-func (repo *VideoRepository) Archive(ctx context.Context, id string) error {
-	archivedAt := time.Now().UTC()
+// Archive marks the video archived. A non-zero version makes it conditional
+// on the stored version.
+func (repo *VideoRepository) Archive(ctx context.Context, id string, version int) error {
+	conds := []any{"archived_at IS NULL"}
+	if version > 0 {
+		conds = []any{"archived_at IS NULL AND version = ?", version}
+	}
+	err := repo.transition(ctx, id, domain.VideoEventArchived, errUnmatched, map[string]any{
+		"archived_at": time.Now().UTC(),
+		"status":      string(domain.StatusArchived),
+		"version":     nextVersion,
+	}, conds...)
+	if errors.Is(err, errUnmatched) {
+		return repo.archiveConflict(ctx, id)
+	}
+	return err
+}
 
-	if err := repo.DB.WithContext(ctx).Model(&domain.Video{}).Where("id = ?", id).Updates(map[string]interface{}{"archived_at": archivedAt, "status": string(domain.StatusArchived)}).Error; err != nil {
+// archiveConflict explains why Archive matched no row.
+func (repo *VideoRepository) archiveConflict(ctx context.Context, id string) error {
+	var video domain.Video
+	if err := repo.DB.WithContext(ctx).Select("id", "archived_at").First(&video, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return domain.ErrVideoNotFound
 		}
 		return err
 	}
+	if video.ArchivedAt != nil {
+		return domain.ErrAlreadyArchived
+	}
+	return domain.ErrVersionMismatch
+}
+
+func (repo *VideoRepository) Restore(ctx context.Context, id string, status domain.VideoStatus) error {
+	return repo.transition(ctx, id, domain.VideoEventRestored, domain.ErrVideoNotArchived, map[string]any{
+		"archived_at": nil,
+		"status":      string(status),
+		"version":     nextVersion,
+	}, "archived_at IS NOT NULL")
+}
+
+// SwitchRevision points the video at revision in one update, so readers see
+// either the old or the new source together with its output.
+func (repo *VideoRepository) SwitchRevision(ctx context.Context, revision *domain.VideoRevision) error {
+	return repo.transition(ctx, revision.VideoID, domain.VideoEventReady, domain.ErrVideoArchived, map[string]any{
+		"revision":             revision.Number,
+		"filename":             revision.Filename,
+		"size_bytes":           revision.SizeBytes,
+		"duration_s":           revision.DurationS,
+		"status":               string(domain.StatusComplete),
+		"hls_ready_at":         revision.ReadyAt,
+		"converted_size_bytes": revision.ConvertedSizeBytes,
+		"failure_reason":       nil,
+		"version":              nextVersion,
+	}, "archived_at IS NULL")
+}
+
+// errUnmatched lets a caller of transition work out itself why no row matched.
+var errUnmatched = errors.New("no video matched the transition")
+
+// transition applies a status change to a video and records event with it.
+// unmatched is returned when no row satisfies the id and conditions.
+func (repo *VideoRepository) transition(ctx context.Context, id string, event domain.VideoEventType, unmatched error, updates map[string]any, conds ...any) error {
+	err := repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return applyTransition(tx, id, event, unmatched, updates, conds...)
+	})
+	if err != nil {
+		return err
+	}
 	repo.Cache.Delete(id)
 	return nil
 }
 
+func applyTransition(tx *gorm.DB, id string, event domain.VideoEventType, unmatched error, updates map[string]any, conds ...any) error {
+	query := tx.Model(&domain.Video{}).Where("id = ?", id)
+	if len(conds) > 0 {
+		query = query.Where(conds[0], conds[1:]...)
+	}
+	res := query.Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return unmatched
+	}
+	if err := bus.Notify(tx, bus.Message{Topic: bus.TopicVideoChanged, VideoID: id}); err != nil {
+		return err
+	}
+	return recordEvent(tx, id, event, nil)
+}
+
+// GetArchivedBefore pages through the videos archived before cutoff in id
+// order, starting after afterID; an empty afterID starts at the beginning.
+func (repo *VideoRepository) GetArchivedBefore(ctx context.Context, cutoff time.Time, afterID string, limit int) ([]domain.Video, error) {
+	var videos []domain.Video
+
+	query := repo.DB.WithContext(ctx).
+		Where("archived_at IS NOT NULL AND archived_at < ?", cutoff)
+	if afterID != "" {
+		query = query.Where("id > ?::uuid", afterID)
+	}
+	if err := query.
+		Order("id ASC").
+		Limit(limit).
+		Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+func (repo *VideoRepository) GetAllUnpaged(ctx context.Context) ([]domain.Video, error) {
+	var videos []domain.Video
+
+	if err := repo.DB.WithContext(ctx).Order("created_at ASC").Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// Delete removes the video. A non-zero version makes it conditional on the
+// stored version.
+func (repo *VideoRepository) Delete(ctx context.Context, id string, version int) error {
+	err := repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("id = ?", id)
+		if version > 0 {
+			query = query.Where("version = ?", version)
+		}
+		res := query.Delete(&domain.Video{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return missingOrMismatch(tx, id)
+		}
+		return bus.Notify(tx, bus.Message{Topic: bus.TopicVideoChanged, VideoID: id})
+	})
+	if err != nil {
+		return err
+	}
+	repo.Cache.Delete(id)
+	return nil
+}
+
+type statusStatsRow struct {
+	Status         string
+	Count          int64
+	RawBytes       int64
+	ConvertedBytes int64
+	ArchivedBytes  int64
+	DurationS      int64
+}
+
+func (repo *VideoRepository) StorageStats(ctx context.Context, top int) (*domain.StorageStats, error) {
+	var rows []statusStatsRow
+
+	err := repo.DB.WithContext(ctx).
+		Model(&domain.Video{}).
+		Select(`status,
+			COUNT(*) AS count,
+			COALESCE(SUM(CASE WHEN archived_at IS NULL THEN size_bytes ELSE 0 END), 0) AS raw_bytes,
+			COALESCE(SUM(converted_size_bytes), 0) AS converted_bytes,
+			COALESCE(SUM(CASE WHEN archived_at IS NOT NULL THEN size_bytes ELSE 0 END), 0) AS archived_bytes,
+			COALESCE(SUM(duration_s), 0) AS duration_s`).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &domain.StorageStats{
+		Raw:       domain.NewStorageUsage(),
+		Converted: domain.NewStorageUsage(),
+		Archived:  domain.NewStorageUsage(),
+		Counts:    map[string]int64{},
+		Largest:   []domain.VideoStorageEntry{},
+	}
+	for _, row := range rows {
+		stats.Counts[row.Status] = row.Count
+		stats.TotalCount += row.Count
+		stats.TotalDurationS += row.DurationS
+
+		stats.Raw.ByStatus[row.Status] = row.RawBytes
+		stats.Raw.TotalBytes += row.RawBytes
+		stats.Converted.ByStatus[row.Status] = row.ConvertedBytes
+		stats.Converted.TotalBytes += row.ConvertedBytes
+		stats.Archived.ByStatus[row.Status] = row.ArchivedBytes
+		stats.Archived.TotalBytes += row.ArchivedBytes
+	}
+
+	if top <= 0 {
+		return stats, nil
+	}
+
+	err = repo.DB.WithContext(ctx).
+		Model(&domain.Video{}).
+		Select(`id, filename, slug, status, size_bytes,
+			converted_size_bytes,
+			size_bytes + COALESCE(converted_size_bytes, 0) AS total_bytes`).
+		Order("total_bytes DESC").
+		Limit(top).
+		Scan(&stats.Largest).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
 var VideoRepoModule = fx.Module("video-repository", fx.Provide(NewVideoRepository))
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const deliveryLogLimit = 100
+
+type WebhookRepository struct {
+	DB     *gorm.DB
+	Logger *zap.Logger
+}
+
+func NewWebhookRepository(db *gorm.DB, logger *zap.Logger) *WebhookRepository {
+	return &WebhookRepository{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+func (repo *WebhookRepository) GetAll(ctx context.Context) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+
+	if err := repo.DB.WithContext(ctx).Order("created_at DESC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (repo *WebhookRepository) GetById(ctx context.Context, id string) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+
+	if err := repo.DB.WithContext(ctx).First(&webhook, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetSubscribed returns the active webhooks that receive event.
+func (repo *WebhookRepository) GetSubscribed(ctx context.Context, event domain.WebhookEvent) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+
+	err := repo.DB.WithContext(ctx).
+		Where("active AND (cardinality(events) = 0 OR ? = ANY(events))", string(event)).
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (repo *WebhookRepository) Insert(ctx context.Context, webhook *domain.Webhook) error {
+	return repo.DB.WithContext(ctx).Create(webhook).Error
+}
+
+func (repo *WebhookRepository) Update(ctx context.Context, id string, updates map[string]any) (*domain.Webhook, error) {
+	updates["updated_at"] = time.Now().UTC()
+
+	res := repo.DB.WithContext(ctx).Model(&domain.Webhook{}).Where("id = ?", id).Updates(updates)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, domain.ErrWebhookNotFound
+	}
+	return repo.GetById(ctx, id)
+}
+
+func (repo *WebhookRepository) Delete(ctx context.Context, id string) error {
+	res := repo.DB.WithContext(ctx).Delete(&domain.Webhook{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of a webhook, newest first.
+func (repo *WebhookRepository) GetDeliveries(ctx context.Context, webhookId string) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+
+	err := repo.DB.WithContext(ctx).
+		Where("webhook_id = ?", webhookId).
+		Order("created_at DESC").
+		Limit(deliveryLogLimit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (repo *WebhookRepository) GetDelivery(ctx context.Context, webhookId, id string) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+
+	err := repo.DB.WithContext(ctx).First(&delivery, "id = ? AND webhook_id = ?", id, webhookId).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (repo *WebhookRepository) InsertDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return repo.DB.WithContext(ctx).Omit("Webhook").Create(&deliveries).Error
+}
+
+// ClaimDue leases up to limit due deliveries by moving their next attempt
+// past lease, so that other instances skip them while they are being sent.
+// A delivery whose sender dies is picked up again once the lease expires.
+func (repo *WebhookRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+
+	err := repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		err := tx.Model(&domain.WebhookDelivery{}).
+			Where("status = ? AND next_attempt_at <= ?", domain.DeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		err = tx.Model(&domain.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Preload("Webhook").Where("id IN ?", ids).Order("next_attempt_at").Find(&deliveries).Error
+	})
+	return deliveries, err
+}
+
+func (repo *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return repo.DB.WithContext(ctx).
+		Model(&domain.WebhookDelivery{}).
+		Where("id = ?", delivery.ID).
+		Updates(map[string]any{
+			"status":           delivery.Status,
+			"attempts":         delivery.Attempts,
+			"next_attempt_at":  delivery.NextAttemptAt,
+			"last_status_code": delivery.LastStatusCode,
+			"last_error":       delivery.LastError,
+			"response_body":    delivery.ResponseBody,
+			"delivered_at":     delivery.DeliveredAt,
+		}).Error
+}
+
+var WebhookRepoModule = fx.Module("webhook-repository", fx.Provide(NewWebhookRepository))
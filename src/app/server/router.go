@@ -1,7 +1,10 @@
 package server
 
 import (
+	"awesomeProject/src/app/domain"
 	"awesomeProject/src/app/handler"
+	"awesomeProject/src/app/middleware"
+	"awesomeProject/src/app/web"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
@@ -15,21 +18,95 @@ type RouterParams struct {
 	HelloHandler *handler.HelloHandler
 	VideoHandler *handler.VideoHandler
 	MediaHandler *handler.MediaHandler
+	StatsHandler *handler.StatsHandler
+	AuthHandler  *handler.AuthHandler
+	KeyHandler   *handler.APIKeyHandler
+	ShareHandler *handler.ShareHandler
+	DocsHandler  *handler.DocsHandler
+	BulkHandler  *handler.BulkHandler
+
+	RevisionHandler  *handler.RevisionHandler
+	SubtitleHandler  *handler.SubtitleHandler
+	AnalyticsHandler *handler.AnalyticsHandler
+	WebhookHandler   *handler.WebhookHandler
+	EventHandler     *handler.EventHandler
+
+	CollectionHandler *handler.CollectionHandler
+
+	Auth    *middleware.AuthMiddleware
+	OpenAPI *middleware.OpenAPIValidator
+	Errors  *middleware.ErrorRenderer
+
+	Idempotency *middleware.Idempotency
 }
 
 func NewRouter(p RouterParams) *gin.Engine {
 
 	r := gin.New()
-	r.Use(gin.Logger(), gin.Recovery())
+	r.Use(gin.Logger(), gin.Recovery(), p.OpenAPI.Validate, p.Errors.Render)
+	r.SetHTMLTemplate(web.Templates)
+
+	public := r.Group("/api")
+	public.GET("/hello", p.HelloHandler.Hello)
+	public.GET("/openapi.json", p.DocsHandler.GetSpec)
+	public.GET("/docs", p.DocsHandler.GetDocs)
+	public.POST("/auth/login", p.AuthHandler.Login)
+	public.POST("/auth/refresh", p.AuthHandler.Refresh)
+
+	share := r.Group(handler.SharePath, p.Auth.OptionalAuth)
+	share.GET("", p.ShareHandler.GetPublicVideos)
+	share.GET("/:slug", p.ShareHandler.GetSharedVideo)
+	r.StaticFS(handler.ShareAssetsPath, web.Static())
+
+	api := r.Group("/api", p.Auth.RequireAuth, p.Idempotency.Handle)
+	api.GET("/auth/me", p.AuthHandler.Me)
+
+	read := p.Auth.RequireScope(domain.ScopeVideoRead)
+	write := p.Auth.RequireScope(domain.ScopeVideoWrite)
+	archive := p.Auth.RequireScope(domain.ScopeVideoArchive)
+
+	api.GET("/video", read, p.VideoHandler.GetVideos)
+	api.GET("/video/:video_uuid", read, p.VideoHandler.GetVideo)
+	api.POST("/video", write, p.VideoHandler.AddVideo)
+	api.PATCH("/video/:video_uuid", write, p.VideoHandler.UpdateVideo)
+	api.DELETE("/video/:video_uuid", archive, p.VideoHandler.ArchiveVideo)
+	api.GET("/video/:video_uuid/verify", read, p.VideoHandler.VerifyVideo)
+	api.GET("/video/:video_uuid/chapters", read, p.VideoHandler.GetChapters)
+	api.PUT("/video/:video_uuid/chapters", write, p.VideoHandler.PutChapters)
+	api.PUT("/video/:video_uuid/source", write, p.RevisionHandler.ReplaceSource)
+	api.GET("/video/:video_uuid/revisions", read, p.RevisionHandler.GetRevisions)
+	api.POST("/video/:video_uuid/revisions/:revision/rollback", write, p.RevisionHandler.Rollback)
+	api.GET("/video/:video_uuid/subtitles", read, p.SubtitleHandler.GetSubtitles)
+	api.GET("/video/:video_uuid/analytics", read, p.AnalyticsHandler.GetAnalytics)
+	api.GET("/events", read, p.EventHandler.Stream)
+	api.PUT("/video/:video_uuid/subtitles/:language", write, p.SubtitleHandler.PutSubtitle)
+	api.DELETE("/video/:video_uuid/subtitles/:language", write, p.SubtitleHandler.DeleteSubtitle)
+	api.POST("/video/bulk", write, p.BulkHandler.CreateBulkOperation)
+	api.GET("/video/bulk/:bulk_uuid", read, p.BulkHandler.GetBulkOperation)
 
-	api := r.Group("/api")
-	api.GET("/hello", p.HelloHandler.Hello)
+	api.GET("/collections", read, p.CollectionHandler.GetCollections)
+	api.POST("/collections", write, p.CollectionHandler.CreateCollection)
+	api.GET("/collections/:collection_uuid", read, p.CollectionHandler.GetCollection)
+	api.PATCH("/collections/:collection_uuid", write, p.CollectionHandler.UpdateCollection)
+	api.DELETE("/collections/:collection_uuid", write, p.CollectionHandler.DeleteCollection)
+	api.GET("/collections/:collection_uuid/videos", read, p.CollectionHandler.GetCollectionVideos)
+	api.POST("/collections/:collection_uuid/videos", write, p.CollectionHandler.AddVideo)
+	api.PUT("/collections/:collection_uuid/videos", write, p.CollectionHandler.ReorderVideos)
+	api.DELETE("/collections/:collection_uuid/videos/:video_uuid", write, p.CollectionHandler.RemoveVideo)
 
-	api.GET("/video", p.VideoHandler.GetVideos)
-	api.GET("/video/:video_uuid", p.VideoHandler.GetVideo)
-	api.POST("/video", p.VideoHandler.AddVideo)
-	api.PATCH("/video/:video_uuid", p.VideoHandler.UpdateVideo)
-	api.DELETE("/video/:video_uuid", p.VideoHandler.ArchiveVideo)
+	admin := api.Group("", p.Auth.RequireAdmin)
+	admin.POST("/users", p.AuthHandler.CreateUser)
+	admin.GET("/stats/storage", p.StatsHandler.GetStorageStats)
+	admin.GET("/admin/api-keys", p.KeyHandler.GetAPIKeys)
+	admin.POST("/admin/api-keys", p.KeyHandler.CreateAPIKey)
+	admin.DELETE("/admin/api-keys/:key_uuid", p.KeyHandler.RevokeAPIKey)
+	admin.GET("/admin/webhooks", p.WebhookHandler.GetWebhooks)
+	admin.POST("/admin/webhooks", p.WebhookHandler.CreateWebhook)
+	admin.GET("/admin/webhooks/:webhook_uuid", p.WebhookHandler.GetWebhook)
+	admin.PATCH("/admin/webhooks/:webhook_uuid", p.WebhookHandler.UpdateWebhook)
+	admin.DELETE("/admin/webhooks/:webhook_uuid", p.WebhookHandler.DeleteWebhook)
+	admin.GET("/admin/webhooks/:webhook_uuid/deliveries", p.WebhookHandler.GetDeliveries)
+	admin.POST("/admin/webhooks/:webhook_uuid/deliveries/:delivery_uuid/redeliver", p.WebhookHandler.Redeliver)
 
 	p.MediaHandler.Register(r)
 	return r
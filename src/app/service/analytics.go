@@ -0,0 +1,323 @@
+package service
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/hls"
+	"awesomeProject/src/app/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	analyticsBatchSize     = 500
+	analyticsQueueSize     = 4096
+	analyticsSweepInterval = time.Hour
+	maxCachedTimelines     = 1024
+)
+
+// PlaybackHit is a media fetch as seen by the media handler. Hits are queued
+// and turned into events off the request path.
+type PlaybackHit struct {
+	VideoID   string
+	Dir       string
+	File      string
+	Token     string
+	ClientIP  string
+	UserAgent string
+	At        time.Time
+}
+
+// segmentTimeline locates the segments of a media playlist on the timeline.
+type segmentTimeline struct {
+	modTime  time.Time
+	index    map[string]int
+	startMs  []int64
+	lengthMs []int64
+}
+
+type AnalyticsService struct {
+	repo    *repository.AnalyticsRepository
+	videos  *VideoService
+	config  *config.Config
+	log     *zap.Logger
+	enabled bool
+
+	hits      chan PlaybackHit
+	timelines map[string]*segmentTimeline
+
+	runCtx context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewAnalyticsService(cfg *config.Config, repo *repository.AnalyticsRepository, videos *VideoService, logger *zap.Logger) *AnalyticsService {
+	return &AnalyticsService{
+		repo:      repo,
+		videos:    videos,
+		config:    cfg,
+		log:       logger,
+		enabled:   cfg.Analytics.Enabled,
+		hits:      make(chan PlaybackHit, analyticsQueueSize),
+		timelines: make(map[string]*segmentTimeline),
+	}
+}
+
+// Track queues a hit without blocking; hits are dropped while the queue is
+// full rather than slowing down delivery.
+func (svc *AnalyticsService) Track(hit PlaybackHit) {
+	if !svc.enabled {
+		return
+	}
+	select {
+	case svc.hits <- hit:
+	default:
+		svc.log.Debug("analytics queue is full, hit dropped", zap.String("videoId", hit.VideoID))
+	}
+}
+
+func (svc *AnalyticsService) Get(ctx context.Context, principal domain.Principal, id string, r domain.AnalyticsRange) (*domain.VideoAnalytics, error) {
+	video, err := svc.videos.GetVideo(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	from, to := domain.FormatAnalyticsDate(r.From), domain.FormatAnalyticsDate(r.To)
+
+	daily, err := svc.repo.GetDaily(ctx, video.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	viewers, err := svc.repo.CountViewers(ctx, video.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	retention, err := svc.repo.GetRetention(ctx, video.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.VideoAnalytics{
+		VideoID:       video.ID,
+		From:          from,
+		To:            to,
+		UniqueViewers: viewers,
+		Daily:         make([]domain.DailyAnalytics, 0, r.Days()),
+		Retention:     make([]domain.RetentionPoint, 0, len(retention)),
+	}
+
+	byDay := make(map[string]repository.DailyStatsRow, len(daily))
+	for _, row := range daily {
+		byDay[row.Day] = row
+	}
+	for day := r.From; !day.After(r.To); day = day.AddDate(0, 0, 1) {
+		date := domain.FormatAnalyticsDate(day)
+		row := byDay[date]
+		result.Views += row.Views
+		result.WatchTimeS += float64(row.WatchMs) / 1000
+		result.Daily = append(result.Daily, domain.DailyAnalytics{
+			Date:          date,
+			Views:         row.Views,
+			UniqueViewers: row.UniqueViewers,
+			WatchTimeS:    float64(row.WatchMs) / 1000,
+		})
+	}
+	if result.Views > 0 {
+		result.AverageWatchTimeS = result.WatchTimeS / float64(result.Views)
+	}
+
+	for _, row := range retention {
+		point := domain.RetentionPoint{
+			Segment:  row.Segment,
+			StartS:   float64(row.StartMs) / 1000,
+			Sessions: row.Sessions,
+		}
+		if result.Views > 0 {
+			point.Ratio = float64(row.Sessions) / float64(result.Views)
+		}
+		result.Retention = append(result.Retention, point)
+	}
+
+	return result, nil
+}
+
+func (svc *AnalyticsService) Start() {
+	svc.done = make(chan struct{})
+	if !svc.enabled {
+		close(svc.done)
+		return
+	}
+
+	go func() {
+		defer close(svc.done)
+
+		flush := time.NewTicker(svc.config.Analytics.FlushInterval)
+		defer flush.Stop()
+		sweep := time.NewTicker(analyticsSweepInterval)
+		defer sweep.Stop()
+
+		batch := make([]domain.PlaybackEvent, 0, analyticsBatchSize)
+		for {
+			select {
+			case <-svc.runCtx.Done():
+				for len(svc.hits) > 0 {
+					if event, ok := svc.event(<-svc.hits); ok {
+						batch = append(batch, event)
+					}
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				svc.flush(ctx, batch)
+				cancel()
+				return
+			case hit := <-svc.hits:
+				if event, ok := svc.event(hit); ok {
+					batch = append(batch, event)
+				}
+				if len(batch) >= analyticsBatchSize {
+					batch = svc.flush(svc.runCtx, batch)
+				}
+			case <-flush.C:
+				batch = svc.flush(svc.runCtx, batch)
+			case <-sweep.C:
+				svc.sweep()
+			}
+		}
+	}()
+}
+
+func (svc *AnalyticsService) flush(ctx context.Context, batch []domain.PlaybackEvent) []domain.PlaybackEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := svc.repo.Record(ctx, batch); err != nil {
+		svc.log.Error("failed to record playback events", zap.Int("events", len(batch)), zap.Error(err))
+	}
+	return batch[:0]
+}
+
+func (svc *AnalyticsService) sweep() {
+	if svc.config.Analytics.RawRetention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-svc.config.Analytics.RawRetention)
+	n, err := svc.repo.DeleteSessionsBefore(svc.runCtx, cutoff)
+	if err != nil {
+		svc.log.Error("playback session sweep failed", zap.Error(err))
+		return
+	}
+	if n > 0 {
+		svc.log.Info("expired playback sessions removed", zap.Int64("count", n))
+	}
+
+	n, err = svc.repo.DeleteViewersBefore(svc.runCtx, cutoff)
+	if err != nil {
+		svc.log.Error("daily viewer sweep failed", zap.Error(err))
+		return
+	}
+	if n > 0 {
+		svc.log.Info("expired daily viewers removed", zap.Int64("count", n))
+	}
+}
+
+// event attributes a hit to its session and, for segments, to a position
+// on the timeline. Fetches other than playlists and segments are ignored.
+func (svc *AnalyticsService) event(hit PlaybackHit) (domain.PlaybackEvent, bool) {
+	event := domain.PlaybackEvent{
+		VideoID:    hit.VideoID,
+		SessionKey: hashKey(hit.Token),
+		ViewerKey:  hashKey(svc.config.Playback.Secret, hit.ClientIP, hit.UserAgent),
+		Segment:    domain.NoSegment,
+		At:         hit.At,
+	}
+
+	switch {
+	case hit.File == "index.m3u8" || hit.File == hls.MasterPlaylist:
+		return event, true
+	case path.Ext(hit.File) == ".ts":
+		timeline := svc.timeline(hit.Dir)
+		if timeline == nil {
+			return event, false
+		}
+		i, ok := timeline.index[hit.File]
+		if !ok {
+			return event, false
+		}
+		event.Segment, event.StartMs, event.DurationMs = i, timeline.startMs[i], timeline.lengthMs[i]
+		return event, true
+	default:
+		return event, false
+	}
+}
+
+func (svc *AnalyticsService) timeline(dir string) *segmentTimeline {
+	file := filepath.Join(svc.config.Conv.ConvDir, filepath.FromSlash(dir), "index.m3u8")
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil
+	}
+	if cached, ok := svc.timelines[dir]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached
+	}
+
+	playlist, err := hls.ParsePlaylistFile(file)
+	if err != nil {
+		svc.log.Warn("cannot read playlist for analytics", zap.String("path", file), zap.Error(err))
+		return nil
+	}
+
+	timeline := &segmentTimeline{modTime: info.ModTime(), index: make(map[string]int, len(playlist.Segments))}
+	var offset int64
+	for i, s := range playlist.Segments {
+		length := int64(s.Duration * 1000)
+		timeline.index[s.URI] = i
+		timeline.startMs = append(timeline.startMs, offset)
+		timeline.lengthMs = append(timeline.lengthMs, length)
+		offset += length
+	}
+
+	if len(svc.timelines) >= maxCachedTimelines {
+		clear(svc.timelines)
+	}
+	svc.timelines[dir] = timeline
+	return timeline
+}
+
+func hashKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+var AnalyticsModule = fx.Module("analytics_service",
+	fx.Provide(NewAnalyticsService),
+	fx.Invoke(func(lc fx.Lifecycle, as *AnalyticsService) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				as.runCtx, as.cancel = context.WithCancel(context.Background())
+				as.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				if as.cancel == nil {
+					return nil
+				}
+				as.cancel()
+				select {
+				case <-as.done:
+				case <-ctx.Done():
+				}
+				return nil
+			},
+		})
+	}),
+)
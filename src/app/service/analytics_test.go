@@ -0,0 +1,103 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAnalyticsRollup(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.cfg.Playback.Secret = "secret"
+	svc := NewAnalyticsService(env.cfg, repository.NewAnalyticsRepository(env.db, env.log), env.videos, env.log)
+	admin := domain.Principal{Role: domain.RoleAdmin}
+
+	video := env.createVideo(t, domain.Video{Slug: "watched1"})
+	writeFile(t, filepath.Join(convertedDir(env.cfg, video), "index.m3u8"),
+		"#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10.0,\nseg0.ts\n#EXTINF:10.0,\nseg1.ts\n#EXTINF:5.0,\nseg2.ts\n#EXT-X-ENDLIST\n")
+	dir, err := filepath.Rel(env.cfg.Conv.ConvDir, convertedDir(env.cfg, video))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day1 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	events := func(token, ip string, at time.Time, files ...string) []domain.PlaybackEvent {
+		var events []domain.PlaybackEvent
+		for i, file := range files {
+			hit := PlaybackHit{VideoID: video.ID, Dir: filepath.ToSlash(dir), File: file, Token: token, ClientIP: ip, UserAgent: "player", At: at.Add(time.Duration(i) * time.Second)}
+			if event, ok := svc.event(hit); ok {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	var first []domain.PlaybackEvent
+	// a session watching two segments, one of them fetched twice
+	first = append(first, events("a", "10.0.0.1", day1.Add(10*time.Hour), "index.m3u8", "seg0.ts", "seg1.ts", "seg1.ts")...)
+	// the same viewer in another session
+	first = append(first, events("b", "10.0.0.1", day1.Add(11*time.Hour), "index.m3u8", "seg0.ts", "poster.jpg", "seg9.ts")...)
+	// a session that never starts playing is not a view
+	first = append(first, events("c", "10.0.0.2", day1.Add(12*time.Hour), "index.m3u8")...)
+	first = append(first, events("d", "10.0.0.3", day2.Add(9*time.Hour), "seg0.ts", "seg1.ts", "seg2.ts")...)
+	if len(first) != 10 {
+		t.Fatalf("%d events, want 10: fetches other than playlists and known segments are ignored", len(first))
+	}
+	if err := svc.repo.Record(ctx, first); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	// session a goes on in a later batch, seeking back to the start
+	if err := svc.repo.Record(ctx, events("a", "10.0.0.1", day1.Add(10*time.Hour+time.Minute), "seg0.ts", "seg2.ts")); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	analytics, err := svc.Get(ctx, admin, video.ID, domain.AnalyticsRange{From: day1, To: day2})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if analytics.Views != 3 || analytics.UniqueViewers != 2 || analytics.WatchTimeS != 60 || analytics.AverageWatchTimeS != 20 {
+		t.Errorf("totals = %d views by %d viewers watching %vs (%vs each), want 3 by 2 watching 60s (20s each)",
+			analytics.Views, analytics.UniqueViewers, analytics.WatchTimeS, analytics.AverageWatchTimeS)
+	}
+
+	daily := []domain.DailyAnalytics{
+		{Date: "2024-03-01", Views: 2, UniqueViewers: 1, WatchTimeS: 35},
+		{Date: "2024-03-02", Views: 1, UniqueViewers: 1, WatchTimeS: 25},
+	}
+	if len(analytics.Daily) != len(daily) {
+		t.Fatalf("daily = %+v, want %+v", analytics.Daily, daily)
+	}
+	for i, want := range daily {
+		if analytics.Daily[i] != want {
+			t.Errorf("day %d = %+v, want %+v", i, analytics.Daily[i], want)
+		}
+	}
+
+	retention := []domain.RetentionPoint{
+		{Segment: 0, StartS: 0, Sessions: 3, Ratio: 1},
+		{Segment: 1, StartS: 10, Sessions: 2, Ratio: 2.0 / 3},
+		{Segment: 2, StartS: 20, Sessions: 2, Ratio: 2.0 / 3},
+	}
+	if len(analytics.Retention) != len(retention) {
+		t.Fatalf("retention = %+v, want %+v", analytics.Retention, retention)
+	}
+	for i, want := range retention {
+		if analytics.Retention[i] != want {
+			t.Errorf("segment %d = %+v, want %+v", i, analytics.Retention[i], want)
+		}
+	}
+
+	// days without playback are reported empty
+	later, err := svc.Get(ctx, admin, video.ID, domain.AnalyticsRange{From: day2, To: day2.AddDate(0, 0, 1)})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if later.Views != 1 || len(later.Daily) != 2 || later.Daily[1] != (domain.DailyAnalytics{Date: "2024-03-03"}) {
+		t.Errorf("later range = %+v, want one view on 2024-03-02 only", later)
+	}
+}
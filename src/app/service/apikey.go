@@ -0,0 +1,153 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 24
+)
+
+type APIKeyService struct {
+	Repository *repository.APIKeyRepository
+	users      *repository.UserRepository
+	log        *zap.Logger
+}
+
+type CreateAPIKeyParams struct {
+	Name      string
+	Scopes    []string
+	OwnerID   string
+	ExpiresAt *time.Time
+}
+
+func NewAPIKeyService(repo *repository.APIKeyRepository, users *repository.UserRepository, log *zap.Logger) *APIKeyService {
+	return &APIKeyService{
+		Repository: repo,
+		users:      users,
+		log:        log,
+	}
+}
+
+func (svc *APIKeyService) GetAll(ctx context.Context) ([]domain.APIKey, error) {
+	return svc.Repository.GetAll(ctx)
+}
+
+// Create returns the stored key together with its plaintext value, which is
+// never persisted and cannot be recovered later.
+func (svc *APIKeyService) Create(ctx context.Context, params CreateAPIKeyParams) (*domain.APIKey, string, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return nil, "", &domain.ValidationError{Field: "name", Message: "must not be empty"}
+	}
+	scopes, err := domain.ParseScopes(params.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, "", &domain.ValidationError{Field: "expiresAt", Message: "must be in the future"}
+	}
+	if _, err := svc.users.GetById(ctx, params.OwnerID); err != nil {
+		if errors.Is(err, domain.ErrUnauthorized) {
+			return nil, "", &domain.ValidationError{Field: "ownerId", Message: "user does not exist"}
+		}
+		return nil, "", err
+	}
+
+	prefix, err := randomToken(apiKeyPrefixBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomToken(apiKeySecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		names = append(names, string(s))
+	}
+
+	key := &domain.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashToken(secret),
+		Scopes:    names,
+		OwnerID:   params.OwnerID,
+		ExpiresAt: params.ExpiresAt,
+	}
+	if _, err := svc.Repository.Insert(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, domain.APIKeyPrefix + prefix + "_" + secret, nil
+}
+
+func (svc *APIKeyService) Revoke(ctx context.Context, id string) error {
+	return svc.Repository.Revoke(ctx, id)
+}
+
+func (svc *APIKeyService) Authenticate(ctx context.Context, raw string) (domain.Principal, error) {
+	rest, ok := strings.CutPrefix(raw, domain.APIKeyPrefix)
+	if !ok {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+
+	key, err := svc.Repository.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return domain.Principal{}, domain.ErrInvalidToken
+		}
+		return domain.Principal{}, err
+	}
+
+	now := time.Now().UTC()
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashToken(secret))) != 1 || !key.IsActive(now) {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+
+	if err := svc.Repository.TouchLastUsed(ctx, key.ID, now); err != nil {
+		svc.log.Warn("failed to update api key usage", zap.String("id", key.ID), zap.Error(err))
+	}
+
+	principal := domain.Principal{
+		UserID:   key.OwnerID,
+		Role:     domain.RoleUser,
+		APIKeyID: key.ID,
+		Scopes:   make([]domain.Scope, 0, len(key.Scopes)),
+	}
+	for _, s := range key.Scopes {
+		principal.Scopes = append(principal.Scopes, domain.Scope(s))
+		if domain.Scope(s) == domain.ScopeAdmin {
+			principal.Role = domain.RoleAdmin
+		}
+	}
+	return principal, nil
+}
+
+func randomToken(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	// "_" separates prefix from secret, so keep it out of the alphabet
+	return strings.ReplaceAll(base64.RawURLEncoding.EncodeToString(raw), "_", "-"), nil
+}
+
+var APIKeyModule = fx.Module("apikey-service", fx.Provide(NewAPIKeyService))
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"awesomeProject/src/app/auth"
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyPasswordHash is compared against when the email is unknown.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	return hash
+})
+
+type AuthService struct {
+	config *config.Config
+	users  *repository.UserRepository
+	signer *auth.Signer
+	log    *zap.Logger
+}
+
+func NewAuthService(cfg *config.Config, users *repository.UserRepository, signer *auth.Signer, logger *zap.Logger) *AuthService {
+	return &AuthService{
+		config: cfg,
+		users:  users,
+		signer: signer,
+		log:    logger,
+	}
+}
+
+func (svc *AuthService) Login(ctx context.Context, email string, password string) (*domain.TokenPair, error) {
+	email, err := domain.NormalizeEmail(email)
+	if err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	user, err := svc.users.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrInvalidCredentials) {
+		// spend as long as for a known email, so timing does not tell them apart
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	return svc.issueTokens(ctx, user)
+}
+
+func (svc *AuthService) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	token, err := svc.users.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if token.RevokedAt != nil {
+		// a rotated token is being replayed, so the whole chain is suspect
+		svc.log.Warn("refresh token reuse detected", zap.String("user_id", token.UserID))
+		_ = svc.users.RevokeAllRefreshTokens(ctx, token.UserID)
+		return nil, domain.ErrInvalidToken
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, domain.ErrInvalidToken
+	}
+	if err := svc.users.RevokeRefreshToken(ctx, token.ID); err != nil {
+		return nil, err
+	}
+
+	user, err := svc.users.GetById(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return svc.issueTokens(ctx, user)
+}
+
+func (svc *AuthService) Authenticate(ctx context.Context, accessToken string) (domain.Principal, error) {
+	claims, err := svc.signer.Parse(accessToken, time.Now())
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	role, ok := domain.ParseRole(claims.Role)
+	if !ok {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+	return domain.Principal{UserID: claims.Subject, Role: role}, nil
+}
+
+func (svc *AuthService) GetUser(ctx context.Context, id string) (*domain.User, error) {
+	return svc.users.GetById(ctx, id)
+}
+
+func (svc *AuthService) CreateUser(ctx context.Context, email string, password string, role domain.Role) (*domain.User, error) {
+	email, err := domain.NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.ValidatePassword(password); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{
+		Email:        email,
+		PasswordHash: string(hash),
+		Role:         string(role),
+	}
+	if _, err := svc.users.Insert(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (svc *AuthService) EnsureAdmin(ctx context.Context) error {
+	email, password := svc.config.Auth.AdminEmail, svc.config.Auth.AdminPassword
+	if email == "" || password == "" {
+		return nil
+	}
+
+	count, err := svc.users.CountAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if _, err := svc.CreateUser(ctx, email, password, domain.RoleAdmin); err != nil && !errors.Is(err, domain.ErrUserExists) {
+		return err
+	}
+	svc.log.Info("bootstrap admin created", zap.String("email", email))
+	return nil
+}
+
+func (svc *AuthService) issueTokens(ctx context.Context, user *domain.User) (*domain.TokenPair, error) {
+	now := time.Now()
+
+	role, _ := domain.ParseRole(user.Role)
+	access, err := svc.signer.Sign(user.ID, role, now)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := svc.users.InsertRefreshToken(ctx, &domain.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: now.Add(svc.config.Auth.RefreshTTL).UTC(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(svc.signer.TTL().Seconds()),
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+var AuthModule = fx.Module("auth-service",
+	fx.Provide(NewAuthService),
+	fx.Invoke(func(lc fx.Lifecycle, svc *AuthService) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				return svc.EnsureAdmin(ctx)
+			},
+		})
+	}),
+)
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"awesomeProject/src/util"
+	"context"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// bulkRescanInterval is how often operations that did not fit in the queue
+// are picked up from the database again.
+const bulkRescanInterval = 30 * time.Second
+
+// bulkLease is how long a claimed operation is reserved for the process
+// executing it; the lease is extended after every item.
+const bulkLease = 5 * time.Minute
+
+type BulkService struct {
+	Repository *repository.BulkRepository
+	videos     *VideoService
+	log        *zap.Logger
+
+	jobs     chan string
+	deferred atomic.Bool
+
+	runCtx context.Context
+	cancel context.CancelFunc
+}
+
+type CreateBulkParams struct {
+	Operation string
+	Params    domain.BulkParams
+	IDs       []string
+	Filter    url.Values
+}
+
+func NewBulkService(repo *repository.BulkRepository, videos *VideoService, logger *zap.Logger) *BulkService {
+	return &BulkService{
+		Repository: repo,
+		videos:     videos,
+		log:        logger,
+		jobs:       make(chan string, 64),
+	}
+}
+
+// Create resolves the target videos and stores the operation; items are
+// executed in the background and reported through Get.
+func (svc *BulkService) Create(ctx context.Context, principal domain.Principal, params CreateBulkParams) (*domain.BulkOperation, error) {
+	operation, opParams, err := domain.ParseBulkOperation(params.Operation, params.Params)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.HasScope(operation.RequiredScope()) {
+		return nil, domain.ErrForbidden
+	}
+
+	ids, err := svc.resolveTargets(ctx, principal, params)
+	if err != nil {
+		return nil, err
+	}
+
+	op := &domain.BulkOperation{
+		Operation: operation,
+		Params:    opParams,
+		Principal: principal,
+		Status:    domain.BulkQueued,
+		Total:     len(ids),
+	}
+	if principal.UserID != "" {
+		op.CreatedBy = &principal.UserID
+	}
+	for i, id := range ids {
+		op.Items = append(op.Items, domain.BulkItem{VideoID: id, Position: i, Status: domain.BulkItemPending})
+	}
+
+	if err := svc.Repository.Insert(ctx, op); err != nil {
+		return nil, err
+	}
+
+	svc.enqueue(op.ID)
+	return op, nil
+}
+
+func (svc *BulkService) Get(ctx context.Context, principal domain.Principal, id string) (*domain.BulkOperation, error) {
+	op, err := svc.Repository.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.IsAdmin() && (op.CreatedBy == nil || *op.CreatedBy != principal.UserID) {
+		return nil, domain.ErrBulkOperationNotFound
+	}
+	return op, nil
+}
+
+func (svc *BulkService) resolveTargets(ctx context.Context, principal domain.Principal, params CreateBulkParams) ([]string, error) {
+	switch {
+	case len(params.IDs) > 0 && params.Filter != nil:
+		return nil, &domain.ValidationError{Field: "ids", Message: "must not be combined with filter"}
+	case len(params.IDs) > 0:
+		if len(params.IDs) > domain.MaxBulkItems {
+			return nil, &domain.ValidationError{Field: "ids", Message: "too many videos in one operation"}
+		}
+		seen := make(map[string]bool, len(params.IDs))
+		ids := make([]string, 0, len(params.IDs))
+		for _, id := range params.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	case params.Filter != nil:
+		query, err := domain.ParseVideoQuery(params.Filter)
+		if err != nil {
+			return nil, err
+		}
+		if !principal.IsAdmin() {
+			query.OwnerID = &principal.UserID
+		}
+		ids, err := svc.videos.Repository.GetIds(ctx, query, domain.MaxBulkItems+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > domain.MaxBulkItems {
+			return nil, &domain.ValidationError{Field: "filter", Message: "matches too many videos for one operation"}
+		}
+		if len(ids) == 0 {
+			return nil, &domain.ValidationError{Field: "filter", Message: "matches no videos"}
+		}
+		return ids, nil
+	default:
+		return nil, &domain.ValidationError{Field: "ids", Message: "either ids or filter is required"}
+	}
+}
+
+func (svc *BulkService) enqueue(id string) {
+	select {
+	case svc.jobs <- id:
+	default:
+		// the operation is stored, so the next rescan picks it up
+		svc.deferred.Store(true)
+		svc.log.Warn("bulk queue is full, operation deferred", zap.String("id", id))
+	}
+}
+
+func (svc *BulkService) Start() {
+	go func() {
+		svc.runUnfinished()
+
+		rescan := time.NewTicker(bulkRescanInterval)
+		defer rescan.Stop()
+
+		for {
+			select {
+			case <-svc.runCtx.Done():
+				svc.log.Info("bulk executor stopped")
+				return
+			case id := <-svc.jobs:
+				svc.run(id)
+			case <-rescan.C:
+				if svc.deferred.Swap(false) {
+					svc.runUnfinished()
+				}
+			}
+		}
+	}()
+}
+
+// runUnfinished runs the operations left over by a previous process or
+// deferred by a full queue.
+func (svc *BulkService) runUnfinished() {
+	ops, err := svc.Repository.GetUnfinished(svc.runCtx)
+	if err != nil {
+		svc.log.Error("failed to load unfinished bulk operations", zap.Error(err))
+		svc.deferred.Store(true)
+		return
+	}
+	for _, op := range ops {
+		if svc.runCtx.Err() != nil {
+			return
+		}
+		svc.run(op.ID)
+	}
+}
+
+func (svc *BulkService) run(id string) {
+	ctx := svc.runCtx
+
+	op, err := svc.Repository.GetById(ctx, id)
+	if err != nil {
+		svc.log.Error("failed to load bulk operation", zap.String("id", id), zap.Error(err))
+		return
+	}
+	if op.Status == domain.BulkCompleted {
+		return
+	}
+	now := time.Now().UTC()
+	claimed, err := svc.Repository.Claim(ctx, id, now, now.Add(bulkLease))
+	if err != nil {
+		svc.log.Error("failed to start bulk operation", zap.String("id", id), zap.Error(err))
+		return
+	}
+	if !claimed {
+		// held by another process; if that process stopped, the operation
+		// is taken over once its lease runs out
+		if op.Status == domain.BulkRunning {
+			svc.deferred.Store(true)
+		}
+		return
+	}
+
+	items, err := svc.Repository.GetPendingItems(ctx, id)
+	if err != nil {
+		svc.log.Error("failed to load bulk items", zap.String("id", id), zap.Error(err))
+		return
+	}
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return
+		}
+
+		item.Status = domain.BulkItemSucceeded
+		if err := svc.apply(ctx, op, item.VideoID); err != nil {
+			// internal errors are logged rather than exposed on the item
+			problem := util.ProblemFromError(err)
+			if problem.Detail == "" {
+				svc.log.Error("bulk item failed", zap.String("id", id), zap.String("video_id", item.VideoID), zap.Error(err))
+				problem.Detail = problem.Title
+			}
+			item.Status = domain.BulkItemFailed
+			item.Error, item.Code = &problem.Detail, &problem.Code
+		}
+		if err := svc.Repository.FinishItem(ctx, &item); err != nil {
+			svc.log.Error("failed to record bulk item", zap.String("id", id), zap.String("video_id", item.VideoID), zap.Error(err))
+			return
+		}
+		if err := svc.Repository.ExtendLease(ctx, id, time.Now().UTC().Add(bulkLease)); err != nil {
+			svc.log.Warn("failed to extend bulk operation lease", zap.String("id", id), zap.Error(err))
+		}
+	}
+
+	if err := svc.Repository.SetCompleted(ctx, id, time.Now().UTC()); err != nil {
+		svc.log.Error("failed to complete bulk operation", zap.String("id", id), zap.Error(err))
+		return
+	}
+	svc.log.Info("bulk operation finished", zap.String("id", id), zap.String("operation", string(op.Operation)))
+}
+
+func (svc *BulkService) apply(ctx context.Context, op *domain.BulkOperation, videoId string) error {
+	principal := op.Principal
+
+	switch op.Operation {
+	case domain.BulkArchive:
+		return svc.videos.Archive(ctx, principal, videoId, 0)
+	case domain.BulkRestore:
+		return svc.videos.Restore(ctx, principal, videoId)
+	case domain.BulkReprocess:
+		return svc.videos.Reprocess(ctx, principal, videoId)
+	}
+
+	video, err := svc.videos.GetVideo(ctx, principal, videoId)
+	if err != nil {
+		return err
+	}
+	if video.ArchivedAt != nil {
+		return domain.ErrVideoArchived
+	}
+
+	var meta domain.VideoMetadata
+	switch op.Operation {
+	case domain.BulkTag:
+		tags := domain.ApplyTags(domain.TagNames(video.Tags), op.Params.Tags, op.Params.TagMode)
+		meta.Tags = &tags
+	case domain.BulkSetVisibility:
+		meta.Visibility = &op.Params.Visibility
+	}
+
+	// conditional on the version read above so tag merges are not lost
+	_, err = svc.videos.UpdateMetadata(ctx, video.ID, meta, video.Version)
+	return err
+}
+
+var BulkModule = fx.Module("bulk_service",
+	fx.Provide(NewBulkService),
+	fx.Invoke(func(lc fx.Lifecycle, bs *BulkService) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				bs.runCtx, bs.cancel = context.WithCancel(context.Background())
+				bs.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				if bs.cancel != nil {
+					bs.cancel()
+				}
+				return nil
+			},
+		})
+	}),
+)
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+)
+
+// SetChapters replaces the chapters and markers of a video. A non-zero
+// version makes the write conditional on the video not having changed.
+func (service *VideoService) SetChapters(ctx context.Context, principal domain.Principal, id string, chapters []domain.Chapter, version int) (*domain.Video, error) {
+	video, err := service.GetVideo(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if video.ArchivedAt != nil {
+		return nil, domain.ErrVideoArchived
+	}
+	if err := domain.ValidateChapters(chapters, video.DurationS); err != nil {
+		return nil, err
+	}
+
+	updated, err := service.Repository.ReplaceChapters(ctx, video.ID, chapters, version)
+	if err != nil {
+		return nil, err
+	}
+	service.recordAudit(ctx, video, domain.AuditChapters, principal.Actor())
+
+	return updated, nil
+}
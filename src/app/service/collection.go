@@ -0,0 +1,187 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"strings"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type CollectionService struct {
+	Repository *repository.CollectionRepository
+	Videos     *repository.VideoRepository
+	log        *zap.Logger
+}
+
+func NewCollectionService(repo *repository.CollectionRepository, videos *repository.VideoRepository, log *zap.Logger) *CollectionService {
+	return &CollectionService{
+		Repository: repo,
+		Videos:     videos,
+		log:        log,
+	}
+}
+
+func (service *CollectionService) GetAll(ctx context.Context, principal domain.Principal, pagination domain.Pagination) (domain.ListPayload[domain.Collection], error) {
+	var ownerId *string
+	if !principal.IsAdmin() {
+		ownerId = &principal.UserID
+	}
+	return service.Repository.GetAll(ctx, ownerId, pagination)
+}
+
+// Get returns the collection if the caller owns it; collections of other
+// users are reported as missing.
+func (service *CollectionService) Get(ctx context.Context, principal domain.Principal, id string) (*domain.Collection, error) {
+	collection, err := service.Repository.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.CanAccessCollection(collection) {
+		return nil, domain.ErrCollectionNotFound
+	}
+	return collection, nil
+}
+
+func (service *CollectionService) Create(ctx context.Context, principal domain.Principal, title string, description string) (*domain.Collection, error) {
+	title, err := domain.NormalizeTitle(title)
+	if err != nil {
+		return nil, err
+	}
+	description, err = domain.NormalizeDescription(description)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := service.Repository.Insert(ctx, &domain.Collection{
+		Title:       title,
+		Description: description,
+		OwnerID:     &principal.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service.Repository.GetById(ctx, id)
+}
+
+func (service *CollectionService) Update(ctx context.Context, principal domain.Principal, id string, update domain.CollectionUpdate) (*domain.Collection, error) {
+	if _, err := service.Get(ctx, principal, id); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+
+	if update.Title != nil {
+		title, err := domain.NormalizeTitle(*update.Title)
+		if err != nil {
+			return nil, err
+		}
+		updates["title"] = title
+	}
+	if update.Description != nil {
+		description, err := domain.NormalizeDescription(*update.Description)
+		if err != nil {
+			return nil, err
+		}
+		updates["description"] = description
+	}
+	if update.CoverVideoID != nil {
+		coverId := strings.TrimSpace(*update.CoverVideoID)
+		if coverId == "" {
+			updates["cover_video_id"] = nil
+		} else {
+			if err := service.checkCover(ctx, principal, id, coverId); err != nil {
+				return nil, err
+			}
+			updates["cover_video_id"] = coverId
+		}
+	}
+
+	if err := service.Repository.UpdateById(ctx, id, updates); err != nil {
+		return nil, err
+	}
+	return service.Repository.GetById(ctx, id)
+}
+
+func (service *CollectionService) Delete(ctx context.Context, principal domain.Principal, id string) error {
+	if _, err := service.Get(ctx, principal, id); err != nil {
+		return err
+	}
+	return service.Repository.Delete(ctx, id)
+}
+
+// GetVideos lists the members of the collection that the caller may access.
+func (service *CollectionService) GetVideos(ctx context.Context, principal domain.Principal, id string) ([]domain.Video, error) {
+	if _, err := service.Get(ctx, principal, id); err != nil {
+		return nil, err
+	}
+	videos, err := service.Repository.GetVideos(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	accessible := videos[:0]
+	for _, video := range videos {
+		if principal.CanAccess(&video) {
+			accessible = append(accessible, video)
+		}
+	}
+	return accessible, nil
+}
+
+func (service *CollectionService) AddVideo(ctx context.Context, principal domain.Principal, id string, videoId string, position int) error {
+	if _, err := service.Get(ctx, principal, id); err != nil {
+		return err
+	}
+	if _, err := service.accessibleVideo(ctx, principal, videoId); err != nil {
+		return err
+	}
+	return service.Repository.AddVideo(ctx, id, videoId, position)
+}
+
+func (service *CollectionService) RemoveVideo(ctx context.Context, principal domain.Principal, id string, videoId string) error {
+	if _, err := service.Get(ctx, principal, id); err != nil {
+		return err
+	}
+	return service.Repository.RemoveVideo(ctx, id, videoId)
+}
+
+func (service *CollectionService) Reorder(ctx context.Context, principal domain.Principal, id string, videoIds []string) error {
+	if _, err := service.Get(ctx, principal, id); err != nil {
+		return err
+	}
+	return service.Repository.Reorder(ctx, id, videoIds)
+}
+
+func (service *CollectionService) checkCover(ctx context.Context, principal domain.Principal, id string, videoId string) error {
+	member, err := service.Repository.IsMember(ctx, id, videoId)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return domain.ErrVideoNotInCollection
+	}
+
+	_, err = service.accessibleVideo(ctx, principal, videoId)
+	return err
+}
+
+// accessibleVideo resolves a video the caller may put in a collection: one
+// they can access that is not archived.
+func (service *CollectionService) accessibleVideo(ctx context.Context, principal domain.Principal, videoId string) (*domain.Video, error) {
+	video, err := service.Videos.GetById(ctx, videoId)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.CanAccess(video) {
+		return nil, domain.ErrVideoNotFound
+	}
+	if video.ArchivedAt != nil {
+		return nil, domain.ErrAlreadyArchived
+	}
+	return video, nil
+}
+
+var CollectionModule = fx.Module("collection-service", fx.Provide(NewCollectionService))
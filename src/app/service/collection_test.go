@@ -0,0 +1,139 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCollections(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	svc := NewCollectionService(repository.NewCollectionRepository(env.db, env.log), env.repo, env.log)
+
+	owner := env.createUser(t, "owner@example.com")
+	other := env.createUser(t, "other@example.com")
+	var videos []*domain.Video
+	for _, slug := range []string{"series01", "series02", "series03", "series04"} {
+		videos = append(videos, env.createVideo(t, domain.Video{Slug: slug, OwnerID: &owner.UserID}))
+	}
+	first, second, third, archived := videos[0].ID, videos[1].ID, videos[2].ID, videos[3].ID
+	foreign := env.createVideo(t, domain.Video{Slug: "foreign1", OwnerID: &other.UserID})
+
+	collection, err := svc.Create(ctx, owner, "  Onboarding ", "")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if collection.Title != "Onboarding" || collection.OwnerID == nil || *collection.OwnerID != owner.UserID {
+		t.Errorf("created %+v", collection)
+	}
+
+	members := func() []string {
+		t.Helper()
+		videos, err := svc.GetVideos(ctx, owner, collection.ID)
+		if err != nil {
+			t.Fatalf("list videos: %v", err)
+		}
+		var got []string
+		for _, v := range videos {
+			got = append(got, v.ID)
+		}
+		return got
+	}
+
+	for _, add := range []struct {
+		id       string
+		position int
+	}{{first, -1}, {second, -1}, {archived, -1}, {third, 0}} {
+		if err := svc.AddVideo(ctx, owner, collection.ID, add.id, add.position); err != nil {
+			t.Fatalf("add %s: %v", add.id, err)
+		}
+	}
+	if got, want := members(), []string{third, first, second, archived}; !slices.Equal(got, want) {
+		t.Errorf("members = %v, want %v", got, want)
+	}
+	if err := svc.AddVideo(ctx, owner, collection.ID, first, -1); !errors.Is(err, domain.ErrVideoAlreadyInCollection) {
+		t.Errorf("adding a member again: error = %v", err)
+	}
+	if err := svc.AddVideo(ctx, owner, collection.ID, foreign.ID, -1); !errors.Is(err, domain.ErrVideoNotFound) {
+		t.Errorf("adding another user's video: error = %v", err)
+	}
+
+	// archived members are hidden, and stay behind the visible ones
+	env.archive(t, videos[3], time.Now().UTC())
+	if got, want := members(), []string{third, first, second}; !slices.Equal(got, want) {
+		t.Errorf("members after archiving = %v, want %v", got, want)
+	}
+	stored, err := svc.Get(ctx, owner, collection.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.VideoCount != 3 {
+		t.Errorf("video count = %d, want 3", stored.VideoCount)
+	}
+
+	var validationErr *domain.ValidationError
+	if err := svc.Reorder(ctx, owner, collection.ID, []string{first, second}); !errors.As(err, &validationErr) {
+		t.Errorf("reorder leaving a video out: error = %v", err)
+	}
+	if err := svc.Reorder(ctx, owner, collection.ID, []string{second, third, first}); err != nil {
+		t.Fatalf("reorder: %v", err)
+	}
+	if got, want := members(), []string{second, third, first}; !slices.Equal(got, want) {
+		t.Errorf("members after reorder = %v, want %v", got, want)
+	}
+
+	cover := third
+	if _, err := svc.Update(ctx, owner, collection.ID, domain.CollectionUpdate{CoverVideoID: &foreign.ID}); !errors.Is(err, domain.ErrVideoNotInCollection) {
+		t.Errorf("cover outside the collection: error = %v", err)
+	}
+	updated, err := svc.Update(ctx, owner, collection.ID, domain.CollectionUpdate{CoverVideoID: &cover})
+	if err != nil {
+		t.Fatalf("set cover: %v", err)
+	}
+	if updated.CoverVideoID == nil || *updated.CoverVideoID != cover {
+		t.Errorf("cover = %v, want %s", updated.CoverVideoID, cover)
+	}
+
+	// removing the cover clears it and closes the gap
+	if err := svc.RemoveVideo(ctx, owner, collection.ID, third); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if got, want := members(), []string{second, first}; !slices.Equal(got, want) {
+		t.Errorf("members after removal = %v, want %v", got, want)
+	}
+	if stored, err = svc.Get(ctx, owner, collection.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stored.CoverVideoID != nil {
+		t.Errorf("cover after removing it = %s, want none", *stored.CoverVideoID)
+	}
+	if err := svc.AddVideo(ctx, owner, collection.ID, third, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := members(), []string{second, third, first}; !slices.Equal(got, want) {
+		t.Errorf("members after inserting in the middle = %v, want %v", got, want)
+	}
+
+	if _, err := svc.Get(ctx, other, collection.ID); !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("another user reading the collection: error = %v", err)
+	}
+	if err := svc.Delete(ctx, other, collection.ID); !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("another user deleting the collection: error = %v", err)
+	}
+	listed, err := svc.GetAll(ctx, other, domain.Pagination{Limit: 10})
+	if err != nil || len(listed.Data) != 0 {
+		t.Errorf("another user's list = %+v, %v", listed.Data, err)
+	}
+
+	if err := svc.Delete(ctx, owner, collection.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := svc.Get(ctx, owner, collection.ID); !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("deleted collection: error = %v", err)
+	}
+}
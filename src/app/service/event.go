@@ -0,0 +1,335 @@
+package service
+
+import (
+	"awesomeProject/src/app/bus"
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	eventPageSize = 500
+	// eventReplayLimit caps how many missed events a resumed stream replays;
+	// a client further behind is told to reload instead.
+	eventReplayLimit      = 1000
+	eventSubscriberBuffer = 64
+	eventSettleWindow     = 10 * time.Second
+	eventSweepInterval    = time.Hour
+)
+
+// Subscription is one event stream. Events is closed when the subscriber
+// falls behind or the service stops; the client then reconnects and resumes
+// with Last-Event-ID.
+type Subscription struct {
+	Events <-chan domain.VideoEvent
+
+	events chan domain.VideoEvent
+	filter domain.EventFilter
+}
+
+// EventService fans video events out to streaming clients. Events are read
+// back from the database rather than passed around in memory, so every
+// instance sees the changes made by the others. The event bus triggers a
+// read as soon as an event is committed; polling catches whatever the bus
+// misses.
+type EventService struct {
+	repo   *repository.EventRepository
+	videos *VideoService
+	config *config.Config
+	log    *zap.Logger
+	wake   chan struct{}
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	stopped     bool
+
+	primed bool
+	last   int64
+	seen   map[int64]time.Time
+
+	runCtx context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewEventService(cfg *config.Config, repo *repository.EventRepository, videos *VideoService, events *bus.Bus, logger *zap.Logger) *EventService {
+	svc := &EventService{
+		repo:        repo,
+		videos:      videos,
+		config:      cfg,
+		log:         logger,
+		wake:        make(chan struct{}, 1),
+		subscribers: make(map[*Subscription]struct{}),
+		seen:        make(map[int64]time.Time),
+	}
+	events.Subscribe(bus.TopicVideoEvent, func(bus.Message) { svc.notify() })
+	events.OnConnect(svc.notify)
+	return svc
+}
+
+// notify asks for a poll without waiting for the next tick.
+func (svc *EventService) notify() {
+	select {
+	case svc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Filter builds the filter of a stream. Callers see events of the videos
+// they can access; listed videos must exist and be accessible.
+func (svc *EventService) Filter(ctx context.Context, principal domain.Principal, videoIds []string) (domain.EventFilter, error) {
+	if len(videoIds) > domain.MaxEventVideoFilter {
+		return domain.EventFilter{}, &domain.ValidationError{Field: "video_id", Message: fmt.Sprintf("at most %d videos are allowed", domain.MaxEventVideoFilter)}
+	}
+	for _, id := range videoIds {
+		if _, err := svc.videos.GetVideo(ctx, principal, id); err != nil {
+			return domain.EventFilter{}, err
+		}
+	}
+
+	filter := domain.EventFilter{VideoIDs: videoIds}
+	if !principal.IsAdmin() {
+		filter.OwnerID = principal.UserID
+	}
+	return filter, nil
+}
+
+func (svc *EventService) Subscribe(filter domain.EventFilter) *Subscription {
+	events := make(chan domain.VideoEvent, eventSubscriberBuffer)
+	sub := &Subscription{Events: events, events: events, filter: filter}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.stopped {
+		close(events)
+		return sub
+	}
+	svc.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (svc *EventService) Unsubscribe(sub *Subscription) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.drop(sub)
+}
+
+// Replay returns the stored events after id that match filter. When more
+// than eventReplayLimit were missed, no events are returned; resetID is then
+// the latest event ID, from which the client resumes after reloading.
+func (svc *EventService) Replay(ctx context.Context, filter domain.EventFilter, after int64) (events []domain.VideoEvent, resetID int64, err error) {
+	// the latest ID is read first, so events after it are still streamed
+	latest, err := svc.repo.LatestID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		page, err := svc.repo.GetAfter(ctx, after, filter, eventPageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, page...)
+		if len(events) > eventReplayLimit {
+			return nil, max(latest, after), nil
+		}
+		if len(page) < eventPageSize {
+			return events, 0, nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+func (svc *EventService) Start() {
+	svc.done = make(chan struct{})
+
+	go func() {
+		defer close(svc.done)
+		defer svc.closeAll()
+
+		poll := time.NewTicker(svc.config.Events.PollInterval)
+		defer poll.Stop()
+		sweep := time.NewTicker(eventSweepInterval)
+		defer sweep.Stop()
+
+		for {
+			select {
+			case <-svc.runCtx.Done():
+				return
+			case <-poll.C:
+				svc.poll()
+			case <-svc.wake:
+				svc.poll()
+			case <-sweep.C:
+				svc.sweep()
+			}
+		}
+	}()
+}
+
+// poll broadcasts the events stored since the previous poll, including late
+// commits below the highest number seen so far.
+func (svc *EventService) poll() {
+	if !svc.primed {
+		if err := svc.prime(); err != nil {
+			svc.log.Error("failed to read the event position", zap.Error(err))
+			return
+		}
+	}
+
+	late, err := svc.repo.GetLate(svc.runCtx, svc.last, eventSettleWindow)
+	if err != nil {
+		svc.logPollError(err)
+		return
+	}
+	var fresh []domain.VideoEvent
+	for _, e := range late {
+		if _, ok := svc.seen[e.ID]; !ok {
+			fresh = append(fresh, e)
+		}
+	}
+
+	for {
+		page, err := svc.repo.GetAfter(svc.runCtx, svc.last, domain.EventFilter{}, eventPageSize)
+		if err != nil {
+			svc.logPollError(err)
+			break
+		}
+		fresh = append(fresh, page...)
+		if len(page) > 0 {
+			svc.last = page[len(page)-1].ID
+		}
+		if len(page) < eventPageSize {
+			break
+		}
+	}
+
+	now := time.Now()
+	for _, e := range fresh {
+		svc.seen[e.ID] = now
+	}
+	for id, at := range svc.seen {
+		if now.Sub(at) > 2*eventSettleWindow {
+			delete(svc.seen, id)
+		}
+	}
+
+	svc.broadcast(fresh)
+}
+
+// prime starts at the latest event, so that history is only sent to clients
+// that ask for it with Last-Event-ID.
+func (svc *EventService) prime() error {
+	last, err := svc.repo.LatestID(svc.runCtx)
+	if err != nil {
+		return err
+	}
+	recent, err := svc.repo.GetLate(svc.runCtx, last, eventSettleWindow)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, e := range recent {
+		svc.seen[e.ID] = now
+	}
+	svc.last, svc.primed = last, true
+	return nil
+}
+
+func (svc *EventService) logPollError(err error) {
+	if svc.runCtx.Err() == nil {
+		svc.log.Error("failed to read video events", zap.Error(err))
+	}
+}
+
+func (svc *EventService) broadcast(events []domain.VideoEvent) {
+	if len(events) == 0 {
+		return
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	for sub := range svc.subscribers {
+		for _, e := range events {
+			if !sub.filter.Match(e) {
+				continue
+			}
+			if !svc.send(sub, e) {
+				break
+			}
+		}
+	}
+}
+
+// send never blocks: a subscriber that cannot keep up is disconnected and
+// catches up through a resumed stream instead.
+func (svc *EventService) send(sub *Subscription, e domain.VideoEvent) bool {
+	select {
+	case sub.events <- e:
+		return true
+	default:
+		svc.log.Debug("event subscriber fell behind, disconnecting")
+		svc.drop(sub)
+		return false
+	}
+}
+
+func (svc *EventService) drop(sub *Subscription) {
+	if _, ok := svc.subscribers[sub]; ok {
+		delete(svc.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (svc *EventService) closeAll() {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.stopped = true
+	for sub := range svc.subscribers {
+		svc.drop(sub)
+	}
+}
+
+func (svc *EventService) sweep() {
+	if svc.config.Events.Retention <= 0 {
+		return
+	}
+	n, err := svc.repo.DeleteBefore(svc.runCtx, time.Now().Add(-svc.config.Events.Retention))
+	if err != nil {
+		svc.log.Error("video event sweep failed", zap.Error(err))
+		return
+	}
+	if n > 0 {
+		svc.log.Info("expired video events removed", zap.Int64("count", n))
+	}
+}
+
+var EventModule = fx.Module("event_service",
+	fx.Provide(NewEventService),
+	fx.Invoke(func(lc fx.Lifecycle, es *EventService) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				es.runCtx, es.cancel = context.WithCancel(context.Background())
+				es.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				if es.cancel == nil {
+					return nil
+				}
+				es.cancel()
+				select {
+				case <-es.done:
+				case <-ctx.Done():
+				}
+				return nil
+			},
+		})
+	}),
+)
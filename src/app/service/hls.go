@@ -2,10 +2,14 @@ package service
 
 import (
 	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
 	"awesomeProject/src/app/hls"
 	"awesomeProject/src/app/repository"
 	"awesomeProject/src/util"
 	"context"
+	"database/sql"
+	"errors"
+	"math"
 	"os"
 	"path/filepath"
 	"time"
@@ -14,31 +18,46 @@ import (
 	"go.uber.org/zap"
 )
 
+const progressStep = 0.05
+
+var errRevisionInterrupted = errors.New("source upload was interrupted")
+
 type Converter interface {
 	Start(ctx context.Context)
 	Enqueue(slug string)
 }
 
 type ConversionService struct {
-	config   *config.Config
-	packager hls.Packager
-	repo     *repository.VideoRepository
-	log      *zap.Logger
+	config    *config.Config
+	packager  hls.Packager
+	repo      *repository.VideoRepository
+	revisions *repository.RevisionRepository
+	webhooks  *WebhookService
+	log       *zap.Logger
 
-	jobs          chan string
+	jobs          chan conversionJob
 	parallelLimit int
 
 	runCtx context.Context
 	cancel context.CancelFunc
 }
 
-func NewConversionService(cfg *config.Config, repo *repository.VideoRepository, pkg hls.Packager, logger *zap.Logger) *ConversionService {
+// conversionJob converts the current source of a video, or a replacement
+// revision when revision is set.
+type conversionJob struct {
+	slug     string
+	revision int
+}
+
+func NewConversionService(cfg *config.Config, repo *repository.VideoRepository, revisions *repository.RevisionRepository, webhooks *WebhookService, pkg hls.Packager, logger *zap.Logger) *ConversionService {
 	return &ConversionService{
 		config:        cfg,
 		packager:      pkg,
 		repo:          repo,
+		revisions:     revisions,
+		webhooks:      webhooks,
 		log:           logger,
-		jobs:          make(chan string, 256),
+		jobs:          make(chan conversionJob, 256),
 		parallelLimit: cfg.Conv.Parallel,
 	}
 }
@@ -46,25 +65,31 @@ func NewConversionService(cfg *config.Config, repo *repository.VideoRepository,
 func (svc *ConversionService) Start() {
 	var semChan = make(chan struct{}, svc.parallelLimit)
 	svc.log.Info("converter started", zap.Int("parallel", svc.parallelLimit))
+	go svc.resumeRevisions()
 	go func() {
 		for {
 			select {
 			case <-svc.runCtx.Done():
 				svc.log.Info("converted stopped")
 				return
-			case slug := <-svc.jobs:
-				svc.log.Info("extracted from chan, waiting for semaphore", zap.String("slug", slug))
+			case job := <-svc.jobs:
+				svc.log.Info("extracted from chan, waiting for semaphore", zap.String("slug", job.slug))
 				semChan <- struct{}{}
-				go func(sl string) {
+				go func(job conversionJob) {
 					defer func() { <-semChan }()
-					err := svc.handleJob(svc.runCtx, sl)
-					svc.log.Info("called job handler", zap.String("slug", slug))
+					var err error
+					if job.revision > 0 {
+						err = svc.handleRevision(svc.runCtx, job.slug, job.revision)
+					} else {
+						err = svc.handleJob(svc.runCtx, job.slug)
+					}
+					svc.log.Info("called job handler", zap.String("slug", job.slug))
 					if err != nil {
 						svc.log.Error("handle job failed", zap.Error(err))
 						return
 					}
-					svc.log.Debug("job finished", zap.String("slug", sl))
-				}(slug)
+					svc.log.Debug("job finished", zap.String("slug", job.slug))
+				}(job)
 			}
 		}
 
@@ -73,7 +98,47 @@ func (svc *ConversionService) Start() {
 
 func (svc *ConversionService) Enqueue(slug string) {
 	svc.log.Info("enqueued worker for ", zap.String("slug", slug))
-	svc.jobs <- slug
+	svc.jobs <- conversionJob{slug: slug}
+}
+
+// EnqueueRevision converts a replacement source; the video switches to it
+// once the output is ready.
+func (svc *ConversionService) EnqueueRevision(slug string, revision int) {
+	svc.log.Info("enqueued revision worker for ", zap.String("slug", slug), zap.Int("revision", revision))
+	svc.jobs <- conversionJob{slug: slug, revision: revision}
+}
+
+// resumeRevisions picks up the replacement sources that a previous process
+// left unfinished: stored sources are converted again, the others fail.
+func (svc *ConversionService) resumeRevisions() {
+	ctx := svc.runCtx
+
+	revisions, err := svc.revisions.GetUnfinished(ctx)
+	if err != nil {
+		svc.log.Error("failed to load unfinished revisions", zap.Error(err))
+		return
+	}
+	for _, revision := range revisions {
+		video, err := svc.repo.GetById(ctx, revision.VideoID)
+		if err != nil {
+			svc.log.Error("failed to load video of revision", zap.String("id", revision.VideoID), zap.Error(err))
+			continue
+		}
+
+		reason := errRevisionInterrupted
+		if revision.DurationS.Valid && video.ArchivedAt == nil {
+			source := filepath.Join(rawRevisionDir(svc.config, video, revision.Number), "source.mp4")
+			if _, err := os.Stat(source); err == nil {
+				svc.EnqueueRevision(video.Slug, revision.Number)
+				continue
+			}
+		} else if video.ArchivedAt != nil {
+			reason = domain.ErrVideoArchived
+		}
+		if err := svc.revisions.SetFailed(ctx, video.ID, revision.Number, reason); err != nil {
+			svc.log.Error("failed to mark revision failed", zap.String("id", video.ID), zap.Int("revision", revision.Number), zap.Error(err))
+		}
+	}
 }
 
 func (svc *ConversionService) handleJob(ctx context.Context, slug string) error {
@@ -82,52 +147,176 @@ func (svc *ConversionService) handleJob(ctx context.Context, slug string) error
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(filepath.Join(svc.config.Conv.TmpDir, slug))
+
+	if video.ArchivedAt != nil {
+		svc.log.Info("skipping conversion of an archived video", zap.String("slug", slug))
+		return nil
+	}
+
+	// a reprocessed video already has output, which stays in place on failure
+	reprocess := video.HLSReadyAt != nil
 
 	if err := svc.repo.SetProcessing(ctx, video.ID, time.Now()); err != nil {
+		if errors.Is(err, domain.ErrVideoArchived) {
+			svc.log.Info("skipping conversion of an archived video", zap.String("slug", slug))
+			return nil
+		}
 		return err
 	}
+	svc.webhooks.Publish(ctx, domain.EventVideoProcessing, video.ID)
 
-	datePath := video.CreatedAt.Format("2006/01/02")
+	convertedSize, err := svc.convert(ctx, video, video.Revision, expectedDuration(video.DurationS))
+	if err != nil {
+		if reprocess {
+			_ = svc.repo.SetReprocessFailed(ctx, video.ID, err)
+		} else {
+			_ = svc.repo.SetInterrupted(ctx, video.ID, err)
+			_ = svc.revisions.SetFailed(ctx, video.ID, video.Revision, err)
+		}
+		svc.webhooks.Publish(ctx, domain.EventVideoFailed, video.ID)
+		return err
+	}
 
-	inPath := filepath.Join(svc.config.Data.RawDir, datePath, slug, "source.mp4")
-	outDir := filepath.Join(svc.config.Conv.TmpDir, slug, "hls")
+	readyAt := time.Now()
+	if err := svc.repo.SetReady(ctx, video.ID, readyAt, convertedSize); err != nil {
+		return err
+	}
+	svc.webhooks.Publish(ctx, domain.EventVideoReady, video.ID)
+	if err := svc.revisions.SetReady(ctx, video.ID, video.Revision, readyAt, convertedSize); err != nil {
+		svc.log.Warn("failed to mark revision ready", zap.Error(err), zap.String("slug", slug))
+	}
+	svc.log.Info("converting succeeded for video", zap.String("slug", slug))
 
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		svc.log.Error("create output dir failed", zap.Error(err), zap.String("slug", slug))
-		_ = svc.repo.SetInterrupted(ctx, video.ID, err)
+	return nil
+}
+
+// handleRevision converts a replacement source next to the current output,
+// which keeps being served until the video is switched over.
+func (svc *ConversionService) handleRevision(ctx context.Context, slug string, number int) error {
+	video, err := svc.repo.GetBySlug(ctx, slug)
+	svc.log.Info("handling revision job for ", zap.String("slug", slug), zap.Int("revision", number))
+	if err != nil {
 		return err
 	}
-	if err := svc.packager.PackageHLS(ctx, inPath, outDir); err != nil {
-		svc.log.Error("packaging failed", zap.Error(err), zap.String("slug", slug))
-		_ = svc.repo.SetInterrupted(ctx, video.ID, err)
+	revision, err := svc.revisions.Get(ctx, video.ID, number)
+	if err != nil {
 		return err
 	}
 
-	destPath := filepath.Join(svc.config.Conv.ConvDir, datePath, slug)
+	if err := svc.revisions.SetProcessing(ctx, video.ID, number); err != nil {
+		_ = svc.revisions.SetFailed(ctx, video.ID, number, err)
+		return err
+	}
 
-	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
-		svc.log.Error("make final parent dir failed", zap.Error(err), zap.String("slug", slug))
-		_ = svc.repo.SetInterrupted(ctx, video.ID, err)
+	convertedSize, err := svc.convert(ctx, video, number, expectedDuration(revision.DurationS))
+	if err != nil {
+		_ = svc.revisions.SetFailed(ctx, video.ID, number, err)
+		return err
+	}
+
+	readyAt := time.Now()
+	if err := svc.revisions.SetReady(ctx, video.ID, number, readyAt, convertedSize); err != nil {
 		return err
 	}
+	revision.ReadyAt, revision.ConvertedSizeBytes = &readyAt, convertedSize
+
+	if err := svc.repo.SwitchRevision(ctx, revision); err != nil {
+		svc.log.Error("switching revision failed", zap.Error(err), zap.String("slug", slug), zap.Int("revision", number))
+		return err
+	}
+	svc.log.Info("video switched to new revision", zap.String("slug", slug), zap.Int("revision", number))
+	svc.webhooks.Publish(ctx, domain.EventVideoReady, video.ID)
+
+	return nil
+}
+
+// convert packages the source of a revision and moves the verified output to
+// its final directory, returning the output size, or nil when it could not
+// be measured.
+func (svc *ConversionService) convert(ctx context.Context, video *domain.Video, revision int, expected time.Duration) (*int64, error) {
+	name := domain.RevisionDirName(video.Slug, revision)
+	defer os.RemoveAll(filepath.Join(svc.config.Conv.TmpDir, name))
+
+	inPath := filepath.Join(rawRevisionDir(svc.config, video, revision), "source.mp4")
+	outDir := filepath.Join(svc.config.Conv.TmpDir, name, "hls")
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		svc.log.Error("create output dir failed", zap.Error(err), zap.String("slug", name))
+		return nil, err
+	}
+	if err := svc.packager.PackageHLS(ctx, inPath, outDir, svc.progressReporter(ctx, video.ID, expected)); err != nil {
+		svc.log.Error("packaging failed", zap.Error(err), zap.String("slug", name))
+		return nil, err
+	}
+	if _, err := hls.Verify(outDir, "index.m3u8", expected); err != nil {
+		svc.log.Error("hls verification failed", zap.Error(err), zap.String("slug", name))
+		return nil, err
+	}
+
+	destPath := convertedRevisionDir(svc.config, video, revision)
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		svc.log.Error("make final parent dir failed", zap.Error(err), zap.String("slug", name))
+		return nil, err
+	}
+	// a reprocessed video replaces its previous output, which is kept aside
+	// until the new one is in place
+	previousPath := destPath + ".previous"
+	if err := os.RemoveAll(previousPath); err != nil {
+		svc.log.Error("remove stale artifacts failed", zap.Error(err), zap.String("slug", name))
+		return nil, err
+	}
+	if err := os.Rename(destPath, previousPath); err != nil && !os.IsNotExist(err) {
+		svc.log.Error("set previous artifacts aside failed", zap.Error(err), zap.String("slug", name))
+		return nil, err
+	}
 	if err := util.MoveDir(outDir, destPath); err != nil {
 		svc.log.Error("move artifacts failed",
 			zap.Error(err),
 			zap.String("from", outDir),
 			zap.String("to", destPath),
-			zap.String("slug", slug),
+			zap.String("slug", name),
 		)
-		_ = svc.repo.SetInterrupted(ctx, video.ID, err)
-		return err
+		_ = os.RemoveAll(destPath)
+		_ = os.Rename(previousPath, destPath)
+		return nil, err
+	}
+	if err := os.RemoveAll(previousPath); err != nil {
+		svc.log.Warn("remove previous artifacts failed", zap.Error(err), zap.String("slug", name))
 	}
 
-	if err := svc.repo.SetReady(ctx, video.ID, time.Now()); err != nil {
-		return err
+	convertedSize, err := util.DirSize(destPath)
+	if err != nil {
+		svc.log.Warn("failed to measure converted size", zap.Error(err), zap.String("slug", name))
+		return nil, nil
 	}
-	svc.log.Info("converting succeeded for video", zap.String("slug", slug))
+	return &convertedSize, nil
+}
 
-	return nil
+// progressReporter publishes conversion progress in steps of
+// progressStep, so a conversion adds a bounded number of events.
+func (svc *ConversionService) progressReporter(ctx context.Context, videoId string, expected time.Duration) hls.ProgressFunc {
+	if expected <= 0 {
+		return nil
+	}
+	reported := 0.0
+	return func(done time.Duration) {
+		progress := min(math.Floor(float64(done)/float64(expected)/progressStep)*progressStep, 1)
+		if progress <= reported || progress >= 1 {
+			return
+		}
+		reported = progress
+		if err := svc.repo.RecordProgress(ctx, videoId, math.Round(progress*100)/100); err != nil {
+			svc.log.Warn("failed to record conversion progress", zap.String("id", videoId), zap.Error(err))
+		}
+	}
+}
+
+func expectedDuration(duration sql.NullInt32) time.Duration {
+	if !duration.Valid {
+		return 0
+	}
+	return time.Duration(duration.Int32) * time.Second
 }
 
 var ConvServiceModule = fx.Module("conversion_service",
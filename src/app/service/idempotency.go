@@ -0,0 +1,110 @@
+package service
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// idempotencyLockTimeout bounds how long an unfinished request holds its key;
+// after that the reservation is assumed to belong to a crashed instance.
+const idempotencyLockTimeout = 10 * time.Minute
+
+type IdempotencyService struct {
+	repo     *repository.IdempotencyRepository
+	log      *zap.Logger
+	ttl      time.Duration
+	interval time.Duration
+	runCtx   context.Context
+	cancel   context.CancelFunc
+}
+
+func NewIdempotencyService(cfg *config.Config, repo *repository.IdempotencyRepository, logger *zap.Logger) *IdempotencyService {
+	return &IdempotencyService{
+		repo:     repo,
+		log:      logger,
+		ttl:      cfg.Idempotency.TTL,
+		interval: cfg.Idempotency.SweepInterval,
+	}
+}
+
+func (svc *IdempotencyService) Enabled() bool {
+	return svc.ttl > 0
+}
+
+// Begin reserves the key of record. It returns nil when the request should
+// run, or the stored record whose response must be replayed instead.
+func (svc *IdempotencyService) Begin(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	now := time.Now().UTC()
+	record.ExpiresAt = now.Add(svc.ttl)
+
+	existing, err := svc.repo.Reserve(ctx, record, now, now.Add(-idempotencyLockTimeout))
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	if existing.Method != record.Method || existing.Path != record.Path || existing.Fingerprint != record.Fingerprint {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	if !existing.Completed() {
+		return nil, domain.ErrIdempotencyInProgress
+	}
+	return existing, nil
+}
+
+func (svc *IdempotencyService) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	return svc.repo.Complete(ctx, record, time.Now().UTC())
+}
+
+func (svc *IdempotencyService) Release(ctx context.Context, record *domain.IdempotencyRecord) error {
+	return svc.repo.Release(ctx, record.Owner, record.Key)
+}
+
+func (svc *IdempotencyService) Start() {
+	if !svc.Enabled() || svc.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(svc.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-svc.runCtx.Done():
+				return
+			case <-ticker.C:
+				n, err := svc.repo.DeleteExpired(svc.runCtx, time.Now().UTC())
+				if err != nil {
+					svc.log.Error("idempotency sweep failed", zap.Error(err))
+					continue
+				}
+				if n > 0 {
+					svc.log.Info("expired idempotency keys removed", zap.Int64("count", n))
+				}
+			}
+		}
+	}()
+}
+
+var IdempotencyModule = fx.Module("idempotency_service",
+	fx.Provide(NewIdempotencyService),
+	fx.Invoke(func(lc fx.Lifecycle, is *IdempotencyService) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				is.runCtx, is.cancel = context.WithCancel(context.Background())
+				is.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				if is.cancel != nil {
+					is.cancel()
+				}
+				return nil
+			},
+		})
+	}),
+)
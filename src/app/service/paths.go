@@ -0,0 +1,33 @@
+package service
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"path/filepath"
+	"strings"
+)
+
+func videoDatePath(video *domain.Video) string {
+	return video.CreatedAt.Format("2006/01/02")
+}
+
+func rawDir(cfg *config.Config, video *domain.Video) string {
+	return rawRevisionDir(cfg, video, video.Revision)
+}
+
+func convertedDir(cfg *config.Config, video *domain.Video) string {
+	return convertedRevisionDir(cfg, video, video.Revision)
+}
+
+func rawRevisionDir(cfg *config.Config, video *domain.Video, revision int) string {
+	return filepath.Join(cfg.Data.RawDir, videoDatePath(video), domain.RevisionDirName(video.Slug, revision))
+}
+
+func convertedRevisionDir(cfg *config.Config, video *domain.Video, revision int) string {
+	return filepath.Join(cfg.Conv.ConvDir, videoDatePath(video), domain.RevisionDirName(video.Slug, revision))
+}
+
+func archivePath(cfg *config.Config, video *domain.Video) string {
+	ext := strings.ToLower(filepath.Ext(video.Filename))
+	return filepath.Join(cfg.Data.ArchiveDir, video.Slug+ext)
+}
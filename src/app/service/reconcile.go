@@ -0,0 +1,350 @@
+package service
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+type ReconcileOptions struct {
+	DeleteOrphans bool
+	MarkBroken    bool
+	GracePeriod   time.Duration
+}
+
+type ReconcileService struct {
+	config    *config.Config
+	repo      *repository.VideoRepository
+	revisions *repository.RevisionRepository
+	log       *zap.Logger
+
+	runCtx context.Context
+	cancel context.CancelFunc
+}
+
+type storageEntry struct {
+	area     domain.StorageArea
+	path     string
+	slug     string
+	revision int
+	size     int64
+	modTime  time.Time
+}
+
+func NewReconcileService(cfg *config.Config, repo *repository.VideoRepository, revisions *repository.RevisionRepository, logger *zap.Logger) *ReconcileService {
+	return &ReconcileService{
+		config:    cfg,
+		repo:      repo,
+		revisions: revisions,
+		log:       logger,
+	}
+}
+
+func (svc *ReconcileService) DefaultOptions() ReconcileOptions {
+	return ReconcileOptions{
+		DeleteOrphans: svc.config.Reconcile.DeleteOrphans,
+		MarkBroken:    svc.config.Reconcile.MarkBroken,
+		GracePeriod:   svc.config.Reconcile.GracePeriod,
+	}
+}
+
+func (svc *ReconcileService) Start() {
+	interval := svc.config.Reconcile.Interval
+	if interval <= 0 {
+		svc.log.Info("scheduled reconciliation disabled")
+		return
+	}
+	svc.log.Info("reconciler started", zap.Duration("interval", interval))
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-svc.runCtx.Done():
+				svc.log.Info("reconciler stopped")
+				return
+			case <-ticker.C:
+				if _, err := svc.Run(svc.runCtx, svc.DefaultOptions()); err != nil {
+					svc.log.Error("reconciliation failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (svc *ReconcileService) Run(ctx context.Context, opts ReconcileOptions) (*domain.ReconcileReport, error) {
+	report := &domain.ReconcileReport{
+		StartedAt: time.Now().UTC(),
+		Orphans:   []domain.OrphanEntry{},
+		Missing:   []domain.MissingArtifact{},
+	}
+
+	videos, err := svc.repo.GetAllUnpaged(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bySlug := make(map[string]*domain.Video, len(videos))
+	for i := range videos {
+		bySlug[videos[i].Slug] = &videos[i]
+	}
+
+	revisions, err := svc.revisions.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byVideo := make(map[string]map[int]domain.VideoRevision)
+	for _, r := range revisions {
+		if byVideo[r.VideoID] == nil {
+			byVideo[r.VideoID] = make(map[int]domain.VideoRevision)
+		}
+		byVideo[r.VideoID][r.Number] = r
+	}
+
+	entries, err := svc.scan()
+	if err != nil {
+		return nil, err
+	}
+	report.Scanned = len(entries)
+
+	now := time.Now()
+	for _, e := range entries {
+		video := bySlug[e.slug]
+		if video != nil && !svc.isOrphan(e, video, byVideo[video.ID]) {
+			continue
+		}
+		orphan := domain.OrphanEntry{
+			Area:      e.area,
+			Path:      e.path,
+			Slug:      e.slug,
+			SizeBytes: e.size,
+			ModTime:   e.modTime,
+		}
+		if opts.DeleteOrphans && now.Sub(e.modTime) > opts.GracePeriod {
+			if err := os.RemoveAll(e.path); err != nil {
+				svc.log.Error("failed to remove orphan", zap.String("path", e.path), zap.Error(err))
+			} else {
+				orphan.Deleted = true
+			}
+		}
+		report.Orphans = append(report.Orphans, orphan)
+	}
+
+	for i := range videos {
+		video := &videos[i]
+		for _, missing := range svc.missingArtifacts(video) {
+			if opts.MarkBroken && canMarkBroken(video) {
+				reason := fmt.Errorf("missing %s artifact: %s", missing.Area, missing.Path)
+				if err := svc.repo.SetInterrupted(ctx, video.ID, reason); err != nil {
+					svc.log.Error("failed to mark video as broken", zap.String("id", video.ID), zap.Error(err))
+				} else {
+					missing.Marked = true
+					video.Status = string(domain.StatusInterrupted)
+				}
+			}
+			report.Missing = append(report.Missing, missing)
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	svc.log.Info("reconciliation finished",
+		zap.Int("scanned", report.Scanned),
+		zap.Int("orphans", len(report.Orphans)),
+		zap.Int("missing", len(report.Missing)),
+	)
+	return report, nil
+}
+
+func (svc *ReconcileService) scan() ([]storageEntry, error) {
+	var entries []storageEntry
+
+	areas := []struct {
+		area  domain.StorageArea
+		root  string
+		depth int
+	}{
+		{domain.AreaRaw, svc.config.Data.RawDir, 4},
+		{domain.AreaConverted, svc.config.Conv.ConvDir, 4},
+		{domain.AreaTmp, svc.config.Conv.TmpDir, 1},
+		{domain.AreaArchive, svc.config.Data.ArchiveDir, 1},
+	}
+
+	for _, a := range areas {
+		found, err := scanArea(a.area, a.root, a.depth)
+		if err != nil {
+			return nil, fmt.Errorf("scan %s: %w", a.area, err)
+		}
+		entries = append(entries, found...)
+	}
+	return entries, nil
+}
+
+func (svc *ReconcileService) isOrphan(e storageEntry, video *domain.Video, revisions map[int]domain.VideoRevision) bool {
+	if video == nil {
+		return true
+	}
+	// sources and output of other revisions are kept for rollbacks
+	if e.area != domain.AreaArchive && e.revision != video.Revision {
+		revision, ok := revisions[e.revision]
+		switch {
+		case !ok:
+			return true
+		case e.area == domain.AreaTmp:
+			return !revision.IsPending()
+		case e.area == domain.AreaRaw:
+			return e.path != rawRevisionDir(svc.config, video, e.revision)
+		default:
+			return e.path != convertedRevisionDir(svc.config, video, e.revision)
+		}
+	}
+	switch e.area {
+	case domain.AreaRaw:
+		return video.ArchivedAt != nil || e.path != rawDir(svc.config, video)
+	case domain.AreaConverted:
+		return e.path != convertedDir(svc.config, video)
+	case domain.AreaTmp:
+		return !video.IsProcessing()
+	case domain.AreaArchive:
+		return video.ArchivedAt == nil || e.path != archivePath(svc.config, video)
+	}
+	return false
+}
+
+func (svc *ReconcileService) missingArtifacts(video *domain.Video) []domain.MissingArtifact {
+	var expected []storageEntry
+
+	if video.ArchivedAt != nil {
+		expected = append(expected, storageEntry{area: domain.AreaArchive, path: archivePath(svc.config, video)})
+	} else {
+		expected = append(expected, storageEntry{area: domain.AreaRaw, path: filepath.Join(rawDir(svc.config, video), "source.mp4")})
+	}
+	if video.Status == string(domain.StatusComplete) {
+		expected = append(expected, storageEntry{area: domain.AreaConverted, path: filepath.Join(convertedDir(svc.config, video), "index.m3u8")})
+	}
+
+	var missing []domain.MissingArtifact
+	for _, e := range expected {
+		if _, err := os.Stat(e.path); err == nil || !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		missing = append(missing, domain.MissingArtifact{
+			VideoID: video.ID,
+			Slug:    video.Slug,
+			Status:  video.Status,
+			Area:    e.area,
+			Path:    e.path,
+		})
+	}
+	return missing
+}
+
+func canMarkBroken(video *domain.Video) bool {
+	return video.ArchivedAt == nil &&
+		!video.IsProcessing() &&
+		video.Status != string(domain.StatusInterrupted)
+}
+
+func scanArea(area domain.StorageArea, root string, depth int) ([]storageEntry, error) {
+	var entries []storageEntry
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if len(strings.Split(rel, string(filepath.Separator))) < depth {
+			return nil
+		}
+
+		size, modTime, err := entryStats(path, d)
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if !d.IsDir() {
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		slug, revision := domain.ParseRevisionDirName(name)
+		entries = append(entries, storageEntry{
+			area:     area,
+			path:     path,
+			slug:     slug,
+			revision: revision,
+			size:     size,
+			modTime:  modTime,
+		})
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func entryStats(path string, d fs.DirEntry) (int64, time.Time, error) {
+	info, err := d.Info()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if !d.IsDir() {
+		return info.Size(), info.ModTime(), nil
+	}
+
+	var size int64
+	modTime := info.ModTime()
+	err = filepath.WalkDir(path, func(_ string, sub fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		subInfo, err := sub.Info()
+		if err != nil {
+			return err
+		}
+		if !sub.IsDir() {
+			size += subInfo.Size()
+		}
+		if subInfo.ModTime().After(modTime) {
+			modTime = subInfo.ModTime()
+		}
+		return nil
+	})
+	return size, modTime, err
+}
+
+var ReconcileModule = fx.Module("reconcile_service",
+	fx.Provide(NewReconcileService),
+	fx.Invoke(func(lc fx.Lifecycle, rs *ReconcileService) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				rs.runCtx, rs.cancel = context.WithCancel(context.Background())
+				rs.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				if rs.cancel != nil {
+					rs.cancel()
+				}
+				return nil
+			},
+		})
+	}),
+)
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReconcile(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	svc := NewReconcileService(env.cfg, env.repo, repository.NewRevisionRepository(env.db, env.log), env.log)
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	healthy := env.createVideo(t, domain.Video{Slug: "healthy1", CreatedAt: created})
+	if err := env.repo.SetReady(ctx, healthy.ID, time.Now(), nil); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(convertedDir(env.cfg, healthy), "index.m3u8"), "#EXTM3U")
+	// the source of a revision that can still be rolled back to
+	if err := env.db.Create(&domain.VideoRevision{VideoID: healthy.ID, Number: 2, Filename: "new.mp4", Status: domain.RevisionReady}).Error; err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(rawRevisionDir(env.cfg, healthy, 2), "source.mp4"), "new")
+
+	broken := env.createVideo(t, domain.Video{Slug: "broken01", CreatedAt: created})
+	if err := env.repo.SetReady(ctx, broken.ID, time.Now(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	archived := env.createVideo(t, domain.Video{Slug: "archive1", CreatedAt: created})
+	env.archive(t, archived, time.Now().UTC())
+	leftover := filepath.Join(rawDir(env.cfg, archived), "source.mp4")
+	writeFile(t, leftover, "copy left by the old archive")
+
+	ghost := filepath.Join(env.cfg.Data.RawDir, "2023", "01", "01", "ghost001")
+	writeFile(t, filepath.Join(ghost, "source.mp4"), "ghost")
+	old := time.Now().Add(-48 * time.Hour)
+	for _, p := range []string{filepath.Join(ghost, "source.mp4"), ghost} {
+		if err := os.Chtimes(p, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fresh := filepath.Join(env.cfg.Conv.TmpDir, "healthy1")
+	writeFile(t, filepath.Join(fresh, "index.m3u8"), "#EXTM3U")
+
+	report, err := svc.Run(ctx, ReconcileOptions{DeleteOrphans: true, MarkBroken: true, GracePeriod: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	orphans := map[string]domain.OrphanEntry{}
+	for _, o := range report.Orphans {
+		orphans[o.Path] = o
+	}
+	want := []string{rawDir(env.cfg, archived), ghost, fresh}
+	if len(orphans) != len(want) {
+		t.Errorf("orphans = %+v, want %v", report.Orphans, want)
+	}
+	for _, p := range want {
+		if _, ok := orphans[p]; !ok {
+			t.Errorf("%s is not reported as an orphan", p)
+		}
+	}
+	if !orphans[ghost].Deleted || exists(t, ghost) {
+		t.Error("orphan older than the grace period was not deleted")
+	}
+	if orphans[fresh].Deleted || !exists(t, fresh) {
+		t.Error("orphan within the grace period was deleted")
+	}
+
+	if len(report.Missing) != 1 {
+		t.Fatalf("missing = %+v, want the output of broken01", report.Missing)
+	}
+	missing := report.Missing[0]
+	if missing.VideoID != broken.ID || missing.Area != domain.AreaConverted || !missing.Marked {
+		t.Errorf("missing = %+v, want marked converted output of broken01", missing)
+	}
+	stored, err := env.repo.GetById(ctx, broken.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != string(domain.StatusInterrupted) {
+		t.Errorf("broken video has status %s, want interrupted", stored.Status)
+	}
+
+	// marked videos are not marked again, and nothing is deleted without
+	// being asked to
+	report, err = svc.Run(ctx, ReconcileOptions{MarkBroken: true})
+	if err != nil {
+		t.Fatalf("second reconcile: %v", err)
+	}
+	if !slices.ContainsFunc(report.Orphans, func(o domain.OrphanEntry) bool { return o.Path == fresh && !o.Deleted }) {
+		t.Errorf("orphans = %+v, want %s kept", report.Orphans, fresh)
+	}
+	for _, m := range report.Missing {
+		if m.Marked {
+			t.Errorf("%s was marked again", m.Slug)
+		}
+	}
+}
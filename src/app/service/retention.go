@@ -0,0 +1,111 @@
+package service
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const purgeBatchSize = 100
+
+type RetentionService struct {
+	config    *config.Config
+	repo      *repository.VideoRepository
+	videos    *VideoService
+	log       *zap.Logger
+	retention time.Duration
+	interval  time.Duration
+	runCtx    context.Context
+	cancel    context.CancelFunc
+}
+
+func NewRetentionService(cfg *config.Config, repo *repository.VideoRepository, videos *VideoService, logger *zap.Logger) *RetentionService {
+	return &RetentionService{
+		config:    cfg,
+		repo:      repo,
+		videos:    videos,
+		log:       logger,
+		retention: cfg.Retention.ArchiveRetention,
+		interval:  cfg.Retention.PurgeInterval,
+	}
+}
+
+func (svc *RetentionService) Start() {
+	if svc.retention <= 0 || svc.interval <= 0 {
+		svc.log.Info("archive retention disabled")
+		return
+	}
+	svc.log.Info("retention purger started",
+		zap.Duration("retention", svc.retention),
+		zap.Duration("interval", svc.interval),
+	)
+	go func() {
+		ticker := time.NewTicker(svc.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-svc.runCtx.Done():
+				svc.log.Info("retention purger stopped")
+				return
+			case <-ticker.C:
+				n, err := svc.PurgeExpired(svc.runCtx)
+				if err != nil {
+					svc.log.Error("retention purge failed", zap.Error(err))
+					continue
+				}
+				svc.log.Info("retention purge finished", zap.Int("purged", n))
+			}
+		}
+	}()
+}
+
+func (svc *RetentionService) PurgeExpired(ctx context.Context) (int, error) {
+	cutoff := time.Now().UTC().Add(-svc.retention)
+	purged := 0
+
+	// videos that fail to purge stay behind, so the pages are keyed on the
+	// last id seen rather than restarting at the oldest video
+	afterID := ""
+	for {
+		videos, err := svc.repo.GetArchivedBefore(ctx, cutoff, afterID, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		if len(videos) == 0 {
+			return purged, nil
+		}
+
+		for _, video := range videos {
+			if err := svc.videos.Purge(ctx, video.ID, domain.ActorRetention, 0); err != nil {
+				svc.log.Error("purge failed", zap.String("id", video.ID), zap.Error(err))
+				continue
+			}
+			purged++
+		}
+		afterID = videos[len(videos)-1].ID
+	}
+}
+
+var RetentionModule = fx.Module("retention_service",
+	fx.Provide(NewRetentionService),
+	fx.Invoke(func(lc fx.Lifecycle, rs *RetentionService) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				rs.runCtx, rs.cancel = context.WithCancel(context.Background())
+				rs.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				if rs.cancel != nil {
+					rs.cancel()
+				}
+				return nil
+			},
+		})
+	}),
+)
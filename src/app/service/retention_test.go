@@ -0,0 +1,59 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPurgeExpired(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	retention := &RetentionService{config: env.cfg, repo: env.repo, videos: env.videos, log: env.log, retention: 7 * 24 * time.Hour}
+	expired := time.Now().UTC().Add(-30 * 24 * time.Hour)
+
+	// a full page of expired videos that cannot be purged, ahead of the
+	// purgeable one in id order
+	err := env.db.Exec(`INSERT INTO videos (id, filename, slug, size_bytes, status, archived_at)
+		SELECT ('00000000-0000-0000-0000-' || lpad(n::text, 12, '0'))::uuid, 'stuck.mp4', 'stuck' || n, 0, 'processing', ?
+		FROM generate_series(1, ?) AS n`, expired, purgeBatchSize).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := env.createVideo(t, domain.Video{ID: "ffffffff-ffff-ffff-ffff-ffffffffffff", Slug: "expired1"})
+	env.archive(t, old, expired)
+	recent := env.createVideo(t, domain.Video{Slug: "recent01"})
+	env.archive(t, recent, time.Now().UTC().Add(-time.Hour))
+	active := env.createVideo(t, domain.Video{Slug: "active01", CreatedAt: expired})
+
+	purged, err := retention.PurgeExpired(ctx)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d videos, want 1", purged)
+	}
+
+	if _, err := env.repo.GetById(ctx, old.ID); !errors.Is(err, domain.ErrVideoNotFound) {
+		t.Errorf("expired video behind a page of failures was not purged: %v", err)
+	}
+	if exists(t, archivePath(env.cfg, old)) {
+		t.Error("archived source of the expired video is left behind")
+	}
+	for _, video := range []*domain.Video{recent, active} {
+		if _, err := env.repo.GetById(ctx, video.ID); err != nil {
+			t.Errorf("%s was purged: %v", video.Slug, err)
+		}
+	}
+
+	var stuck int64
+	if err := env.db.Model(&domain.Video{}).Where("status = ?", domain.StatusProcessing).Count(&stuck).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stuck != purgeBatchSize {
+		t.Errorf("%d videos being processed are left, want %d", stuck, purgeBatchSize)
+	}
+}
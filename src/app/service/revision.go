@@ -0,0 +1,186 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"awesomeProject/src/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// RevisionService replaces the source of a video without changing its ID,
+// slug or share links. Every source is kept as a revision to roll back to.
+type RevisionService struct {
+	Repository *repository.RevisionRepository
+	videos     *VideoService
+	log        *zap.Logger
+}
+
+func NewRevisionService(repo *repository.RevisionRepository, videos *VideoService, logger *zap.Logger) *RevisionService {
+	return &RevisionService{
+		Repository: repo,
+		videos:     videos,
+		log:        logger,
+	}
+}
+
+func (svc *RevisionService) GetRevisions(ctx context.Context, principal domain.Principal, id string) (*domain.Video, []domain.VideoRevision, error) {
+	video, err := svc.videos.GetVideo(ctx, principal, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisions, err := svc.Repository.GetByVideo(ctx, video.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return video, revisions, nil
+}
+
+// ReplaceSource stores header as a new revision and queues its conversion.
+// The current output keeps being served until the new one is ready.
+func (svc *RevisionService) ReplaceSource(ctx context.Context, principal domain.Principal, id string, header *multipart.FileHeader) (*domain.VideoRevision, error) {
+	video, err := svc.videos.GetVideo(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if video.ArchivedAt != nil {
+		return nil, domain.ErrVideoArchived
+	}
+	// a queued first conversion would overwrite the switch when it finishes
+	if video.IsProcessing() || video.Status == string(domain.StatusUploaded) {
+		return nil, domain.ErrVideoIsProcessing
+	}
+
+	revision := &domain.VideoRevision{
+		VideoID:   video.ID,
+		Filename:  header.Filename,
+		SizeBytes: header.Size,
+		Status:    domain.RevisionPending,
+	}
+	if principal.UserID != "" {
+		revision.CreatedBy = &principal.UserID
+	}
+
+	// the row reserves the revision number before any file is written
+	if err := svc.Repository.Insert(ctx, revision); err != nil {
+		return nil, err
+	}
+
+	// from here on a failure must not leave the revision pending, as that
+	// blocks every later replacement and rollback of the video
+	dir := rawRevisionDir(svc.videos.Config, video, revision.Number)
+	fail := func(err error) error {
+		_ = os.RemoveAll(dir)
+		if err := svc.Repository.SetFailed(context.WithoutCancel(ctx), video.ID, revision.Number, err); err != nil {
+			svc.log.Error("failed to mark revision failed", zap.String("id", video.ID), zap.Int("revision", revision.Number), zap.Error(err))
+		}
+		return err
+	}
+
+	duration, err := svc.storeSource(ctx, header, dir)
+	if err != nil {
+		return nil, fail(err)
+	}
+	revision.DurationS = duration
+	if err := svc.Repository.SetDuration(ctx, video.ID, revision.Number, duration); err != nil {
+		return nil, fail(err)
+	}
+
+	svc.videos.HlsService.EnqueueRevision(video.Slug, revision.Number)
+	svc.videos.recordAudit(ctx, video, domain.AuditReplaced, principal.Actor())
+
+	return revision, nil
+}
+
+// Rollback switches the video to an earlier revision whose output is still
+// on disk.
+func (svc *RevisionService) Rollback(ctx context.Context, principal domain.Principal, id string, number int) (*domain.Video, error) {
+	video, err := svc.videos.GetVideo(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if video.ArchivedAt != nil {
+		return nil, domain.ErrVideoArchived
+	}
+	if video.IsProcessing() {
+		return nil, domain.ErrVideoIsProcessing
+	}
+
+	revision, err := svc.Repository.Get(ctx, video.ID, number)
+	if err != nil {
+		return nil, err
+	}
+	if revision.Number == video.Revision {
+		return video, nil
+	}
+	if revision.Status != domain.RevisionReady {
+		return nil, domain.ErrRevisionNotReady
+	}
+
+	pending, err := svc.Repository.HasPending(ctx, video.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, domain.ErrRevisionPending
+	}
+
+	required := []string{
+		filepath.Join(rawRevisionDir(svc.videos.Config, video, number), "source.mp4"),
+		filepath.Join(convertedRevisionDir(svc.videos.Config, video, number), "index.m3u8"),
+	}
+	for _, p := range required {
+		if _, err := os.Stat(p); err != nil {
+			svc.log.Warn("revision files are missing", zap.String("path", p), zap.Error(err))
+			return nil, domain.ErrRevisionNotReady
+		}
+	}
+
+	if err := svc.videos.Repository.SwitchRevision(ctx, revision); err != nil {
+		return nil, err
+	}
+	svc.videos.recordAudit(ctx, video, domain.AuditRolledBack, principal.Actor())
+
+	return svc.videos.Repository.GetById(ctx, video.ID)
+}
+
+func (svc *RevisionService) storeSource(ctx context.Context, header *multipart.FileHeader, dir string) (sql.NullInt32, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return sql.NullInt32{}, err
+	}
+	destPath := filepath.Join(dir, "source.mp4")
+
+	file, err := header.Open()
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+	defer file.Close()
+
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+	defer dest.Close()
+
+	if _, err := io.Copy(dest, file); err != nil {
+		return sql.NullInt32{}, err
+	}
+
+	duration, err := util.ProbeDuration(ctx, destPath)
+	if err != nil {
+		svc.log.Error("ffprobe for duration failed", zap.Error(err), zap.String("path", destPath))
+		return sql.NullInt32{}, fmt.Errorf("%w: %v", domain.ErrUnsupportedMedia, err)
+	}
+	return sql.NullInt32{Int32: int32(math.Round(duration.Seconds())), Valid: true}, nil
+}
+
+var RevisionModule = fx.Module("revision-service", fx.Provide(NewRevisionService))
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// upload builds the file header of a multipart upload of content.
+func upload(t *testing.T, filename string, content string) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write([]byte(content))
+	_ = w.Close()
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+func TestReplaceSourceAndRollback(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	svc := NewRevisionService(env.revisions, env.videos, env.log)
+	owner := env.createUser(t, "owner@example.com")
+	tenSeconds := sql.NullInt32{Int32: 10, Valid: true}
+
+	video := env.createVideo(t, domain.Video{Slug: "replace1", Filename: "first.mp4", OwnerID: &owner.UserID, DurationS: tenSeconds})
+	if err := env.conversions.handleJob(ctx, video.Slug); err != nil {
+		t.Fatalf("convert first source: %v", err)
+	}
+	if first, err := env.revisions.Get(ctx, video.ID, 1); err != nil || first.Status != domain.RevisionReady {
+		t.Fatalf("first revision = %+v, %v, want ready", first, err)
+	}
+
+	// a source that cannot be probed fails without blocking later uploads
+	if _, err := svc.ReplaceSource(ctx, owner, video.ID, upload(t, "broken.mp4", "not a video")); !errors.Is(err, domain.ErrUnsupportedMedia) {
+		t.Fatalf("replace with a broken source: error = %v, want ErrUnsupportedMedia", err)
+	}
+	failed, err := env.revisions.Get(ctx, video.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed.Status != domain.RevisionFailed {
+		t.Errorf("broken revision is %s, want failed", failed.Status)
+	}
+	if exists(t, rawRevisionDir(env.cfg, video, 2)) {
+		t.Error("source of the broken revision is left behind")
+	}
+	if pending, err := env.revisions.HasPending(ctx, video.ID); err != nil || pending {
+		t.Errorf("pending = %v, %v after a failed replacement", pending, err)
+	}
+
+	// a replacement as ReplaceSource leaves it once the source is probed
+	replacement := &domain.VideoRevision{VideoID: video.ID, Filename: "second.mp4", DurationS: tenSeconds, Status: domain.RevisionPending}
+	if err := env.revisions.Insert(ctx, replacement); err != nil {
+		t.Fatal(err)
+	}
+	if replacement.Number != 3 {
+		t.Fatalf("replacement is revision %d, want 3", replacement.Number)
+	}
+	writeFile(t, filepath.Join(rawRevisionDir(env.cfg, video, 3), "source.mp4"), "second")
+
+	if _, err := svc.ReplaceSource(ctx, owner, video.ID, upload(t, "third.mp4", "third")); !errors.Is(err, domain.ErrRevisionPending) {
+		t.Errorf("replace while another source is pending: error = %v, want ErrRevisionPending", err)
+	}
+
+	if err := env.conversions.handleRevision(ctx, video.Slug, 3); err != nil {
+		t.Fatalf("convert replacement: %v", err)
+	}
+	switched, err := env.repo.GetById(ctx, video.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if switched.Revision != 3 || switched.Filename != "second.mp4" || switched.Status != string(domain.StatusComplete) {
+		t.Errorf("after conversion the video is at revision %d of %s, %s; want 3 of second.mp4, complete", switched.Revision, switched.Filename, switched.Status)
+	}
+	for _, n := range []int{1, 3} {
+		if !exists(t, filepath.Join(convertedRevisionDir(env.cfg, video, n), "index.m3u8")) {
+			t.Errorf("output of revision %d is missing", n)
+		}
+	}
+
+	// no rollback while a new source is still being converted
+	upcoming := &domain.VideoRevision{VideoID: video.ID, Filename: "fourth.mp4", Status: domain.RevisionPending}
+	if err := env.revisions.Insert(ctx, upcoming); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Rollback(ctx, owner, video.ID, 1); !errors.Is(err, domain.ErrRevisionPending) {
+		t.Errorf("rollback while a source is pending: error = %v, want ErrRevisionPending", err)
+	}
+	if err := env.revisions.SetFailed(ctx, video.ID, upcoming.Number, errors.New("abandoned")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Rollback(ctx, owner, video.ID, 2); !errors.Is(err, domain.ErrRevisionNotReady) {
+		t.Errorf("rollback to the broken revision: error = %v, want ErrRevisionNotReady", err)
+	}
+	if _, err := svc.Rollback(ctx, owner, video.ID, 9); !errors.Is(err, domain.ErrRevisionNotFound) {
+		t.Errorf("rollback to a missing revision: error = %v, want ErrRevisionNotFound", err)
+	}
+
+	rolledBack, err := svc.Rollback(ctx, owner, video.ID, 1)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if rolledBack.Revision != 1 || rolledBack.Filename != "first.mp4" {
+		t.Errorf("rolled back to revision %d of %s, want 1 of first.mp4", rolledBack.Revision, rolledBack.Filename)
+	}
+	entries, err := env.videos.Audit.GetByVideoId(ctx, video.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(entries, func(e domain.AuditEntry) bool {
+		return e.Action == string(domain.AuditRolledBack) && e.Actor == owner.Actor()
+	}) {
+		t.Errorf("audit trail = %+v, want a rollback by the owner", entries)
+	}
+
+	// a revision whose output was removed cannot be switched back to
+	if err := os.RemoveAll(convertedRevisionDir(env.cfg, video, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Rollback(ctx, owner, video.ID, 3); !errors.Is(err, domain.ErrRevisionNotReady) {
+		t.Errorf("rollback without output: error = %v, want ErrRevisionNotReady", err)
+	}
+	if current, err := env.repo.GetById(ctx, video.ID); err != nil || current.Revision != 1 {
+		t.Errorf("video = %+v, %v, want it left at revision 1", current, err)
+	}
+}
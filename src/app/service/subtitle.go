@@ -0,0 +1,150 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/hls"
+	"awesomeProject/src/app/repository"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"unicode/utf8"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// SubtitleService manages the caption tracks of videos. Uploads are
+// converted to WebVTT once; renditions are cut from it on delivery.
+type SubtitleService struct {
+	Repository *repository.SubtitleRepository
+	videos     *VideoService
+	log        *zap.Logger
+}
+
+type PutSubtitleParams struct {
+	Language string
+	Label    string
+	Default  bool
+	File     *multipart.FileHeader
+	Version  int
+}
+
+func NewSubtitleService(repo *repository.SubtitleRepository, videos *VideoService, logger *zap.Logger) *SubtitleService {
+	return &SubtitleService{
+		Repository: repo,
+		videos:     videos,
+		log:        logger,
+	}
+}
+
+// Put stores params.File as the track of its language, replacing an earlier
+// upload. It returns the updated video together with the track.
+func (svc *SubtitleService) Put(ctx context.Context, principal domain.Principal, id string, params PutSubtitleParams) (*domain.Video, *domain.Subtitle, error) {
+	video, err := svc.videos.GetVideo(ctx, principal, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if video.ArchivedAt != nil {
+		return nil, nil, domain.ErrVideoArchived
+	}
+
+	language, err := domain.NormalizeLanguage(params.Language)
+	if err != nil {
+		return nil, nil, err
+	}
+	label, err := domain.NormalizeSubtitleLabel(params.Label, language)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := readSubtitleFile(params.File)
+	if err != nil {
+		return nil, nil, err
+	}
+	cues, err := hls.ParseSubtitles(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domain.ErrInvalidSubtitles, err)
+	}
+
+	format := domain.SubtitleSRT
+	if hls.IsWebVTT(data) {
+		format = domain.SubtitleWebVTT
+	}
+
+	subtitle := &domain.Subtitle{
+		VideoID:   video.ID,
+		Language:  language,
+		Label:     label,
+		Format:    format,
+		IsDefault: params.Default,
+		CueCount:  len(cues),
+		Content:   string(hls.WriteSubtitles(cues)),
+	}
+	if err := svc.Repository.Put(ctx, subtitle, params.Version); err != nil {
+		return nil, nil, err
+	}
+	svc.videos.recordAudit(ctx, video, domain.AuditSubtitlesSet, principal.Actor())
+
+	updated, err := svc.videos.Repository.GetById(ctx, video.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, subtitle, nil
+}
+
+func (svc *SubtitleService) Delete(ctx context.Context, principal domain.Principal, id string, language string, version int) (*domain.Video, error) {
+	video, err := svc.videos.GetVideo(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if video.ArchivedAt != nil {
+		return nil, domain.ErrVideoArchived
+	}
+
+	language, err = domain.NormalizeLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.Repository.Delete(ctx, video.ID, language, version); err != nil {
+		return nil, err
+	}
+	svc.videos.recordAudit(ctx, video, domain.AuditSubtitlesRemoved, principal.Actor())
+
+	return svc.videos.Repository.GetById(ctx, video.ID)
+}
+
+// Cues loads a stored track for delivery.
+func (svc *SubtitleService) Cues(ctx context.Context, videoId string, language string) ([]hls.SubtitleCue, error) {
+	subtitle, err := svc.Repository.Get(ctx, videoId, language)
+	if err != nil {
+		return nil, err
+	}
+	return hls.ParseSubtitles([]byte(subtitle.Content))
+}
+
+func readSubtitleFile(header *multipart.FileHeader) ([]byte, error) {
+	if header.Size > domain.MaxSubtitleBytes {
+		return nil, &domain.ValidationError{Field: "file", Message: fmt.Sprintf("must be at most %d bytes", domain.MaxSubtitleBytes)}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, domain.MaxSubtitleBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > domain.MaxSubtitleBytes {
+		return nil, &domain.ValidationError{Field: "file", Message: fmt.Sprintf("must be at most %d bytes", domain.MaxSubtitleBytes)}
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%w: file is not utf-8 encoded", domain.ErrInvalidSubtitles)
+	}
+	return data, nil
+}
+
+var SubtitleModule = fx.Module("subtitle-service", fx.Provide(NewSubtitleService))
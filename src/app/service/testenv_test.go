@@ -0,0 +1,130 @@
+package service
+
+import (
+	"awesomeProject/src/app/cache"
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/hls"
+	"awesomeProject/src/app/repository"
+	"awesomeProject/src/app/testdb"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// testEnv is the video service wired to a test database, with the data
+// directories in a temporary directory.
+type testEnv struct {
+	cfg         *config.Config
+	db          *gorm.DB
+	log         *zap.Logger
+	repo        *repository.VideoRepository
+	revisions   *repository.RevisionRepository
+	videos      *VideoService
+	conversions *ConversionService
+}
+
+// fakePackager writes a playlist of one ten second segment instead of
+// running ffmpeg.
+type fakePackager struct{}
+
+func (fakePackager) PackageHLS(ctx context.Context, inPath string, outDir string, progress hls.ProgressFunc) error {
+	if _, err := os.Stat(inPath); err != nil {
+		return err
+	}
+	playlist := "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10.0,\nseg0.ts\n#EXT-X-ENDLIST\n"
+	if err := os.WriteFile(filepath.Join(outDir, "index.m3u8"), []byte(playlist), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "seg0.ts"), []byte("segment"), 0o644)
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := testdb.Open(t)
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Data:   config.DataConfig{SlugLength: 8, DataDir: dir, ArchiveDir: filepath.Join(dir, "archive"), RawDir: filepath.Join(dir, "raw")},
+		Conv:   config.ConversionConfig{TmpDir: filepath.Join(dir, "tmp"), ConvDir: filepath.Join(dir, "converted"), Parallel: 1},
+		Cache:  config.CacheConfig{DefaultExpiration: time.Minute, DefaultFrequency: time.Minute},
+		Search: config.SearchConfig{Language: "simple"},
+	}
+	log := zap.NewNop()
+
+	repo := repository.NewVideoRepository(db, log, cache.NewVideoCache(cfg, log), cfg)
+	revisions := repository.NewRevisionRepository(db, log)
+	webhooks := NewWebhookService(cfg, repository.NewWebhookRepository(db, log), repo, log)
+	// jobs are queued but not picked up; tests run them with handleJob
+	conversions := NewConversionService(cfg, repo, revisions, webhooks, fakePackager{}, log)
+	videos := NewVideoService(repo, repository.NewAuditRepository(db, log), revisions, cfg, conversions, webhooks, log)
+
+	return &testEnv{cfg: cfg, db: db, log: log, repo: repo, revisions: revisions, videos: videos, conversions: conversions}
+}
+
+// createVideo stores video and writes a source file for it in the raw
+// tree.
+func (env *testEnv) createVideo(t *testing.T, video domain.Video) *domain.Video {
+	t.Helper()
+	if video.Filename == "" {
+		video.Filename = video.Slug + ".mp4"
+	}
+	if video.CreatedAt.IsZero() {
+		video.CreatedAt = time.Now().UTC()
+	}
+	if _, err := env.repo.Insert(context.Background(), &video); err != nil {
+		t.Fatalf("insert video: %v", err)
+	}
+	writeFile(t, filepath.Join(rawDir(env.cfg, &video), "source.mp4"), "source")
+	return &video
+}
+
+// createUser stores a user and returns the principal they act as.
+func (env *testEnv) createUser(t *testing.T, email string) domain.Principal {
+	t.Helper()
+	user := &domain.User{Email: email, PasswordHash: "-"}
+	if err := env.db.Create(user).Error; err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return domain.Principal{UserID: user.ID, Role: domain.RoleUser}
+}
+
+// archive marks video as archived at archivedAt and moves its source into
+// the archive directory, as VideoService.Archive does.
+func (env *testEnv) archive(t *testing.T, video *domain.Video, archivedAt time.Time) {
+	t.Helper()
+	if err := env.videos.archiveSource(video); err != nil {
+		t.Fatal(err)
+	}
+	err := env.db.Model(&domain.Video{}).Where("id = ?", video.ID).
+		Updates(map[string]any{"archived_at": archivedAt, "status": string(domain.StatusArchived)}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.repo.Cache.Delete(video.ID)
+	video.ArchivedAt = &archivedAt
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(t *testing.T, path string) bool {
+	t.Helper()
+	_, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
@@ -3,17 +3,20 @@ package service
 import (
 	"awesomeProject/src/app/config"
 	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/hls"
 	"awesomeProject/src/app/repository"
 	"awesomeProject/src/util"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"math"
 	"mime/multipart"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"go.uber.org/fx"
@@ -24,37 +27,159 @@ type VideoService struct {
 	Config     *config.Config
 	Repository *repository.VideoRepository
 	HlsService *ConversionService
+	Audit      *repository.AuditRepository
+	Revisions  *repository.RevisionRepository
+	Webhooks   *WebhookService
 	log        *zap.Logger
 }
 
-func NewVideoService(repo *repository.VideoRepository, config *config.Config, convService *ConversionService, log *zap.Logger) *VideoService {
+func NewVideoService(repo *repository.VideoRepository, audit *repository.AuditRepository, revisions *repository.RevisionRepository, config *config.Config, convService *ConversionService, webhooks *WebhookService, log *zap.Logger) *VideoService {
 	return &VideoService{
 		Config:     config,
 		Repository: repo,
 		HlsService: convService,
+		Audit:      audit,
+		Revisions:  revisions,
+		Webhooks:   webhooks,
 		log:        log,
 	}
 }
 
-func (service *VideoService) GetAllVideos(ctx context.Context, pagination domain.Pagination, status domain.ListFilter) (domain.ListPayload[domain.Video], error) {
-	payload, err := service.Repository.GetAll(ctx, pagination, status)
+func (service *VideoService) GetAllVideos(ctx context.Context, pagination domain.Pagination, query domain.VideoQuery) (domain.ListPayload[domain.Video], error) {
+	payload, err := service.Repository.GetAll(ctx, pagination, query)
 
 	return payload, err
 }
 
-func (service *VideoService) GetVideo(ctx context.Context, id string) (*domain.Video, error) {
+func (service *VideoService) GetVideo(ctx context.Context, principal domain.Principal, id string) (*domain.Video, error) {
 	video, err := service.Repository.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-	return video, err
+	if !principal.CanAccess(video) {
+		return nil, domain.ErrVideoNotFound
+	}
+
+	return video, nil
+}
+
+// GetPlayable resolves a video for media delivery; archived videos are
+// treated as missing.
+func (service *VideoService) GetPlayable(ctx context.Context, id string) (*domain.Video, error) {
+	video, err := service.Repository.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if video.ArchivedAt != nil {
+		return nil, domain.ErrVideoNotFound
+	}
+	return video, nil
+}
+
+// GetShared resolves a share link. Private videos are reported as missing to
+// callers who may not view them, so a slug does not reveal their existence.
+func (service *VideoService) GetShared(ctx context.Context, principal domain.Principal, slug string) (*domain.Video, error) {
+	video, err := service.Repository.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if video.ArchivedAt != nil {
+		return nil, domain.ErrVideoNotFound
+	}
+	if !principal.CanView(video) {
+		if principal.IsAnonymous() {
+			return nil, domain.ErrUnauthorized
+		}
+		return nil, domain.ErrVideoNotFound
+	}
+	return video, nil
 }
 
-func (service *VideoService) UpdateVideoTitle(ctx context.Context, id string, title string) (*domain.Video, error) {
-	video, err := service.Repository.UpdateById(ctx, id, map[string]interface{}{"filename": title + ".mp4"})
+func (service *VideoService) GetPublicVideos(ctx context.Context, pagination domain.Pagination, query domain.VideoQuery) (domain.ListPayload[domain.Video], error) {
+	query.Archive = domain.FilterActive
+	query.Visibility = []domain.Visibility{domain.VisibilityPublic}
+	query.Statuses = []domain.VideoStatus{domain.StatusComplete}
+	query.OwnerID = nil
+
+	return service.Repository.GetAll(ctx, pagination, query)
+}
+
+// UpdateMetadata applies meta to the video. A non-zero version makes the
+// update conditional on the stored version (see VideoRepository.UpdateById).
+func (service *VideoService) UpdateMetadata(ctx context.Context, id string, meta domain.VideoMetadata, version int) (*domain.Video, error) {
+	updates := map[string]interface{}{}
+
+	if meta.Title != nil {
+		title, err := domain.NormalizeTitle(*meta.Title)
+		if err != nil {
+			return nil, err
+		}
+		updates["title"] = title
+	}
+	if meta.Description != nil {
+		description, err := domain.NormalizeDescription(*meta.Description)
+		if err != nil {
+			return nil, err
+		}
+		updates["description"] = description
+	}
+	if meta.Visibility != nil {
+		visibility, err := domain.ParseVisibility(*meta.Visibility)
+		if err != nil {
+			return nil, err
+		}
+		updates["visibility"] = string(visibility)
+	}
+
+	var tags *[]string
+	if meta.Tags != nil {
+		normalized, err := domain.NormalizeTags(*meta.Tags)
+		if err != nil {
+			return nil, err
+		}
+		tags = &normalized
+	}
+
+	video, err := service.Repository.UpdateById(ctx, id, updates, tags, version)
 
 	return video, err
 }
 
-func (service *VideoService) Save(ctx context.Context, header *multipart.FileHeader) (string, error) {
+func (service *VideoService) Save(ctx context.Context, principal domain.Principal, header *multipart.FileHeader, meta domain.VideoMetadata) (string, error) {
+	title := domain.TitleFromFilename(header.Filename)
+	if meta.Title != nil {
+		title = *meta.Title
+	}
+	title, err := domain.NormalizeTitle(title)
+	if err != nil {
+		return "", err
+	}
+
+	var description string
+	if meta.Description != nil {
+		if description, err = domain.NormalizeDescription(*meta.Description); err != nil {
+			return "", err
+		}
+	}
+
+	visibility := domain.VisibilityPrivate
+	if meta.Visibility != nil {
+		if visibility, err = domain.ParseVisibility(*meta.Visibility); err != nil {
+			return "", err
+		}
+	}
+
+	var tags []domain.Tag
+	if meta.Tags != nil {
+		names, err := domain.NormalizeTags(*meta.Tags)
+		if err != nil {
+			return "", err
+		}
+		for _, name := range names {
+			tags = append(tags, domain.Tag{Name: name})
+		}
+	}
 
 	slug, err := util.RandomSlug(service.Config.Data.SlugLength)
 
@@ -99,28 +224,36 @@ func (service *VideoService) Save(ctx context.Context, header *multipart.FileHea
 		durationField = sql.NullInt32{Int32: seconds, Valid: true}
 	} else {
 		service.log.Error("ffprobe for duration failed", zap.Error(err), zap.String("slug", slug))
-		return "", err
+		return "", fmt.Errorf("%w: %v", domain.ErrUnsupportedMedia, err)
 
 	}
 
-	_, err = service.Repository.Insert(ctx, &domain.Video{
-		Filename:  header.Filename,
-		Slug:      slug,
-		SizeBytes: header.Size,
-		DurationS: durationField,
+	id, err := service.Repository.Insert(ctx, &domain.Video{
+		Filename:    header.Filename,
+		Title:       title,
+		Description: description,
+		Tags:        tags,
+		Slug:        slug,
+		SizeBytes:   header.Size,
+		DurationS:   durationField,
+		OwnerID:     &principal.UserID,
+		Visibility:  string(visibility),
 	})
 	if err != nil {
 		return "", err
 	}
 
+	service.Webhooks.Publish(ctx, domain.EventVideoUploaded, id)
 	service.HlsService.Enqueue(slug)
 	log.Println("enqueued worker for video", zap.String("slug", slug))
 
 	return destPath, nil
 }
 
-func (service *VideoService) Archive(ctx context.Context, id string) error {
-	video, err := service.Repository.GetById(ctx, id)
+// Archive moves the source of the video to the archive. A non-zero version
+// makes it conditional on the stored version, as in UpdateMetadata.
+func (service *VideoService) Archive(ctx context.Context, principal domain.Principal, id string, version int) error {
+	video, err := service.GetVideo(ctx, principal, id)
 
 	if err != nil {
 		log.Println("error getting file from db: ", err)
@@ -140,55 +273,184 @@ func (service *VideoService) Archive(ctx context.Context, id string) error {
 		return err
 	}
 
-	datePath := time.Now().Format("2006/01/02")
+	// the version is checked before the source is moved and again by the
+	// update, which may find the video changed during a long move
+	if version > 0 && video.Version != version {
+		return domain.ErrVersionMismatch
+	}
+	if err := service.archiveSource(video); err != nil {
+		log.Println("error archiving the file: ", err)
+		return err
+	}
+
+	if err := service.Repository.Archive(ctx, id, version); err != nil {
+		if undoErr := util.MoveFile(archivePath(service.Config, video), filepath.Join(rawDir(service.Config, video), "source.mp4")); undoErr != nil {
+			service.log.Error("failed to move the source back after a failed archive", zap.String("id", id), zap.Error(undoErr))
+		}
+		return err
+	}
+	if err := os.RemoveAll(rawDir(service.Config, video)); err != nil {
+		service.log.Warn("failed to remove the raw directory of an archived video", zap.String("id", id), zap.Error(err))
+	}
+
+	service.recordAudit(ctx, video, domain.AuditArchived, principal.Actor())
+	service.Webhooks.Publish(ctx, domain.EventVideoArchived, video.ID)
+
+	return nil
+}
 
-	//Достаем из raw/.../slug/source.mp4
-	oldPath := filepath.Join(service.Config.Data.RawDir, datePath, video.Slug, "source.mp4")
+// archiveSource moves the source into the archive directory. The raw
+// directory stays until the archive is recorded, so the move can be undone.
+func (service *VideoService) archiveSource(video *domain.Video) error {
+	if err := os.MkdirAll(service.Config.Data.ArchiveDir, 0o755); err != nil {
+		return err
+	}
+	return util.MoveFile(filepath.Join(rawDir(service.Config, video), "source.mp4"), archivePath(service.Config, video))
+}
 
-	originalFile, err := os.Open(oldPath)
+// Restore moves an archived source back into the raw tree. The HLS output is
+// kept on archive, so a converted video becomes playable again immediately.
+func (service *VideoService) Restore(ctx context.Context, principal domain.Principal, id string) error {
+	video, err := service.GetVideo(ctx, principal, id)
 	if err != nil {
-		log.Println("error opening the file: ", err)
 		return err
 	}
-	defer originalFile.Close()
+	if video.ArchivedAt == nil {
+		return domain.ErrVideoNotArchived
+	}
 
-	//Кладем в archive/slug
-	ext := strings.ToLower(filepath.Ext(video.Filename))
-	newName := video.Slug + ext
-	newPath := filepath.Join(service.Config.Data.ArchiveDir, newName)
+	dir := rawDir(service.Config, video)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	source := filepath.Join(dir, "source.mp4")
+	if err := util.MoveFile(archivePath(service.Config, video), source); err != nil {
+		return err
+	}
 
-	newFile, err := os.Create(newPath)
-	if err != nil {
-		log.Println("we here!")
-		log.Println(newPath)
-		log.Println("error creating new file: ", err)
+	status := domain.StatusUploaded
+	if video.HLSReadyAt != nil {
+		status = domain.StatusComplete
+	}
+	if err := service.Repository.Restore(ctx, id, status); err != nil {
+		// the row still says archived, so the source belongs in the archive
+		if undoErr := util.MoveFile(source, archivePath(service.Config, video)); undoErr != nil {
+			service.log.Error("failed to move the source back after a failed restore", zap.String("id", id), zap.Error(undoErr))
+		}
 		return err
 	}
-	defer newFile.Close()
 
-	_, err = io.Copy(newFile, originalFile)
+	service.recordAudit(ctx, video, domain.AuditRestored, principal.Actor())
+	return nil
+}
+
+// Reprocess queues the source for conversion again; the current HLS output
+// keeps being served until the new one replaces it.
+func (service *VideoService) Reprocess(ctx context.Context, principal domain.Principal, id string) error {
+	video, err := service.GetVideo(ctx, principal, id)
 	if err != nil {
-		log.Println("error copying file: ", err)
+		return err
+	}
+	if video.ArchivedAt != nil {
+		return domain.ErrVideoArchived
+	}
+	if video.IsProcessing() {
+		return domain.ErrVideoIsProcessing
+	}
+	if _, err := os.Stat(filepath.Join(rawDir(service.Config, video), "source.mp4")); err != nil {
 		return err
 	}
 
-	originalFile.Close()
-
-	oldDir := filepath.Join(service.Config.Data.RawDir, datePath, video.Slug)
+	service.HlsService.Enqueue(video.Slug)
+	service.recordAudit(ctx, video, domain.AuditReprocessed, principal.Actor())
+	return nil
+}
 
-	if err := os.RemoveAll(oldDir); err != nil {
-		log.Println("error removing old dir:", err)
+// Purge deletes the video and all of its files. A non-zero version makes it
+// conditional on the stored version.
+func (service *VideoService) Purge(ctx context.Context, id string, actor string, version int) error {
+	video, err := service.Repository.GetById(ctx, id)
+	if err != nil {
 		return err
 	}
 
-	err = service.Repository.Archive(ctx, id)
+	if video.IsProcessing() {
+		return domain.ErrVideoIsProcessing
+	}
 
+	revisions, err := service.Revisions.GetByVideo(ctx, id)
 	if err != nil {
-		log.Println("error archiving the file: ", err)
 		return err
 	}
 
+	paths := []string{
+		archivePath(service.Config, video),
+		convertedDir(service.Config, video),
+		rawDir(service.Config, video),
+	}
+	for _, revision := range revisions {
+		if revision.Number != video.Revision {
+			paths = append(paths,
+				convertedRevisionDir(service.Config, video, revision.Number),
+				rawRevisionDir(service.Config, video, revision.Number))
+		}
+	}
+	// the row goes first: files left behind by a failed removal are
+	// orphans that reconcile collects, while a row without files is broken
+	if err := service.Repository.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := os.RemoveAll(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			service.log.Error("failed to remove video files", zap.String("id", id), zap.String("path", p), zap.Error(err))
+		}
+	}
+
+	service.recordAudit(ctx, video, domain.AuditPurged, actor)
+	service.log.Info("video purged", zap.String("id", id), zap.String("actor", actor))
+
 	return nil
 }
 
+func (service *VideoService) Verify(ctx context.Context, principal domain.Principal, id string) (*hls.VerifyReport, error) {
+	video, err := service.GetVideo(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if video.IsProcessing() {
+		return nil, domain.ErrVideoIsProcessing
+	}
+
+	report, err := hls.Verify(convertedDir(service.Config, video), "index.m3u8", expectedDuration(video.DurationS))
+	if err != nil && !errors.Is(err, hls.ErrInvalidOutput) {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (service *VideoService) StorageStats(ctx context.Context, top int) (*domain.StorageStats, error) {
+	return service.Repository.StorageStats(ctx, top)
+}
+
+func (service *VideoService) recordAudit(ctx context.Context, video *domain.Video, action domain.AuditAction, actor string) {
+	raw, err := json.Marshal(map[string]any{
+		"filename":   video.Filename,
+		"slug":       video.Slug,
+		"sizeBytes":  video.SizeBytes,
+		"archivedAt": video.ArchivedAt,
+	})
+	var details *string
+	if err == nil {
+		d := string(raw)
+		details = &d
+	}
+
+	_ = service.Audit.Record(ctx, &domain.AuditEntry{
+		VideoID: video.ID,
+		Action:  string(action),
+		Actor:   actor,
+		Details: details,
+	})
+}
+
 var VideoModule = fx.Module("video-service", fx.Provide(NewVideoService))
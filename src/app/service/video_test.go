@@ -0,0 +1,187 @@
+package service
+
+import (
+	"awesomeProject/src/app/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPurge(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	video := env.createVideo(t, domain.Video{Slug: "purge001", Title: "Purged"})
+	env.archive(t, video, time.Now().UTC())
+	// an older source that was replaced, with output of its own
+	if err := env.db.Create(&domain.VideoRevision{VideoID: video.ID, Number: 2, Filename: "old.mp4", Status: domain.RevisionReady}).Error; err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(convertedDir(env.cfg, video), "index.m3u8"), "#EXTM3U")
+	writeFile(t, filepath.Join(rawRevisionDir(env.cfg, video, 2), "source.mp4"), "old")
+	writeFile(t, filepath.Join(convertedRevisionDir(env.cfg, video, 2), "index.m3u8"), "#EXTM3U")
+
+	paths := []string{
+		archivePath(env.cfg, video),
+		convertedDir(env.cfg, video),
+		rawRevisionDir(env.cfg, video, 2),
+		convertedRevisionDir(env.cfg, video, 2),
+	}
+
+	stored, err := env.repo.GetById(ctx, video.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.videos.Purge(ctx, video.ID, "user:admin", stored.Version+1); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Fatalf("purge of another version: error = %v, want ErrVersionMismatch", err)
+	}
+	for _, p := range paths {
+		if !exists(t, p) {
+			t.Fatalf("%s was removed by a purge that did not happen", p)
+		}
+	}
+
+	if err := env.videos.Purge(ctx, video.ID, "user:admin", stored.Version); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if _, err := env.repo.GetById(ctx, video.ID); !errors.Is(err, domain.ErrVideoNotFound) {
+		t.Errorf("purged video is still found: %v", err)
+	}
+	for _, p := range append(paths, rawDir(env.cfg, video)) {
+		if exists(t, p) {
+			t.Errorf("%s is left behind", p)
+		}
+	}
+
+	entries, err := env.videos.Audit.GetByVideoId(ctx, video.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != string(domain.AuditPurged) || entries[0].Actor != "user:admin" {
+		t.Errorf("audit trail = %+v, want one purge by user:admin", entries)
+	}
+}
+
+func TestPurgeRefusesVideosBeingProcessed(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	video := env.createVideo(t, domain.Video{Slug: "purge002"})
+	if err := env.repo.SetProcessing(ctx, video.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.videos.Purge(ctx, video.ID, "user:admin", 0); !errors.Is(err, domain.ErrVideoIsProcessing) {
+		t.Fatalf("error = %v, want ErrVideoIsProcessing", err)
+	}
+	if !exists(t, filepath.Join(rawDir(env.cfg, video), "source.mp4")) {
+		t.Error("source of a video being processed was removed")
+	}
+}
+
+func TestStorageStats(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	converted := func(n int64) *int64 { return &n }
+
+	env.createVideo(t, domain.Video{Slug: "stats001", SizeBytes: 100, DurationS: sql.NullInt32{Int32: 10, Valid: true}})
+	complete := env.createVideo(t, domain.Video{Slug: "stats002", SizeBytes: 200, DurationS: sql.NullInt32{Int32: 20, Valid: true}})
+	if err := env.repo.SetReady(ctx, complete.ID, time.Now(), converted(50)); err != nil {
+		t.Fatal(err)
+	}
+	archived := env.createVideo(t, domain.Video{Slug: "stats003", SizeBytes: 300, DurationS: sql.NullInt32{Int32: 30, Valid: true}})
+	if err := env.repo.SetReady(ctx, archived.ID, time.Now(), converted(70)); err != nil {
+		t.Fatal(err)
+	}
+	env.archive(t, archived, time.Now().UTC())
+
+	stats, err := env.videos.StorageStats(ctx, 2)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+
+	usage := []struct {
+		name  string
+		got   domain.StorageUsage
+		total int64
+		by    map[string]int64
+	}{
+		{"raw", stats.Raw, 300, map[string]int64{"uploaded": 100, "complete": 200, "archived": 0}},
+		{"converted", stats.Converted, 120, map[string]int64{"uploaded": 0, "complete": 50, "archived": 70}},
+		{"archived", stats.Archived, 300, map[string]int64{"uploaded": 0, "complete": 0, "archived": 300}},
+	}
+	for _, u := range usage {
+		if u.got.TotalBytes != u.total || !maps.Equal(u.got.ByStatus, u.by) {
+			t.Errorf("%s = %+v, want %d bytes by %v", u.name, u.got, u.total, u.by)
+		}
+	}
+	if want := map[string]int64{"uploaded": 1, "complete": 1, "archived": 1}; !maps.Equal(stats.Counts, want) || stats.TotalCount != 3 {
+		t.Errorf("counts = %v of %d, want %v of 3", stats.Counts, stats.TotalCount, want)
+	}
+	if stats.TotalDurationS != 60 {
+		t.Errorf("total duration = %d, want 60", stats.TotalDurationS)
+	}
+
+	var largest []string
+	for _, e := range stats.Largest {
+		largest = append(largest, fmt.Sprintf("%s:%d", e.Slug, e.TotalBytes))
+	}
+	if want := []string{"stats003:370", "stats002:250"}; !slices.Equal(largest, want) {
+		t.Errorf("largest = %v, want %v", largest, want)
+	}
+}
+
+func TestUpdateMetadataIsConditionalOnVersion(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	title := func(s string) domain.VideoMetadata { return domain.VideoMetadata{Title: &s} }
+
+	video := env.createVideo(t, domain.Video{Slug: "version1", Title: "Draft"})
+
+	updated, err := env.videos.UpdateMetadata(ctx, video.ID, title("First"), 1)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Title != "First" || updated.Version != 2 {
+		t.Errorf("updated to %q at version %d, want First at 2", updated.Title, updated.Version)
+	}
+
+	// a second editor still holding version 1
+	if _, err := env.videos.UpdateMetadata(ctx, video.ID, title("Second"), 1); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Fatalf("stale update: error = %v, want ErrVersionMismatch", err)
+	}
+	stored, err := env.repo.GetById(ctx, video.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title != "First" || stored.Version != 2 {
+		t.Errorf("stale update changed the video to %q at version %d", stored.Title, stored.Version)
+	}
+
+	if updated, err = env.videos.UpdateMetadata(ctx, video.ID, title("Third"), 0); err != nil {
+		t.Fatalf("unconditional update: %v", err)
+	}
+	if updated.Version != 3 {
+		t.Errorf("unconditional update: version %d, want 3", updated.Version)
+	}
+	if _, err := env.videos.UpdateMetadata(ctx, "7f1c1d3a-8d0e-4a58-9d43-2d7f0d1c9a10", title("Missing"), 1); !errors.Is(err, domain.ErrVideoNotFound) {
+		t.Errorf("update of a missing video: error = %v, want ErrVideoNotFound", err)
+	}
+
+	principal := domain.Principal{Role: domain.RoleAdmin}
+	if err := env.videos.Archive(ctx, principal, video.ID, 2); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("stale archive: error = %v, want ErrVersionMismatch", err)
+	}
+	if !exists(t, filepath.Join(rawDir(env.cfg, video), "source.mp4")) {
+		t.Error("stale archive moved the source")
+	}
+	if err := env.videos.Archive(ctx, principal, video.ID, 3); err != nil {
+		t.Errorf("archive: %v", err)
+	}
+}
@@ -0,0 +1,472 @@
+package service
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"awesomeProject/src/app/repository"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookBatchSize    = 20
+	webhookSecretBytes  = 24
+	minWebhookSecretLen = 16
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = time.Hour
+)
+
+// WebhookService notifies subscribed endpoints about video lifecycle events.
+// Events are stored as deliveries first and sent by a background dispatcher,
+// so a slow or failing receiver never holds up the code that emitted them.
+type WebhookService struct {
+	Repository *repository.WebhookRepository
+	videos     *repository.VideoRepository
+	config     *config.Config
+	client     *http.Client
+	log        *zap.Logger
+
+	wake chan struct{}
+
+	runCtx context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type CreateWebhookParams struct {
+	URL         string
+	Events      []string
+	Secret      string
+	Description string
+	CreatedBy   string
+}
+
+type UpdateWebhookParams struct {
+	URL         *string
+	Events      *[]string
+	Active      *bool
+	Description *string
+}
+
+func NewWebhookService(cfg *config.Config, repo *repository.WebhookRepository, videos *repository.VideoRepository, logger *zap.Logger) *WebhookService {
+	return &WebhookService{
+		Repository: repo,
+		videos:     videos,
+		config:     cfg,
+		client: &http.Client{
+			Timeout:   cfg.Webhook.Timeout,
+			Transport: webhookTransport(cfg.Webhook.AllowedCIDRs),
+			// a redirect is reported as a failed attempt rather than followed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		log:  logger,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+func (svc *WebhookService) GetAll(ctx context.Context) ([]domain.Webhook, error) {
+	return svc.Repository.GetAll(ctx)
+}
+
+func (svc *WebhookService) Get(ctx context.Context, id string) (*domain.Webhook, error) {
+	return svc.Repository.GetById(ctx, id)
+}
+
+// Create generates a secret unless one is given. The secret is returned in
+// the webhook and never shown again afterwards.
+func (svc *WebhookService) Create(ctx context.Context, params CreateWebhookParams) (*domain.Webhook, error) {
+	url, err := domain.ValidateWebhookURL(params.URL, svc.config.Webhook.AllowedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	events, err := domain.ParseWebhookEvents(params.Events)
+	if err != nil {
+		return nil, err
+	}
+	description, err := domain.NormalizeWebhookDescription(params.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := params.Secret
+	switch {
+	case secret == "":
+		if secret, err = randomToken(webhookSecretBytes); err != nil {
+			return nil, err
+		}
+	case len(secret) < minWebhookSecretLen:
+		return nil, &domain.ValidationError{Field: "secret", Message: fmt.Sprintf("must be at least %d characters", minWebhookSecretLen)}
+	}
+
+	webhook := &domain.Webhook{
+		URL:         url,
+		Description: description,
+		Events:      events,
+		Secret:      secret,
+		Active:      true,
+	}
+	if params.CreatedBy != "" {
+		webhook.CreatedBy = &params.CreatedBy
+	}
+	if err := svc.Repository.Insert(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (svc *WebhookService) Update(ctx context.Context, id string, params UpdateWebhookParams) (*domain.Webhook, error) {
+	updates := map[string]any{}
+	if params.URL != nil {
+		url, err := domain.ValidateWebhookURL(*params.URL, svc.config.Webhook.AllowedCIDRs)
+		if err != nil {
+			return nil, err
+		}
+		updates["url"] = url
+	}
+	if params.Events != nil {
+		events, err := domain.ParseWebhookEvents(*params.Events)
+		if err != nil {
+			return nil, err
+		}
+		updates["events"] = pq.StringArray(events)
+	}
+	if params.Description != nil {
+		description, err := domain.NormalizeWebhookDescription(*params.Description)
+		if err != nil {
+			return nil, err
+		}
+		updates["description"] = description
+	}
+	if params.Active != nil {
+		updates["active"] = *params.Active
+	}
+	return svc.Repository.Update(ctx, id, updates)
+}
+
+func (svc *WebhookService) Delete(ctx context.Context, id string) error {
+	return svc.Repository.Delete(ctx, id)
+}
+
+func (svc *WebhookService) GetDeliveries(ctx context.Context, webhookId string) ([]domain.WebhookDelivery, error) {
+	if _, err := svc.Repository.GetById(ctx, webhookId); err != nil {
+		return nil, err
+	}
+	return svc.Repository.GetDeliveries(ctx, webhookId)
+}
+
+// Redeliver queues the event of an earlier delivery again as a new delivery
+// with a fresh set of attempts. The payload and event ID are unchanged, so
+// receivers can recognize the repeat.
+func (svc *WebhookService) Redeliver(ctx context.Context, webhookId, deliveryId string) (*domain.WebhookDelivery, error) {
+	webhook, err := svc.Repository.GetById(ctx, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Active {
+		return nil, domain.ErrWebhookDisabled
+	}
+	previous, err := svc.Repository.GetDelivery(ctx, webhookId, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := domain.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventID:       previous.EventID,
+		Event:         previous.Event,
+		Payload:       previous.Payload,
+		Status:        domain.DeliveryPending,
+		NextAttemptAt: time.Now().UTC(),
+	}
+	deliveries := []domain.WebhookDelivery{delivery}
+	if err := svc.Repository.InsertDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+	svc.notify()
+
+	return &deliveries[0], nil
+}
+
+// Publish queues event about a video for every subscribed webhook. Failures
+// are logged rather than returned: the change that triggered the event has
+// already happened and must not be reported as failed.
+func (svc *WebhookService) Publish(ctx context.Context, event domain.WebhookEvent, videoId string) {
+	ctx = context.WithoutCancel(ctx)
+	if err := svc.publish(ctx, event, videoId); err != nil {
+		svc.log.Error("failed to publish webhook event", zap.String("event", string(event)), zap.String("videoId", videoId), zap.Error(err))
+	}
+}
+
+func (svc *WebhookService) publish(ctx context.Context, event domain.WebhookEvent, videoId string) error {
+	webhooks, err := svc.Repository.GetSubscribed(ctx, event)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	video, err := svc.videos.GetById(ctx, videoId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	payload := domain.WebhookPayload{
+		ID:        uuid.NewString(),
+		Type:      event,
+		CreatedAt: now,
+		Video:     video.ToDto(),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(webhooks))
+	for _, w := range webhooks {
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			WebhookID:     w.ID,
+			EventID:       payload.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	if err := svc.Repository.InsertDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	svc.notify()
+	return nil
+}
+
+func (svc *WebhookService) notify() {
+	select {
+	case svc.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (svc *WebhookService) Start() {
+	svc.done = make(chan struct{})
+
+	go func() {
+		defer close(svc.done)
+
+		ticker := time.NewTicker(svc.config.Webhook.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			svc.dispatch()
+			select {
+			case <-svc.runCtx.Done():
+				return
+			case <-svc.wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// dispatch sends due deliveries batch by batch until none are left.
+func (svc *WebhookService) dispatch() {
+	for svc.runCtx.Err() == nil {
+		deliveries, err := svc.Repository.ClaimDue(svc.runCtx, time.Now().UTC(), svc.lease(), webhookBatchSize)
+		if err != nil {
+			if svc.runCtx.Err() == nil {
+				svc.log.Error("failed to claim webhook deliveries", zap.Error(err))
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			wg.Add(1)
+			go func(delivery *domain.WebhookDelivery) {
+				defer wg.Done()
+				svc.attempt(delivery)
+			}(&deliveries[i])
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// lease is how long a claimed delivery is hidden from other dispatchers; it
+// has to outlast the request timeout.
+func (svc *WebhookService) lease() time.Duration {
+	return max(2*svc.config.Webhook.Timeout, time.Minute)
+}
+
+func (svc *WebhookService) attempt(delivery *domain.WebhookDelivery) {
+	var (
+		code int
+		body []byte
+		err  error
+	)
+	if delivery.Webhook == nil || !delivery.Webhook.Active {
+		err = domain.ErrWebhookDisabled
+	} else {
+		code, body, err = svc.send(svc.runCtx, delivery)
+	}
+	if svc.runCtx.Err() != nil {
+		// interrupted by shutdown; the lease expires and the attempt is redone
+		return
+	}
+
+	svc.recordAttempt(delivery, code, body, err, time.Now().UTC())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := svc.Repository.UpdateDelivery(ctx, delivery); err != nil {
+		svc.log.Error("failed to record webhook delivery", zap.String("delivery", delivery.ID), zap.Error(err))
+	}
+}
+
+// recordAttempt updates delivery with the outcome of an attempt and
+// schedules the next one if it failed.
+func (svc *WebhookService) recordAttempt(delivery *domain.WebhookDelivery, code int, body []byte, err error, now time.Time) {
+	delivery.Attempts++
+	delivery.LastStatusCode, delivery.LastError, delivery.ResponseBody = nil, nil, nil
+	if code != 0 {
+		delivery.LastStatusCode = &code
+		// text columns reject NUL bytes and invalid UTF-8
+		response := strings.ToValidUTF8(strings.ReplaceAll(string(body), "\x00", ""), "")
+		delivery.ResponseBody = &response
+	}
+	if err == nil && (code < 200 || code > 299) {
+		err = fmt.Errorf("receiver responded with status %d", code)
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.DeliverySucceeded
+		delivery.DeliveredAt = &now
+	case errors.Is(err, domain.ErrWebhookDisabled) || delivery.Attempts >= svc.config.Webhook.MaxAttempts:
+		delivery.Status = domain.DeliveryFailed
+	default:
+		delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+	}
+	if err != nil {
+		message := err.Error()
+		delivery.LastError = &message
+		svc.log.Warn("webhook delivery attempt failed",
+			zap.String("delivery", delivery.ID),
+			zap.String("webhook", delivery.WebhookID),
+			zap.Int("attempt", delivery.Attempts),
+			zap.Error(err))
+	}
+}
+
+// send POSTs the payload signed with the webhook secret. Receivers verify
+// X-Webhook-Signature as the hex HMAC-SHA256 of "<timestamp>.<body>" and
+// should reject stale timestamps to prevent replays.
+func (svc *WebhookService) send(ctx context.Context, delivery *domain.WebhookDelivery) (int, []byte, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "video-backend-webhooks/1")
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signPayload(delivery.Webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, domain.MaxDeliveryResponseBytes))
+	return resp.StatusCode, body, nil
+}
+
+// webhookTransport connects only to public addresses and those within
+// allowed. The check runs on the resolved address of every connection, so a
+// name that passed validation cannot be rebound to an internal host later.
+// Proxies are not used, as the check would only see the proxy.
+func webhookTransport(allowed []netip.Prefix) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !domain.WebhookAddrAllowed(addrPort.Addr(), allowed) {
+				return fmt.Errorf("%w: %s", domain.ErrWebhookAddressNotPublic, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+func signPayload(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff doubles the wait after every failed attempt.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+var WebhookModule = fx.Module("webhook_service",
+	fx.Provide(NewWebhookService),
+	fx.Invoke(func(lc fx.Lifecycle, ws *WebhookService) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				ws.runCtx, ws.cancel = context.WithCancel(context.Background())
+				ws.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				if ws.cancel == nil {
+					return nil
+				}
+				ws.cancel()
+				select {
+				case <-ws.done:
+				case <-ctx.Done():
+				}
+				return nil
+			},
+		})
+	}),
+)
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/domain"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// receiver is a webhook endpoint that answers with the scripted statuses in
+// turn and records what it received.
+type receiver struct {
+	t        *testing.T
+	secret   string
+	statuses []int
+	body     string
+
+	mu       sync.Mutex
+	requests []*http.Request
+	payloads []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	payload, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.t.Error(err)
+	}
+
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.payloads = append(r.payloads, string(payload))
+	status := r.statuses[min(len(r.requests), len(r.statuses))-1]
+	r.mu.Unlock()
+
+	// verified the way the documentation tells receivers to
+	timestamp := req.Header.Get("X-Webhook-Timestamp")
+	mac := hmac.New(sha256.New, []byte(r.secret))
+	mac.Write([]byte(timestamp + "." + string(payload)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(req.Header.Get("X-Webhook-Signature")), []byte(want)) {
+		r.t.Errorf("signature %q does not verify", req.Header.Get("X-Webhook-Signature"))
+	}
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		r.t.Errorf("bad timestamp %q", timestamp)
+	}
+
+	if status == http.StatusFound {
+		w.Header().Set("Location", "http://169.254.169.254/")
+	}
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, r.body)
+}
+
+func newTestWebhookService(maxAttempts int) *WebhookService {
+	cfg := &config.Config{Webhook: config.WebhookConfig{
+		Timeout:     5 * time.Second,
+		MaxAttempts: maxAttempts,
+		// the test receivers listen on loopback
+		AllowedCIDRs: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")},
+	}}
+	return NewWebhookService(cfg, nil, nil, zap.NewNop())
+}
+
+func newTestDelivery(url, secret string) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:      "d1",
+		EventID: "e1",
+		Event:   domain.EventVideoReady,
+		Payload: `{"event":"video.ready","video":{"id":"v1"}}`,
+		Status:  domain.DeliveryPending,
+		Webhook: &domain.Webhook{URL: url, Secret: secret, Active: true},
+	}
+}
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		maxAttempts int
+		want        domain.DeliveryStatus
+		attempts    int
+	}{
+		{"first attempt succeeds", []int{http.StatusAccepted}, 5, domain.DeliverySucceeded, 1},
+		{"succeeds after failures", []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK}, 5, domain.DeliverySucceeded, 3},
+		{"gives up after the last attempt", []int{http.StatusBadGateway}, 3, domain.DeliveryFailed, 3},
+		{"redirects are failures", []int{http.StatusFound}, 2, domain.DeliveryFailed, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recv := &receiver{t: t, secret: "s3cret-s3cret-s3cret", statuses: tt.statuses, body: strings.Repeat("x", domain.MaxDeliveryResponseBytes+500)}
+			server := httptest.NewServer(recv)
+			defer server.Close()
+
+			svc := newTestWebhookService(tt.maxAttempts)
+			delivery := newTestDelivery(server.URL, recv.secret)
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+			for delivery.Status == domain.DeliveryPending {
+				if delivery.Attempts >= tt.maxAttempts {
+					t.Fatalf("still pending after %d attempts", delivery.Attempts)
+				}
+				code, body, err := svc.send(context.Background(), delivery)
+				svc.recordAttempt(delivery, code, body, err, now)
+
+				if delivery.Status == domain.DeliveryPending {
+					if want := now.Add(webhookBackoff(delivery.Attempts)); !delivery.NextAttemptAt.Equal(want) {
+						t.Errorf("attempt %d: next attempt at %s, want %s", delivery.Attempts, delivery.NextAttemptAt, want)
+					}
+					if delivery.LastError == nil {
+						t.Errorf("attempt %d: failure has no error", delivery.Attempts)
+					}
+				}
+				if delivery.ResponseBody == nil || len(*delivery.ResponseBody) != domain.MaxDeliveryResponseBytes {
+					t.Errorf("attempt %d: response body %v is not cut to %d bytes", delivery.Attempts, delivery.ResponseBody, domain.MaxDeliveryResponseBytes)
+				}
+				now = delivery.NextAttemptAt
+			}
+
+			if delivery.Status != tt.want || delivery.Attempts != tt.attempts {
+				t.Errorf("status %s after %d attempts, want %s after %d", delivery.Status, delivery.Attempts, tt.want, tt.attempts)
+			}
+			if (delivery.DeliveredAt != nil) != (tt.want == domain.DeliverySucceeded) {
+				t.Errorf("delivered at = %v", delivery.DeliveredAt)
+			}
+			if len(recv.requests) != tt.attempts {
+				t.Errorf("receiver got %d requests, want %d", len(recv.requests), tt.attempts)
+			}
+			for i, req := range recv.requests {
+				if req.Header.Get("X-Webhook-Id") != "e1" || req.Header.Get("X-Webhook-Delivery") != "d1" || req.Header.Get("X-Webhook-Event") != "video.ready" {
+					t.Errorf("request %d has headers %v", i, req.Header)
+				}
+				if recv.payloads[i] != delivery.Payload {
+					t.Errorf("request %d has payload %q", i, recv.payloads[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryToDisabledWebhook(t *testing.T) {
+	svc := newTestWebhookService(5)
+	delivery := newTestDelivery("https://hooks.example.com/", "s3cret-s3cret-s3cret")
+	delivery.Webhook.Active = false
+
+	svc.recordAttempt(delivery, 0, nil, domain.ErrWebhookDisabled, time.Now())
+	if delivery.Status != domain.DeliveryFailed || delivery.LastStatusCode != nil {
+		t.Errorf("status %s with code %v, want failed without a code", delivery.Status, delivery.LastStatusCode)
+	}
+}
+
+func TestWebhookTransportRefusesInternalAddresses(t *testing.T) {
+	recv := &receiver{t: t, statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		allowed []netip.Prefix
+	}{
+		{"nothing allowed", nil},
+		{"other networks allowed", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Webhook: config.WebhookConfig{Timeout: 5 * time.Second, MaxAttempts: 5, AllowedCIDRs: tt.allowed}}
+			svc := NewWebhookService(cfg, nil, nil, zap.NewNop())
+
+			_, _, err := svc.send(context.Background(), newTestDelivery(server.URL, "s3cret-s3cret-s3cret"))
+			if !errors.Is(err, domain.ErrWebhookAddressNotPublic) {
+				t.Errorf("error = %v, want ErrWebhookAddressNotPublic", err)
+			}
+		})
+	}
+	if len(recv.requests) != 0 {
+		t.Errorf("receiver got %d requests", len(recv.requests))
+	}
+}
@@ -0,0 +1,25 @@
+(function () {
+    var video = document.getElementById("player");
+    if (!video) {
+        return;
+    }
+    var src = video.getAttribute("data-src");
+
+    if (video.canPlayType("application/vnd.apple.mpegurl")) {
+        video.src = src;
+        return;
+    }
+
+    // Browsers without native HLS support fall back to hls.js.
+    var script = document.createElement("script");
+    script.src = "https://cdn.jsdelivr.net/npm/hls.js@1/dist/hls.min.js";
+    script.onload = function () {
+        if (!window.Hls || !window.Hls.isSupported()) {
+            return;
+        }
+        var hls = new window.Hls();
+        hls.loadSource(src);
+        hls.attachMedia(video);
+    };
+    document.head.appendChild(script);
+})();
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"awesomeProject/src/app/auth"
+	"awesomeProject/src/app/bus"
 	"awesomeProject/src/app/cache"
 	"awesomeProject/src/app/config"
 	"awesomeProject/src/app/handler"
 	"awesomeProject/src/app/hls"
+	"awesomeProject/src/app/middleware"
 	"awesomeProject/src/app/repository"
 	"awesomeProject/src/app/server"
 	"awesomeProject/src/app/service"
 	"mime"
+	"os"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/fx"
@@ -24,17 +28,65 @@ func init() {
 }
 
 func main() {
-	fx.New(
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconcile(os.Args[2:])
+		return
+	}
+
+	fx.New(serverApp()).Run()
+}
+
+// serverApp is the dependency graph of the HTTP server.
+func serverApp() fx.Option {
+	return fx.Options(
 		server.Module,
 		handler.HelloModule,
 		handler.VideoModule,
 		handler.MediaModule,
+		handler.StatsModule,
+		handler.CollectionModule,
+		handler.AuthModule,
+		handler.APIKeyModule,
+		handler.ShareModule,
+		handler.DocsModule,
+		handler.BulkModule,
+		handler.RevisionModule,
+		handler.SubtitleModule,
+		handler.AnalyticsModule,
+		handler.WebhookModule,
+		handler.EventModule,
+		middleware.Module,
+		auth.Module,
 		config.Module,
 		config.DbModule,
 		cache.CacheModule,
+		bus.Module,
 		hls.FFmpegPackagerModule,
 		service.VideoModule,
 		service.ConvServiceModule,
+		service.RetentionModule,
+		service.ReconcileModule,
+		service.CollectionModule,
+		service.AuthModule,
+		service.APIKeyModule,
+		service.BulkModule,
+		service.IdempotencyModule,
+		service.RevisionModule,
+		service.SubtitleModule,
+		service.AnalyticsModule,
+		service.WebhookModule,
+		service.EventModule,
 		repository.VideoRepoModule,
-	).Run()
+		repository.AuditRepoModule,
+		repository.CollectionRepoModule,
+		repository.UserRepoModule,
+		repository.APIKeyRepoModule,
+		repository.BulkRepoModule,
+		repository.IdempotencyRepoModule,
+		repository.RevisionRepoModule,
+		repository.SubtitleRepoModule,
+		repository.AnalyticsRepoModule,
+		repository.WebhookRepoModule,
+		repository.EventRepoModule,
+	)
 }
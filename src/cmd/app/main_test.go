@@ -0,0 +1,25 @@
+package main
+
+import (
+	"awesomeProject/src/app/service"
+	"testing"
+
+	"go.uber.org/fx"
+)
+
+// TestGraphs checks that every dependency of the server and the reconcile
+// command is provided; constructors are not run.
+func TestGraphs(t *testing.T) {
+	var svc *service.ReconcileService
+	graphs := map[string]fx.Option{
+		"server":    serverApp(),
+		"reconcile": reconcileApp(&svc),
+	}
+	for name, graph := range graphs {
+		t.Run(name, func(t *testing.T) {
+			if err := fx.ValidateApp(graph, fx.NopLogger); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
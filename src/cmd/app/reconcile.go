@@ -0,0 +1,72 @@
+package main
+
+import (
+	"awesomeProject/src/app/cache"
+	"awesomeProject/src/app/config"
+	"awesomeProject/src/app/repository"
+	"awesomeProject/src/app/server"
+	"awesomeProject/src/app/service"
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+func runReconcile(args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	deleteOrphans := flags.Bool("delete-orphans", false, "delete orphaned files older than the grace period")
+	markBroken := flags.Bool("mark-broken", false, "mark videos with missing artifacts as interrupted")
+	grace := flags.Duration("grace", 0, "grace period before orphans may be deleted")
+	_ = flags.Parse(args)
+
+	var svc *service.ReconcileService
+	app := fx.New(reconcileApp(&svc))
+
+	startCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := app.Start(startCtx); err != nil {
+		log.Fatalln("failed to start reconcile:", err)
+	}
+	defer app.Stop(context.Background())
+
+	opts := svc.DefaultOptions()
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "delete-orphans":
+			opts.DeleteOrphans = *deleteOrphans
+		case "mark-broken":
+			opts.MarkBroken = *markBroken
+		case "grace":
+			opts.GracePeriod = *grace
+		}
+	})
+
+	report, err := svc.Run(context.Background(), opts)
+	if err != nil {
+		log.Fatalln("reconcile failed:", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+}
+
+// reconcileApp is the dependency graph of the reconcile command; it fills
+// svc with the reconcile service.
+func reconcileApp(svc **service.ReconcileService) fx.Option {
+	return fx.Options(
+		fx.NopLogger,
+		fx.Provide(server.NewLogger),
+		config.Module,
+		config.DbModule,
+		cache.CacheModule,
+		repository.VideoRepoModule,
+		repository.RevisionRepoModule,
+		fx.Provide(service.NewReconcileService),
+		fx.Populate(svc),
+	)
+}
@@ -0,0 +1,128 @@
+package util
+
+import (
+	"awesomeProject/src/app/domain"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error document. Code is a stable identifier that
+// clients can switch on; Detail is human readable and may change.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Errors   []ProblemField `json:"errors,omitempty"`
+}
+
+type ProblemField struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// problemKind maps an error to a response. Detail is fixed: wrapped errors
+// may carry internals such as tool output or paths, which only go to the log.
+type problemKind struct {
+	err    error
+	status int
+	code   string
+	detail string
+}
+
+// problemKinds is matched in order with errors.Is, so specific errors must
+// precede the generic ones they wrap.
+var problemKinds = []problemKind{
+	{domain.ErrInvalidCursor, http.StatusUnprocessableEntity, "invalid_cursor", "The cursor is malformed or does not match the requested sort."},
+	{domain.ErrInvalidJSON, http.StatusBadRequest, "invalid_json", "The request body is not valid JSON."},
+	{domain.ErrIncorrectUuid, http.StatusBadRequest, "invalid_uuid", "An identifier is not a valid UUID."},
+	{domain.ErrInvalidIdempotencyKey, http.StatusBadRequest, "invalid_idempotency_key", "The idempotency key must be 1 to 255 printable characters."},
+	{domain.ErrUnsupportedMedia, http.StatusUnprocessableEntity, "unsupported_media", "The file is not a supported video."},
+	{domain.ErrInvalidSubtitles, http.StatusUnprocessableEntity, "invalid_subtitles", "The file is not a valid SRT or WebVTT subtitle file."},
+	{domain.ErrVideoNotFound, http.StatusNotFound, "video_not_found", "The video does not exist."},
+	{domain.ErrCollectionNotFound, http.StatusNotFound, "collection_not_found", "The collection does not exist."},
+	{domain.ErrAPIKeyNotFound, http.StatusNotFound, "api_key_not_found", "The API key does not exist."},
+	{domain.ErrBulkOperationNotFound, http.StatusNotFound, "bulk_operation_not_found", "The bulk operation does not exist."},
+	{domain.ErrRevisionNotFound, http.StatusNotFound, "revision_not_found", "The revision does not exist."},
+	{domain.ErrSubtitleNotFound, http.StatusNotFound, "subtitle_not_found", "The subtitle track does not exist."},
+	{domain.ErrWebhookNotFound, http.StatusNotFound, "webhook_not_found", "The webhook does not exist."},
+	{domain.ErrDeliveryNotFound, http.StatusNotFound, "webhook_delivery_not_found", "The webhook delivery does not exist."},
+	{domain.ErrVideoNotInCollection, http.StatusNotFound, "video_not_in_collection", "The video is not in the collection."},
+	{domain.ErrVideoAlreadyInCollection, http.StatusConflict, "video_already_in_collection", "The video is already in the collection."},
+	{domain.ErrAlreadyArchived, http.StatusConflict, "video_already_archived", "The video is already archived."},
+	{domain.ErrVideoArchived, http.StatusConflict, "video_archived", "The video is archived."},
+	{domain.ErrVideoNotArchived, http.StatusConflict, "video_not_archived", "The video is not archived."},
+	{domain.ErrVideoIsProcessing, http.StatusConflict, "video_processing", "The video is being processed."},
+	{domain.ErrRevisionPending, http.StatusConflict, "revision_pending", "A new source is still being processed."},
+	{domain.ErrRevisionNotReady, http.StatusConflict, "revision_not_ready", "The revision has no playable output."},
+	{domain.ErrWebhookDisabled, http.StatusConflict, "webhook_disabled", "The webhook is disabled."},
+	{domain.ErrVersionMismatch, http.StatusPreconditionFailed, "precondition_failed", "The video was modified since it was read."},
+	{domain.ErrIdempotencyKeyReused, http.StatusConflict, "idempotency_key_reused", "The idempotency key was already used for a different request."},
+	{domain.ErrIdempotencyInProgress, http.StatusConflict, "idempotency_in_progress", "A request with this idempotency key is still in progress."},
+	{domain.ErrUserExists, http.StatusConflict, "user_exists", "A user with this email already exists."},
+	{domain.ErrForbidden, http.StatusForbidden, "forbidden", "The operation is not permitted."},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized", "Authentication is required."},
+	{domain.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials", "The email or password is invalid."},
+	{domain.ErrInvalidToken, http.StatusUnauthorized, "invalid_token", "The token is invalid or expired."},
+}
+
+func ProblemFromError(err error) Problem {
+	var validationErr *domain.ValidationError
+	var validationErrs domain.ValidationErrors
+
+	for _, kind := range problemKinds {
+		if errors.Is(err, kind.err) {
+			p := newProblem(kind.status, kind.code, kind.detail)
+			if errors.As(err, &validationErr) {
+				p.Errors = []ProblemField{{Field: validationErr.Field, Message: validationErr.Message}}
+			}
+			return p
+		}
+	}
+
+	switch {
+	case errors.As(err, &validationErrs):
+		p := newProblem(http.StatusUnprocessableEntity, "validation_failed", "request failed validation")
+		for _, v := range validationErrs {
+			p.Errors = append(p.Errors, ProblemField{Field: v.Field, Message: v.Message})
+		}
+		return p
+	case errors.As(err, &validationErr):
+		p := newProblem(http.StatusUnprocessableEntity, "validation_failed", validationErr.Error())
+		p.Errors = []ProblemField{{Field: validationErr.Field, Message: validationErr.Message}}
+		return p
+	default:
+		return newProblem(http.StatusInternalServerError, "internal_error", "")
+	}
+}
+
+func newProblem(status int, code string, detail string) Problem {
+	return Problem{
+		Type:   "/problems/" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func WriteProblem(ctx *gin.Context, p Problem) {
+	if p.Instance == "" {
+		p.Instance = ctx.Request.URL.Path
+	}
+	ctx.Header("Content-Type", ProblemContentType)
+	ctx.AbortWithStatusJSON(p.Status, p)
+}
+
+// AbortWithError renders err immediately; handlers should prefer ctx.Error
+// and let the error middleware render it.
+func AbortWithError(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	WriteProblem(ctx, ProblemFromError(err))
+}
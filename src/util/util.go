@@ -3,9 +3,7 @@ package util
 import (
 	"awesomeProject/src/app/domain"
 	"crypto/rand"
-	"errors"
 	"math/big"
-	"net/http"
 	"net/url"
 	"path"
 	"strconv"
@@ -40,26 +38,35 @@ func ParsePagination(c *gin.Context) domain.Pagination {
 			p.Offset = uint(n)
 		}
 	}
+	if v, ok := c.GetQuery("cursor"); ok {
+		p.Cursor = &v
+		p.Offset = 0
+	}
+
+	p.WithTotal = !p.IsCursor()
+	if v := c.Query("with_total"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			p.WithTotal = b
+		}
+	}
 
 	p.Normalize()
 	return p
 }
 
-func HttpResponseFromError(err error) (int, map[string]any) {
-	var code int
+const principalKey = "principal"
 
-	switch {
-	case errors.Is(err, domain.ErrVideoNotFound):
-		code = http.StatusNotFound
-	case errors.Is(err, domain.ErrAlreadyArchived):
-		code = http.StatusBadRequest
-	case errors.Is(err, domain.ErrIncorrectUuid):
-		code = http.StatusBadRequest
-	default:
-		code = http.StatusInternalServerError
-	}
+func SetPrincipal(c *gin.Context, principal domain.Principal) {
+	c.Set(principalKey, principal)
+}
 
-	return code, map[string]any{"error": err.Error()}
+func PrincipalFrom(c *gin.Context) domain.Principal {
+	if v, ok := c.Get(principalKey); ok {
+		if principal, ok := v.(domain.Principal); ok {
+			return principal
+		}
+	}
+	return domain.Principal{}
 }
 
 func JoinURL(base string, elems ...string) string {